@@ -30,12 +30,16 @@ add to nodes :
 add to default cluster:
 	sealos add --masters x.x.x.x --nodes x.x.x.x
 	sealos add --masters x.x.x.x-x.x.x.y --nodes x.x.x.x-x.x.x.y
+
+add to external etcd members:
+	sealos add --etcd x.x.x.x
 `
 
 // addCmd represents the delete command
 func newAddCmd() *cobra.Command {
 	addArgs := &apply.ScaleArgs{
-		Cluster: &apply.Cluster{},
+		Cluster:  &apply.Cluster{},
+		PlanArgs: &apply.PlanArgs{},
 	}
 	var addCmd = &cobra.Command{
 		Use:     "add",
@@ -47,18 +51,25 @@ func newAddCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return applier.Apply()
+			if addArgs.IsDryRun() {
+				return apply.PrintPlan(applier, addArgs.PlanArgs)
+			}
+			return applier.Apply(cmd.Context())
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if addArgs.Nodes == "" && addArgs.Masters == "" {
-				return errors.New("nodes and masters can't both be empty")
+			if addArgs.Nodes == "" && addArgs.Masters == "" && addArgs.Etcd == "" {
+				return errors.New("nodes, masters and etcd can't all be empty")
 			}
 			return nil
 		},
 		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if addArgs.IsDryRun() {
+				return
+			}
 			logger.Info(getContact())
 		},
 	}
 	addArgs.RegisterFlags(addCmd.Flags(), "be joined", "join")
+	setCommandDestructive(addCmd)
 	return addCmd
 }
@@ -24,7 +24,9 @@ import (
 var clusterFile string
 
 func newApplyCmd() *cobra.Command {
-	applyArgs := &apply.Args{}
+	applyArgs := &apply.Args{
+		PlanArgs: &apply.PlanArgs{},
+	}
 	// applyCmd represents the apply command
 	var applyCmd = &cobra.Command{
 		Use:     "apply",
@@ -36,13 +38,20 @@ func newApplyCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return applier.Apply()
+			if applyArgs.IsDryRun() {
+				return apply.PrintPlan(applier, applyArgs.PlanArgs)
+			}
+			return applier.Apply(cmd.Context())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
+			if applyArgs.IsDryRun() {
+				return
+			}
 			logger.Info(getContact())
 		},
 	}
 	applyCmd.Flags().StringVarP(&clusterFile, "Clusterfile", "f", "Clusterfile", "apply a kubernetes cluster")
 	applyArgs.RegisterFlags(applyCmd.Flags())
+	applyCmd.Flags().BoolVar(&applyArgs.Resume, "resume", false, "resume the last failed apply of the same Clusterfile, skip the steps already succeeded")
 	return applyCmd
 }
@@ -0,0 +1,139 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply"
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/bundle"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/version"
+)
+
+var exampleBundle = `
+save the images, the Clusterfile and the sealos binary into a single archive:
+	sealos bundle create -f Clusterfile -o site.tar
+
+restore the archive into local storage on an offline host and apply it:
+	sealos bundle load -i site.tar
+
+restore the archive only:
+	sealos bundle load -i site.tar --skip-apply
+`
+
+func newBundleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "bundle",
+		Short:   "Export and import a cluster definition with all its images for air-gapped environments",
+		Example: exampleBundle,
+	}
+	cmd.AddCommand(newBundleCreateCmd())
+	cmd.AddCommand(newBundleLoadCmd())
+	return cmd
+}
+
+func newBundleCreateCmd() *cobra.Command {
+	var (
+		clusterFile string
+		output      string
+		withBinary  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Save the images of Clusterfile, the Clusterfile and the sealos binary into an archive",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bder, err := buildah.New("")
+			if err != nil {
+				return err
+			}
+			m, err := bundle.Create(cmd.Context(), bder, clusterFile, output, withBinary)
+			if err != nil {
+				return err
+			}
+			logger.Info("bundle of cluster %s with %d images is saved to %s", m.ClusterName, len(m.Images), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterFile, "Clusterfile", "f", "Clusterfile", "the Clusterfile to bundle")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of the bundle archive")
+	cmd.Flags().BoolVar(&withBinary, "with-binary", true, "save the running sealos binary into the bundle")
+	_ = cmd.MarkFlagRequired("output")
+	return cmd
+}
+
+func newBundleLoadCmd() *cobra.Command {
+	var (
+		input     string
+		dir       string
+		skipApply bool
+	)
+	applyArgs := &apply.Args{
+		PlanArgs: &apply.PlanArgs{},
+	}
+	cmd := &cobra.Command{
+		Use:   "load",
+		Short: "Restore the images of a bundle into local storage and apply its Clusterfile",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				tmp, err := os.MkdirTemp("", "sealos-bundle")
+				if err != nil {
+					return err
+				}
+				defer os.RemoveAll(tmp)
+				dir = tmp
+			}
+			m, err := bundle.Extract(input, dir)
+			if err != nil {
+				return err
+			}
+			if current := version.Get().GitVersion; m.SealosVersion != current {
+				logger.Warn("bundle is created by sealos %s, but the running one is %s", m.SealosVersion, current)
+			}
+			bder, err := buildah.New(m.ClusterName)
+			if err != nil {
+				return err
+			}
+			clusterfilePath, err := bundle.Load(bder, dir, m)
+			if err != nil {
+				return err
+			}
+			logger.Info("%d images of cluster %s are loaded", len(m.Images), m.ClusterName)
+			if skipApply {
+				return nil
+			}
+			applier, err := apply.NewApplierFromFile(clusterfilePath, applyArgs)
+			if err != nil {
+				return fmt.Errorf("failed to apply Clusterfile of bundle: %v", err)
+			}
+			if applyArgs.IsDryRun() {
+				return apply.PrintPlan(applier, applyArgs.PlanArgs)
+			}
+			return applier.Apply(cmd.Context())
+		},
+	}
+	cmd.Flags().StringVarP(&input, "input", "i", "", "path of the bundle archive")
+	cmd.Flags().StringVar(&dir, "dir", "", "directory to extract the bundle into, the bundle is extracted into a temporary directory and removed after loading if not set")
+	cmd.Flags().BoolVar(&skipApply, "skip-apply", false, "load the images only, do not apply the Clusterfile")
+	applyArgs.RegisterFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired("input")
+	return cmd
+}
@@ -15,16 +15,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
 
 	"github.com/labring/sealos/pkg/apply/processor"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/runtime"
+	"github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
 )
 
 var altNames string
@@ -49,18 +57,9 @@ func newCertCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("get default cluster failed, %v", err)
 			}
-			processor.SyncNewVersionConfig(cluster.Name)
-			clusterPath := constants.Clusterfile(cluster.Name)
-
-			cf := clusterfile.NewClusterFile(clusterPath,
-				clusterfile.WithCustomKubeadmFiles([]string{path.Join(constants.NewData(cluster.Name).EtcPath(), constants.DefaultInitKubeadmFileName)}),
-			)
-			if err = cf.Process(); err != nil {
-				return err
-			}
-			r, err := runtime.NewDefaultRuntime(cluster, cf.GetKubeadmConfig())
+			r, _, err := newRuntimeFromCluster(cmd.Context(), cluster)
 			if err != nil {
-				return fmt.Errorf("get default runtime failed, %v", err)
+				return err
 			}
 			return r.UpdateCert(strings.Split(altNames, ","))
 		},
@@ -73,6 +72,106 @@ func newCertCmd() *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied exec action")
 	cmd.Flags().StringVar(&altNames, "alt-names", "", "add domain or ip in certs, sealos.io or 10.103.97.2")
+	cmd.AddCommand(newCertCheckCmd(), newCertRenewCmd())
 	setCommandUnrelatedToBuildah(cmd)
+	setCommandDestructive(cmd)
 	return cmd
 }
+
+func newCertCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the expiration of the certs in cluster",
+		Long: `Check the expiration of the certs managed by kubeadm and the client certs of kubeconfig on the masters
+and etcd hosts, and the kubelet client certs on all the hosts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			r, _, err := newRuntimeFromCluster(cmd.Context(), cluster)
+			if err != nil {
+				return err
+			}
+			expirations, err := r.CheckCerts()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "HOST\tCERTIFICATE\tEXPIRES\tRESIDUAL TIME\tCA\tRENEWABLE")
+			for _, e := range expirations {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%t\n", e.Host, e.Name, e.NotAfter.Format(time.RFC3339),
+					duration.ShortHumanDuration(time.Until(e.NotAfter)), e.IsCA, e.Renewable)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to check")
+	setCommandUnrelatedToBuildah(cmd)
+	return cmd
+}
+
+func newCertRenewCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "renew",
+		Short: "Renew all the certs managed by kubeadm in cluster",
+		Long: `Renew the certs managed by kubeadm and the client certs of kubeconfig with the cas of cluster, as kubeadm certs renew does.
+The etcd hosts and the masters are renewed one by one and their static pods are restarted in order,
+the kubeconfig of admin is updated on all the hosts at last. The ca certs and kubelet client certs are not renewed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to renew the certs of cluster %s? the control plane will be restarted one by one", cluster.Name)
+				if pass, err := confirm.Confirm(prompt, "you have canceled to renew the certs"); err != nil || !pass {
+					return err
+				}
+			}
+			r, _, err := newRuntimeFromCluster(cmd.Context(), cluster)
+			if err != nil {
+				return err
+			}
+			if err = r.RenewCerts(); err != nil {
+				return err
+			}
+			logger.Info("succeeded in renewing the certs of cluster %s", cluster.Name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to renew")
+	cmd.Flags().BoolVar(&force, "force", false, "renew without confirmation")
+	setCommandUnrelatedToBuildah(cmd)
+	setCommandDestructive(cmd)
+	return cmd
+}
+
+// newRuntimeFromCluster returns the runtime of the cluster together with its processed Clusterfile.
+func newRuntimeFromCluster(ctx context.Context, cluster *v1beta1.Cluster) (runtime.Interface, clusterfile.Interface, error) {
+	processor.SyncNewVersionConfig(cluster.Name)
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(cluster.Name),
+		clusterfile.WithCustomKubeadmFiles([]string{path.Join(constants.NewData(cluster.Name).EtcPath(), constants.DefaultInitKubeadmFileName)}),
+	)
+	if err := cf.Process(); err != nil {
+		return nil, nil, err
+	}
+	r, err := runtime.NewDefaultRuntime(ctx, cluster, cf.GetKubeadmConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("get default runtime failed, %v", err)
+	}
+	return r, cf, nil
+}
+
+// requireKubeadmDistribution rejects the operations which are only implemented by the kubeadm runtime,
+// before anything is confirmed or changed on the cluster.
+func requireKubeadmDistribution(cluster *v1beta1.Cluster, operation string) error {
+	if d := cluster.GetDistribution(); d != v1beta1.KubeadmDistribution {
+		return fmt.Errorf("%s is not supported by cluster %s, it is only supported by distribution %s but the cluster runs %s",
+			operation, cluster.Name, v1beta1.KubeadmDistribution, d)
+	}
+	return nil
+}
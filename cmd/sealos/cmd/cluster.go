@@ -0,0 +1,289 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const defaultClusterName = "default"
+
+// annotationDestructive marks the commands which change the cluster, they ask for confirmation
+// before running against the current cluster, unless the cluster is specified by --cluster.
+const annotationDestructive = "sealos.io/destructive"
+
+var exampleCluster = `
+list the clusters:
+	sealos cluster list
+
+show the details of cluster xxx:
+	sealos cluster describe xxx
+
+make cluster xxx the default cluster of the commands which accept -c/--cluster:
+	sealos cluster use xxx
+
+remove the Clusterfile archives of reset clusters, but keep the latest one of each cluster:
+	sealos cluster prune --keep 1
+`
+
+func newClusterCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cluster",
+		Short:   "Manage the clusters stored under the runtime root",
+		Example: exampleCluster,
+	}
+	cmd.AddCommand(
+		newClusterListCmd(),
+		newClusterDescribeCmd(),
+		newClusterUseCmd(),
+		newClusterPruneCmd(),
+	)
+	for _, c := range cmd.Commands() {
+		setCommandUnrelatedToBuildah(c)
+	}
+	return cmd
+}
+
+// getCurrentClusterName returns the cluster selected by `sealos cluster use`, or the default cluster.
+func getCurrentClusterName() string {
+	if current := clusterfile.GetCurrentClusterName(); current != "" {
+		return current
+	}
+	return defaultClusterName
+}
+
+// useCurrentClusterByDefault makes the --cluster flag of the executing command default to
+// the cluster selected by `sealos cluster use`, the destructive commands ask for confirmation first.
+func useCurrentClusterByDefault(cmd *cobra.Command) {
+	preRun := cmd.PersistentPreRun
+	cmd.PersistentPreRun = nil
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if f := cmd.Flags().Lookup("cluster"); f != nil && !f.Changed {
+			if current := clusterfile.GetCurrentClusterName(); current != "" && current != f.Value.String() {
+				if err := confirmCurrentCluster(cmd, current); err != nil {
+					return err
+				}
+				if err := f.Value.Set(current); err != nil {
+					return fmt.Errorf("failed to use current cluster %s: %v", current, err)
+				}
+			}
+		}
+		if preRun != nil {
+			preRun(cmd, args)
+		}
+		return nil
+	}
+}
+
+func setCommandDestructive(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[annotationDestructive] = "true"
+}
+
+func confirmCurrentCluster(cmd *cobra.Command, current string) error {
+	logger.Info("using the current cluster %s, specify --cluster to use another one", current)
+	if cmd.Annotations[annotationDestructive] == "" {
+		return nil
+	}
+	prompt := fmt.Sprintf("--cluster is not specified, are you sure to run %q against the current cluster %s?", cmd.CommandPath(), current)
+	pass, err := confirm.Confirm(prompt, "you have canceled to run against the current cluster")
+	if err != nil {
+		return err
+	}
+	if !pass {
+		return fmt.Errorf("cancelled, specify the cluster by --cluster")
+	}
+	return nil
+}
+
+func newClusterListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the clusters",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := clusterfile.ListClusterNames()
+			if err != nil {
+				return err
+			}
+			current := getCurrentClusterName()
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "CURRENT\tNAME\tPHASE\tKUBERNETES\tMASTERS\tNODES\tAGE")
+			for _, name := range names {
+				mark := ""
+				if name == current {
+					mark = "*"
+				}
+				cluster, err := clusterfile.GetClusterFromName(name)
+				if err != nil {
+					logger.Debug("failed to load cluster %s: %v", name, err)
+					fmt.Fprintf(w, "%s\t%s\tUnknown\t\t\t\t\n", mark, name)
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\n", mark, name, cluster.Status.Phase,
+					cluster.GetRootfsImage("").Labels[v2.ImageKubeVersionKey],
+					len(cluster.GetMasterIPAndPortList()), len(cluster.GetNodeIPAndPortList()),
+					translateTimestampSince(cluster.CreationTimestamp.Time))
+			}
+			return w.Flush()
+		},
+	}
+	return cmd
+}
+
+func newClusterDescribeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "describe [NAME]",
+		Short: "Show the phase, conditions, images and hosts of a cluster, the current cluster by default",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := getCurrentClusterName()
+			if len(args) > 0 {
+				name = args[0]
+			}
+			cluster, err := clusterfile.GetClusterFromName(name)
+			if err != nil {
+				return err
+			}
+			return describeCluster(os.Stdout, cluster, name == getCurrentClusterName())
+		},
+	}
+	return cmd
+}
+
+func describeCluster(out io.Writer, cluster *v2.Cluster, current bool) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", cluster.Name)
+	fmt.Fprintf(w, "Current:\t%t\n", current)
+	fmt.Fprintf(w, "Clusterfile:\t%s\n", constants.Clusterfile(cluster.Name))
+	fmt.Fprintf(w, "Phase:\t%s\n", cluster.Status.Phase)
+	if !cluster.CreationTimestamp.IsZero() {
+		fmt.Fprintf(w, "Created:\t%s\n", cluster.CreationTimestamp.Format(time.RFC3339))
+	}
+	if checkpoint := cluster.Status.Checkpoint; checkpoint != nil {
+		fmt.Fprintf(w, "Checkpoint:\t%s, %d steps recorded, use --resume to continue\n", checkpoint.Processor, len(checkpoint.Steps))
+	}
+	if backup := cluster.Status.UpgradeBackup; backup != nil {
+		fmt.Fprintf(w, "Upgrade Backup:\t%s -> %s in %s\n", backup.Version, backup.TargetVersion, backup.Dir)
+	}
+
+	fmt.Fprintln(w, "Images:")
+	fmt.Fprintln(w, "  NAME\tIMAGE\tTYPE")
+	for _, m := range cluster.Status.Mounts {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", m.Name, m.ImageName, m.Type)
+	}
+	fmt.Fprintln(w, "Hosts:")
+	fmt.Fprintln(w, "  IP\tROLES")
+	for _, host := range cluster.Spec.Hosts {
+		for _, ip := range host.IPS {
+			fmt.Fprintf(w, "  %s\t%s\n", ip, strings.Join(host.Roles, ","))
+		}
+	}
+	fmt.Fprintln(w, "Conditions:")
+	fmt.Fprintln(w, "  TYPE\tSTATUS\tLAST HEARTBEAT\tREASON\tMESSAGE")
+	for _, c := range cluster.Status.Conditions {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", c.Type, c.Status, c.LastHeartbeatTime.Format(time.RFC3339), c.Reason, c.Message)
+	}
+	return w.Flush()
+}
+
+func newClusterUseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use NAME",
+		Short: "Set the default cluster of the commands which accept -c/--cluster",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := clusterfile.SetCurrentClusterName(args[0]); err != nil {
+				return err
+			}
+			logger.Info("switched to cluster %s", args[0])
+			return nil
+		},
+	}
+	return cmd
+}
+
+func newClusterPruneCmd() *cobra.Command {
+	var (
+		keep      int
+		olderThan time.Duration
+		force     bool
+	)
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the Clusterfile archives left by resetting clusters",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if keep < 0 {
+				return fmt.Errorf("--keep must not be negative")
+			}
+			archives, err := clusterfile.ListResetArchives()
+			if err != nil {
+				return err
+			}
+			pruned := clusterfile.PruneResetArchives(archives, keep, olderThan, time.Now())
+			if len(pruned) == 0 {
+				logger.Info("no reset archive needs to be pruned")
+				return nil
+			}
+			for _, a := range pruned {
+				fmt.Printf("%s\t(reset %s ago)\n", a.Path, translateTimestampSince(a.ResetTime))
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to remove these %d reset archives?", len(pruned))
+				if pass, err := confirm.Confirm(prompt, "you have canceled to prune reset archives"); err != nil || !pass {
+					return err
+				}
+			}
+			for _, a := range pruned {
+				if err = os.Remove(a.Path); err != nil {
+					return err
+				}
+				// the cluster dir only holds the archives after reset, remove it once it is empty
+				_ = os.Remove(filepath.Dir(a.Path))
+			}
+			logger.Info("succeeded in pruning %d reset archives", len(pruned))
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&keep, "keep", 1, "number of the latest reset archives to keep for each cluster")
+	cmd.Flags().DurationVar(&olderThan, "older-than", 0, "only prune the archives reset longer than the duration ago, e.g. 720h")
+	cmd.Flags().BoolVar(&force, "force", false, "prune without confirmation")
+	return cmd
+}
+
+func translateTimestampSince(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/labring/sealos/pkg/apply"
 	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
@@ -38,13 +39,19 @@ delete masters and nodes:
 	sealos delete --masters x.x.x.x --nodes x.x.x.x
 	sealos delete --masters x.x.x.x-x.x.x.y --nodes x.x.x.x-x.x.x.y
 
+delete nodes without waiting for the pods to be evicted longer than 1 minute:
+	sealos delete --nodes x.x.x.x --drain-timeout 1m --ignore-drain-errors
+
 Please note that sealos will delete your master if the --masters parameter is specified.
+The nodes are drained before being deleted, and the etcd members of masters are removed
+only if etcd keeps the quorum afterwards.
 `
 
 // deleteCmd represents the delete command
 func newDeleteCmd() *cobra.Command {
 	deleteArgs := &apply.ScaleArgs{
-		Cluster: &apply.Cluster{},
+		Cluster:  &apply.Cluster{},
+		PlanArgs: &apply.PlanArgs{},
 	}
 	var deleteCmd = &cobra.Command{
 		Use:     "delete",
@@ -52,14 +59,17 @@ func newDeleteCmd() *cobra.Command {
 		Args:    cobra.NoArgs,
 		Example: exampleDelete,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := processor.ConfirmDeleteNodes(); err != nil {
-				return err
-			}
 			applier, err := apply.NewScaleApplierFromArgs(deleteArgs, "delete")
 			if err != nil {
 				return err
 			}
-			return applier.Apply()
+			if deleteArgs.IsDryRun() {
+				return apply.PrintPlan(applier, deleteArgs.PlanArgs)
+			}
+			if err := processor.ConfirmDeleteNodes(); err != nil {
+				return err
+			}
+			return applier.Apply(cmd.Context())
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if deleteArgs.Nodes == "" && deleteArgs.Masters == "" {
@@ -68,10 +78,17 @@ func newDeleteCmd() *cobra.Command {
 			return nil
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
+			if deleteArgs.IsDryRun() {
+				return
+			}
 			logger.Info(getContact())
 		},
 	}
 	deleteArgs.RegisterFlags(deleteCmd.Flags(), "removed", "remove")
-	deleteCmd.Flags().BoolVar(&processor.ForceDelete, "force", false, "we also can input an --force flag to delete cluster by force")
+	deleteCmd.Flags().BoolVar(&processor.ForceDelete, "force", false, "delete without confirmation")
+	deleteCmd.Flags().BoolVar(&runtime.IgnoreDrainErrors, "ignore-drain-errors", false, "go on deleting the nodes which fail to be drained")
+	deleteCmd.Flags().BoolVar(&runtime.DeleteEmptyDirData, "delete-emptydir-data", false, "delete the pods using emptyDir volumes when draining, their data is lost")
+	deleteCmd.Flags().DurationVar(&runtime.DrainTimeout, "drain-timeout", runtime.DrainTimeout, "timeout of draining a node before deleting it, PodDisruptionBudgets are respected until the timeout, zero means no timeout")
+	setCommandDestructive(deleteCmd)
 	return deleteCmd
 }
@@ -0,0 +1,187 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/etcd"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+var exampleEtcdSnapshot = `
+save an etcd snapshot of default cluster:
+	sealos etcd snapshot save
+
+save a snapshot every 6 hours and keep the latest 7 snapshots:
+	sealos etcd snapshot save --schedule 6h --keep 7
+
+list the snapshots of cluster xxx:
+	sealos etcd snapshot list -c xxx
+
+restore the control plane from a snapshot:
+	sealos etcd snapshot restore snapshot-20230101-080000
+`
+
+func newEtcdCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "etcd",
+		Short: "Manage the etcd of cluster",
+	}
+	cmd.AddCommand(newEtcdSnapshotCmd())
+	return cmd
+}
+
+func newEtcdSnapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "snapshot",
+		Short:   "Save, list and restore etcd snapshots, the snapshots are stored alongside the Clusterfile",
+		Example: exampleEtcdSnapshot,
+	}
+	cmd.PersistentFlags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied etcd action")
+	cmd.AddCommand(
+		newEtcdSnapshotSaveCmd(),
+		newEtcdSnapshotListCmd(),
+		newEtcdSnapshotRestoreCmd(),
+	)
+	return cmd
+}
+
+func newEtcdSnapshotSaveCmd() *cobra.Command {
+	var (
+		schedule time.Duration
+		keep     int
+	)
+	cmd := &cobra.Command{
+		Use:   "save [NAME]",
+		Short: "Take an etcd snapshot from master0",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if schedule > 0 && len(args) > 0 {
+				return fmt.Errorf("snapshot name can not be specified with --schedule")
+			}
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			r, _, err := newRuntimeFromCluster(cmd.Context(), cluster)
+			if err != nil {
+				return err
+			}
+			save := func(name string) error {
+				if _, err := etcd.Save(cluster, r, name); err != nil {
+					return err
+				}
+				if keep > 0 {
+					_, err = etcd.Prune(cluster.Name, keep)
+				}
+				return err
+			}
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if err = save(name); err != nil || schedule <= 0 {
+				return err
+			}
+			logger.Info("etcd snapshot of cluster %s will be saved every %s, press Ctrl-C to stop", cluster.Name, schedule)
+			ticker := time.NewTicker(schedule)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-ticker.C:
+					// keep the schedule running, the failure of a single snapshot is only reported
+					if err = save(""); err != nil {
+						logger.Error("failed to save etcd snapshot: %v", err)
+					}
+				}
+			}
+		},
+	}
+	cmd.Flags().DurationVar(&schedule, "schedule", 0, "save snapshots periodically at the interval until interrupted, e.g. 6h")
+	cmd.Flags().IntVar(&keep, "keep", 0, "number of latest snapshots to keep after saving, 0 means keeping all")
+	return cmd
+}
+
+func newEtcdSnapshotListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the etcd snapshots of cluster",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			snapshots, err := etcd.List(clusterName)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tHOST\tKUBERNETES\tSIZE\tCREATED")
+			for _, s := range snapshots {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Host, s.KubernetesVersion,
+					resource.NewQuantity(s.Size, resource.BinarySI).String(), s.CreationTimestamp.Format(time.RFC3339))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newEtcdSnapshotRestoreCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "restore NAME",
+		Short: "Restore the control plane of cluster from an etcd snapshot",
+		Long: `Restore sends the cluster PKI to all masters, recreates the static pods of master0 by kubeadm if they are lost,
+then restores every etcd member from the snapshot. The data written after the snapshot is lost.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			snapshot, err := etcd.Get(cluster.Name, args[0])
+			if err != nil {
+				return err
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to restore cluster %s from etcd snapshot %s created at %s?",
+					cluster.Name, snapshot.Name, snapshot.CreationTimestamp.Format(time.RFC3339))
+				if pass, err := confirm.Confirm(prompt, "you have canceled to restore etcd snapshot"); err != nil || !pass {
+					return err
+				}
+			}
+			r, _, err := newRuntimeFromCluster(cmd.Context(), cluster)
+			if err != nil {
+				return err
+			}
+			if err = etcd.Restore(snapshot, r); err != nil {
+				return err
+			}
+			logger.Info("succeeded in restoring cluster %s from etcd snapshot %s", cluster.Name, snapshot.Name)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "restore without confirmation")
+	setCommandDestructive(cmd)
+	return cmd
+}
@@ -52,13 +52,13 @@ func newExecCmd() *cobra.Command {
 				if err != nil {
 					return err
 				}
-				return execIPCmd.RunCmd(args[0])
+				return execIPCmd.RunCmd(cmd.Context(), args[0])
 			}
 			execRoleCmd, err := ssh.NewExecCmdFromRoles(cluster, roles)
 			if err != nil {
 				return err
 			}
-			return execRoleCmd.RunCmd(args[0])
+			return execRoleCmd.RunCmd(cmd.Context(), args[0])
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			cls, err := clusterfile.GetClusterFromName(clusterName)
@@ -0,0 +1,152 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply"
+	"github.com/labring/sealos/pkg/apply/history"
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+var exampleHistory = `
+list the applied revisions of default cluster:
+	sealos history
+
+list the applied revisions of cluster xxx:
+	sealos history -c xxx
+`
+
+var exampleRollback = `
+roll back the app images and hosts of default cluster to revision 3:
+	sealos rollback --to-revision 3
+
+roll back cluster xxx to revision 3 without confirmation:
+	sealos rollback -c xxx --to-revision 3 --force
+`
+
+func newHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "List the revisions of the Clusterfile recorded by successful applies",
+		Example: exampleHistory,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			revisions, err := history.List(clusterName)
+			if err != nil {
+				return err
+			}
+			if len(revisions) == 0 {
+				logger.Info("no revision of cluster %s is recorded", clusterName)
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "REVISION\tCREATED\tMASTERS\tNODES\tIMAGES")
+			for _, r := range revisions {
+				var images []string
+				for _, img := range r.Images {
+					images = append(images, img.Name)
+				}
+				fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\n", r.Revision, r.CreationTimestamp.Format(time.RFC3339),
+					len(r.Masters), len(r.Nodes), strings.Join(images, ","))
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to list revisions")
+	setCommandUnrelatedToBuildah(cmd)
+	return cmd
+}
+
+func newRollbackCmd() *cobra.Command {
+	var (
+		toRevision int
+		force      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Re-apply the app images and hosts of a previous revision",
+		Long: `Rollback re-applies the app images of the revision and scales the cluster to the hosts of the revision.
+The rootfs image is not changed, use "sealos upgrade rollback" to restore kubernetes instead.`,
+		Example: exampleRollback,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if toRevision <= 0 {
+				return fmt.Errorf("--to-revision must be specified")
+			}
+			revision, err := history.Get(clusterName, toRevision)
+			if err != nil {
+				return err
+			}
+			warnChangedImageDigests(revision)
+			if !force {
+				prompt := fmt.Sprintf("are you sure to roll back cluster %s to revision %d created at %s?",
+					clusterName, revision.Revision, revision.CreationTimestamp.Format(time.RFC3339))
+				if pass, err := confirm.Confirm(prompt, "you have canceled to roll back cluster"); err != nil || !pass {
+					return err
+				}
+			}
+			// the apps of revision are mostly installed already, override them without asking again
+			processor.ForceOverride = true
+			applier, err := apply.NewRollbackApplier(clusterName, revision)
+			if err != nil {
+				return err
+			}
+			if err = applier.Apply(cmd.Context()); err != nil {
+				return err
+			}
+			logger.Info("succeeded in rolling back cluster %s to revision %d", clusterName, revision.Revision)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to roll back")
+	cmd.Flags().IntVar(&toRevision, "to-revision", 0, "the revision to roll back to, see `sealos history`")
+	cmd.Flags().BoolVar(&force, "force", false, "roll back without confirmation")
+	setCommandDestructive(cmd)
+	return cmd
+}
+
+// warnChangedImageDigests warns if the local images are not the ones applied by the revision,
+// e.g. the tags have been pushed again since then.
+func warnChangedImageDigests(revision *history.Revision) {
+	bder, err := buildah.New(clusterName)
+	if err != nil {
+		logger.Debug("failed to check image digests: %v", err)
+		return
+	}
+	for _, img := range revision.Images {
+		if img.Digest == "" {
+			continue
+		}
+		info, err := bder.InspectImage(img.Name)
+		if err != nil {
+			logger.Debug("failed to inspect image %s: %v", img.Name, err)
+			continue
+		}
+		if string(info.FromImageDigest) != img.Digest {
+			logger.Warn("image %s is %s locally but was %s in revision %d, it is rolled back to %s", img.Name, info.FromImageDigest, img.Digest, revision.Revision, img.Reference())
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/runtime"
+	"github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	strutil "github.com/labring/sealos/pkg/utils/strings"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+var exampleNode = `
+cordon the nodes before maintenance:
+	sealos node cordon --ips 192.168.0.4,192.168.0.5
+
+uncordon all the worker nodes:
+	sealos node uncordon -r node
+
+reboot all the worker nodes, two at a time:
+	sealos node reboot -r node --max-unavailable 2
+`
+
+type nodeSelector struct {
+	roles string
+	ips   []string
+}
+
+func (s *nodeSelector) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied action")
+	cmd.Flags().StringVarP(&s.roles, "roles", "r", "", "select the hosts with any of the roles, e.g. master,node")
+	cmd.Flags().StringSliceVar(&s.ips, "ips", []string{}, "select the hosts with ip address")
+}
+
+// hosts returns the masters and nodes of the cluster selected by ips or roles.
+func (s *nodeSelector) hosts(cluster *v1beta1.Cluster) ([]string, error) {
+	if len(s.ips) == 0 && s.roles == "" {
+		return nil, fmt.Errorf("either --ips or --roles is required")
+	}
+	var roles []string
+	if s.roles != "" {
+		roles = strings.Split(s.roles, ",")
+	}
+	selected := iputils.GetHostIPs(s.ips)
+	var hosts []string
+	for _, host := range append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...) {
+		ip := iputils.GetHostIP(host)
+		if len(selected) > 0 && !strutil.In(ip, selected) {
+			continue
+		}
+		if len(roles) > 0 && !hasAnyRole(cluster.GetHostByIP(ip), roles) {
+			continue
+		}
+		if !strutil.In(host, hosts) {
+			hosts = append(hosts, host)
+		}
+	}
+	for _, ip := range selected {
+		if !strutil.In(ip, iputils.GetHostIPs(hosts)) && len(roles) == 0 {
+			return nil, fmt.Errorf("%s is not a master or node of cluster %s", ip, cluster.Name)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts are selected, please check the ips and roles")
+	}
+	return hosts, nil
+}
+
+func hasAnyRole(host *v1beta1.Host, roles []string) bool {
+	if host == nil {
+		return false
+	}
+	for _, role := range host.Roles {
+		if strutil.In(role, roles) {
+			return true
+		}
+	}
+	return false
+}
+
+func newNodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "node",
+		Short:   "Maintain the nodes of cluster",
+		Example: exampleNode,
+	}
+	cmd.AddCommand(
+		newNodeCordonCmd("cordon", "Mark the nodes as unschedulable", runtime.Interface.CordonNodes),
+		newNodeCordonCmd("uncordon", "Mark the nodes as schedulable", runtime.Interface.UncordonNodes),
+		newNodeRebootCmd(),
+	)
+	return cmd
+}
+
+func newNodeCordonCmd(use, short string, fn func(runtime.Interface, []string) error) *cobra.Command {
+	selector := &nodeSelector{}
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			hosts, err := selector.hosts(cluster)
+			if err != nil {
+				return err
+			}
+			r, _, err := newRuntimeFromCluster(cmd.Context(), cluster)
+			if err != nil {
+				return err
+			}
+			return fn(r, hosts)
+		},
+	}
+	selector.addFlags(cmd)
+	setCommandUnrelatedToBuildah(cmd)
+	return cmd
+}
+
+func newNodeRebootCmd() *cobra.Command {
+	var (
+		maxUnavailable int
+		force          bool
+	)
+	selector := &nodeSelector{}
+	cmd := &cobra.Command{
+		Use:   "reboot",
+		Short: "Reboot the nodes in batches safely",
+		Long: `Reboot the selected hosts in batches, the masters are rebooted one by one and then the other nodes
+at most --max-unavailable at a time. Each node is cordoned, drained, rebooted and waited for ssh and the Ready
+condition, the lvscare static pod is synced and the node is uncordoned at last unless it was cordoned before. The rollout stops at the
+first batch which fails, and the failed nodes are left cordoned.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			if err = requireKubeadmDistribution(cluster, "rebooting nodes"); err != nil {
+				return err
+			}
+			hosts, err := selector.hosts(cluster)
+			if err != nil {
+				return err
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to reboot %v of cluster %s?", hosts, cluster.Name)
+				if pass, err := confirm.Confirm(prompt, "you have canceled to reboot the nodes"); err != nil || !pass {
+					return err
+				}
+			}
+			r, cf, err := newRuntimeFromCluster(cmd.Context(), cluster)
+			if err != nil {
+				return err
+			}
+			// the merged dirs of images are empty after the host running sealos is rebooted,
+			// make sure the images are mounted before syncing files to the rebooted nodes
+			bder, err := buildah.New(cluster.Name)
+			if err != nil {
+				return err
+			}
+			if err = processor.MountClusterImages(cluster, bder); err != nil {
+				return err
+			}
+			obj := []interface{}{cluster}
+			for _, config := range cf.GetConfigs() {
+				obj = append(obj, config)
+			}
+			if err = yaml.MarshalYamlToFile(constants.Clusterfile(cluster.Name), obj...); err != nil {
+				return err
+			}
+			if err = r.RebootNodes(hosts, maxUnavailable); err != nil {
+				return err
+			}
+			logger.Info("succeeded in rebooting %v", hosts)
+			return nil
+		},
+	}
+	selector.addFlags(cmd)
+	cmd.Flags().IntVar(&maxUnavailable, "max-unavailable", 1, "max number of worker nodes rebooted at a time, masters are always rebooted one by one")
+	cmd.Flags().DurationVar(&runtime.DrainTimeout, "drain-timeout", runtime.DrainTimeout, "timeout of draining a node before rebooting it, zero means no timeout")
+	cmd.Flags().BoolVar(&runtime.DeleteEmptyDirData, "delete-emptydir-data", false, "delete the pods using emptyDir volumes when draining, their data is lost")
+	cmd.Flags().BoolVar(&runtime.SkipDrain, "skip-drain", false, "only cordon the nodes before rebooting, the pods are not evicted")
+	cmd.Flags().DurationVar(&runtime.RebootTimeout, "timeout", runtime.RebootTimeout, "timeout of waiting for a node to be ready after rebooting")
+	cmd.Flags().BoolVar(&force, "force", false, "reboot without confirmation")
+	setCommandDestructive(cmd)
+	return cmd
+}
@@ -0,0 +1,150 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/runtime"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+var exampleReconfigure = `
+show the changes of control plane in the Clusterfile without applying:
+	sealos reconfigure -f Clusterfile --dry-run
+
+apply the feature gates and extra args of control plane, and send a new audit policy:
+	sealos reconfigure -f Clusterfile --audit-policy audit-policy.yml
+`
+
+func newReconfigureCmd() *cobra.Command {
+	var (
+		clusterFile string
+		auditPolicy string
+		dryRun      bool
+		force       bool
+	)
+	cmd := &cobra.Command{
+		Use:   "reconfigure",
+		Short: "Reconfigure the control plane of a running cluster from the kubeadm config in Clusterfile",
+		Long: `Apply the changes of ClusterConfiguration in Clusterfile to a running cluster, including featureGates, certSANs,
+extraArgs and extraVolumes of apiServer, controllerManager and scheduler. The static pods are regenerated on the masters
+one by one, the rollout stops at the first master which is not healthy and its manifests are restored.
+The kubeadm-config ConfigMap is updated after all the masters are reconfigured.`,
+		Example: exampleReconfigure,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			if err = requireKubeadmDistribution(cluster, "reconfiguring control plane"); err != nil {
+				return err
+			}
+			cf := clusterfile.NewClusterFile(clusterFile)
+			if err = cf.Process(); err != nil {
+				return err
+			}
+			if cf.GetKubeadmConfig() == nil {
+				return fmt.Errorf("no kubeadm configuration found in %s", clusterFile)
+			}
+			r, err := runtime.NewDefaultRuntime(cmd.Context(), cluster, cf.GetKubeadmConfig())
+			if err != nil {
+				return fmt.Errorf("get default runtime failed, %v", err)
+			}
+			changes, err := r.DiffClusterConfig()
+			if err != nil {
+				return err
+			}
+			if len(changes) == 0 && auditPolicy == "" {
+				logger.Info("control plane of cluster %s is up to date", cluster.Name)
+				return nil
+			}
+			if err = printConfigChanges(changes, auditPolicy); err != nil {
+				return err
+			}
+			if dryRun {
+				return nil
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to reconfigure control plane of cluster %s? the masters will be restarted one by one", cluster.Name)
+				if pass, err := confirm.Confirm(prompt, "you have canceled to reconfigure the cluster"); err != nil || !pass {
+					return err
+				}
+			}
+			if err = r.Reconfigure(auditPolicy); err != nil {
+				return err
+			}
+			logger.Info("succeeded in reconfiguring cluster %s", cluster.Name)
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !file.IsExist(clusterFile) {
+				return fmt.Errorf("the Clusterfile %s is not found", clusterFile)
+			}
+			if auditPolicy != "" && !file.IsExist(auditPolicy) {
+				return fmt.Errorf("the audit policy %s is not found", auditPolicy)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to reconfigure")
+	cmd.Flags().StringVarP(&clusterFile, "file", "f", "Clusterfile", "path of the Clusterfile with the desired kubeadm config")
+	cmd.Flags().StringVar(&auditPolicy, "audit-policy", "", "audit policy file sent to the audit-policy-file of api-server on masters")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the changes without applying")
+	cmd.Flags().BoolVar(&force, "force", false, "reconfigure without confirmation")
+	setCommandUnrelatedToBuildah(cmd)
+	setCommandDestructive(cmd)
+	return cmd
+}
+
+func printConfigChanges(changes []runtime.ConfigChange, auditPolicy string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tCURRENT\tDESIRED")
+	for _, change := range changes {
+		current, err := formatConfigValue(change.Current)
+		if err != nil {
+			return err
+		}
+		desired, err := formatConfigValue(change.Desired)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", change.Path, current, desired)
+	}
+	if auditPolicy != "" {
+		fmt.Fprintf(w, "auditPolicy\t-\t%s\n", auditPolicy)
+	}
+	return w.Flush()
+}
+
+func formatConfigValue(v interface{}) (string, error) {
+	if v == nil {
+		return "<none>", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -51,7 +51,7 @@ func newResetCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return applier.Delete()
+			return applier.Delete(cmd.Context())
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
 			logger.Info(getContact())
@@ -59,5 +59,6 @@ func newResetCmd() *cobra.Command {
 	}
 	resetArgs.RegisterFlags(resetCmd.Flags())
 	resetCmd.Flags().BoolVar(&processor.ForceDelete, "force", false, "we also can input an --force flag to reset cluster by force")
+	setCommandDestructive(resetCmd)
 	return resetCmd
 }
@@ -15,11 +15,18 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 
 	"github.com/labring/sealos/pkg/buildah"
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/system"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -29,7 +36,12 @@ import (
 )
 
 var (
-	debug bool
+	debug       bool
+	parallelism int
+	eventsFile  string
+	progress    bool
+	// eventsOut is the file opened for --events-file, it is closed before exit
+	eventsOut *os.File
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -44,18 +56,52 @@ var rootCmd = &cobra.Command{
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		// restore the default behavior, so the second signal terminates immediately
+		signal.Stop(sigCh)
+		logger.Warn("Received signal %s, waiting for the running steps to stop, send it again to force exit", sig)
+		cancel()
+	}()
+	err := rootCmd.ExecuteContext(ctx)
+	// os.Exit skips the deferred calls
+	ssh.ClosePool()
+	closeEventsFile()
+	if err != nil {
 		if rootCmd.SilenceErrors {
 			fmt.Println(err)
 		}
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitCodeError makes sealos exit with the code instead of 1.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
 func init() {
 	cobra.OnInitialize(onBootOnDie)
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug logger")
+	rootCmd.PersistentFlags().IntVar(&parallelism, "parallelism", 0,
+		fmt.Sprintf("max number of hosts to operate concurrently, 0 means no limit, it overrides the config %s", system.ParallelismConfigKey))
+	rootCmd.PersistentFlags().StringVar(&eventsFile, "events-file", "", "append the events of running steps to the file as json lines, - means stdout and moves the logs and output of remote commands to stderr")
+	rootCmd.PersistentFlags().BoolVar(&progress, "progress", false, "print the progress of running steps to stderr")
 	buildah.RegisterRootCommand(rootCmd)
+	useCurrentClusterByDefault(rootCmd)
 
 	groups := templates.CommandGroups{
 		{
@@ -63,9 +109,18 @@ func init() {
 			Commands: []*cobra.Command{
 				newApplyCmd(),
 				newCertCmd(),
+				newReconfigureCmd(),
 				newRunCmd(),
+				newUninstallCmd(),
 				newResetCmd(),
 				newStatusCmd(),
+				newUpgradeCmd(),
+				newEtcdCmd(),
+				newNodeCmd(),
+				newBundleCmd(),
+				newClusterCmd(),
+				newHistoryCmd(),
+				newRollbackCmd(),
 			},
 		},
 		{
@@ -80,6 +135,7 @@ func init() {
 			Commands: []*cobra.Command{
 				newExecCmd(),
 				newScpCmd(),
+				newSSHKeysCmd(),
 			},
 		},
 		{
@@ -99,6 +155,9 @@ func setCommandUnrelatedToBuildah(cmd *cobra.Command) {
 }
 
 func onBootOnDie() {
+	if rootCmd.PersistentFlags().Changed("parallelism") {
+		errExit(system.Set(system.ParallelismConfigKey, strconv.Itoa(parallelism)))
+	}
 	val, err := system.Get(system.DataRootConfigKey)
 	errExit(err)
 	constants.DefaultClusterRootFsDir = val
@@ -111,9 +170,46 @@ func onBootOnDie() {
 		constants.Workdir(),
 	}
 	errExit(file.MkDirs(rootDirs...))
+	// event sinks are registered first, since they may move the console logs
+	// to stderr before the logger is configured.
+	errExit(registerEventSinks())
 	logger.CfgConsoleAndFileLogger(debug, constants.LogPath(), "sealos", false)
 }
 
+func registerEventSinks() error {
+	switch eventsFile {
+	case "":
+	case "-":
+		// the console logs and the output of remote commands are moved to stderr
+		// to keep the json lines parsable, the output of sealos commands such as
+		// `status -o json` is still printed to stdout.
+		events.Register(events.NewJSONLinesSink(os.Stdout))
+		logger.SetConsoleOutput(os.Stderr)
+		ssh.SetCommandOutput(os.Stderr)
+	default:
+		// json lines are written without buffering, the file is closed before exit
+		f, err := os.OpenFile(eventsFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open events file: %v", err)
+		}
+		eventsOut = f
+		events.Register(events.NewJSONLinesSink(f))
+	}
+	if progress {
+		events.Register(events.NewProgressSink(os.Stderr))
+	}
+	return nil
+}
+
+func closeEventsFile() {
+	if eventsOut == nil {
+		return
+	}
+	if err := eventsOut.Close(); err != nil {
+		logger.Warn("failed to close events file: %v", err)
+	}
+}
+
 func errExit(err error) {
 	if err != nil {
 		logger.Error(err)
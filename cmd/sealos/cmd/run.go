@@ -44,6 +44,10 @@ create cluster to your baremetal server, appoint the iplist:
     sealos run -e defaultVIP=10.103.97.2 labring/kubernetes:v1.24.0 --masters 192.168.0.2,192.168.0.3,192.168.0.4 \
 	--nodes 192.168.0.5,192.168.0.6,192.168.0.7 --passwd 'xxx'
   
+  External etcd on dedicated hosts:
+	sealos run labring/kubernetes:v1.24.0 --masters 192.168.0.2,192.168.0.3,192.168.0.4 \
+	--etcd 192.168.0.8,192.168.0.9,192.168.0.10 --nodes 192.168.0.5,192.168.0.6,192.168.0.7 --passwd 'xxx'
+  
   Single kubernetes cluster:
 	sealos run labring/kubernetes:v1.24.0 --single
   
@@ -55,8 +59,9 @@ create a cluster with custom environment variables:
 
 func newRunCmd() *cobra.Command {
 	runArgs := &apply.RunArgs{
-		Cluster: &apply.Cluster{},
-		SSH:     &apply.SSH{},
+		Cluster:  &apply.Cluster{},
+		SSH:      &apply.SSH{},
+		PlanArgs: &apply.PlanArgs{},
 	}
 	var transport string
 	var runCmd = &cobra.Command{
@@ -74,12 +79,18 @@ func newRunCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return applier.Apply()
+			if runArgs.IsDryRun() {
+				return apply.PrintPlan(applier, runArgs.PlanArgs)
+			}
+			return applier.Apply(cmd.Context())
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return buildah.ValidateTransport(transport)
 		},
 		PostRun: func(cmd *cobra.Command, args []string) {
+			if runArgs.IsDryRun() {
+				return
+			}
 			logger.Info(getContact())
 		},
 	}
@@ -89,7 +100,9 @@ func newRunCmd() *cobra.Command {
 		logger.Error(err)
 	}
 	runCmd.Flags().BoolVarP(&processor.ForceOverride, "force", "f", false, "force override app in this cluster")
+	runCmd.Flags().BoolVar(&runArgs.Resume, "resume", false, "resume the last failed run of this cluster, skip the steps already succeeded")
 	runCmd.Flags().StringVarP(&transport, "transport", "t", buildah.OCIArchive,
 		fmt.Sprintf("load image transport from tar archive file.(optional value: %s, %s)", buildah.OCIArchive, buildah.DockerArchive))
+	setCommandDestructive(runCmd)
 	return runCmd
 }
@@ -53,13 +53,13 @@ func newScpCmd() *cobra.Command {
 				if err != nil {
 					return err
 				}
-				return sshCmd.RunCopy(args[0], args[1])
+				return sshCmd.RunCopy(cmd.Context(), args[0], args[1])
 			}
 			sshCmd, err := ssh.NewExecCmdFromRoles(cluster, roles)
 			if err != nil {
 				return err
 			}
-			return sshCmd.RunCopy(args[0], args[1])
+			return sshCmd.RunCopy(cmd.Context(), args[0], args[1])
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			cls, err := clusterfile.GetClusterFromName(clusterName)
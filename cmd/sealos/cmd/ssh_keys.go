@@ -0,0 +1,156 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/system"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+var exampleSSHKeys = `
+trust the current host keys of all the hosts of the cluster:
+	sealos ssh-keys rescan
+
+trust the new host key of a re-imaged machine:
+	sealos ssh-keys rescan --hosts 192.168.0.2
+`
+
+func newSSHKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ssh-keys",
+		Short: "Manage the host keys in the known_hosts file of the cluster",
+		Long: fmt.Sprintf(`The host keys of ssh connections are verified by the known_hosts file next to the Clusterfile,
+the policy is set by the config %s: strict rejects unknown hosts, accept-new records the hosts on
+first contact and insecure accepts any keys. A changed host key is always rejected unless it is rescanned.`, system.HostKeyPolicyConfigKey),
+		Example: exampleSSHKeys,
+	}
+	cmd.AddCommand(newSSHKeysRescanCmd())
+	setCommandUnrelatedToBuildah(cmd)
+	return cmd
+}
+
+func newSSHKeysRescanCmd() *cobra.Command {
+	var (
+		hosts   []string
+		timeout time.Duration
+		force   bool
+	)
+	cmd := &cobra.Command{
+		Use:   "rescan",
+		Short: "Scan the host keys of the hosts again and trust them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			targets, err := getClusterAddrs(cluster, hosts)
+			if err != nil {
+				return err
+			}
+			addrs, keys, err := scanHostKeys(cmd.Context(), cluster, targets, timeout)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "HOST\tTYPE\tFINGERPRINT")
+			for _, addr := range addrs {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", addr, keys[addr].Type(), gossh.FingerprintSHA256(keys[addr]))
+			}
+			if err = w.Flush(); err != nil {
+				return err
+			}
+			if !force {
+				prompt := "are you sure to trust the host keys above?"
+				if pass, err := confirm.Confirm(prompt, "you have canceled to rescan the host keys"); err != nil || !pass {
+					return err
+				}
+			}
+			file := ssh.KnownHostsFile(cluster.Name)
+			if err = ssh.ReplaceKnownHosts(file, keys); err != nil {
+				return err
+			}
+			logger.Info("host keys of %d hosts are saved to %s", len(keys), file)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to rescan the host keys")
+	cmd.Flags().StringSliceVar(&hosts, "hosts", nil, "ips of the hosts to rescan, all the hosts of the cluster if not set")
+	cmd.Flags().DurationVar(&timeout, "timeout", 10*time.Second, "timeout of connecting to a host")
+	cmd.Flags().BoolVar(&force, "force", false, "trust the host keys without confirmation")
+	return cmd
+}
+
+// getClusterAddrs returns the addresses with ssh port of the hosts in the cluster, all the hosts if ips is empty.
+func getClusterAddrs(cluster *v2.Cluster, ips []string) ([]string, error) {
+	all := cluster.GetAllIPS()
+	if len(ips) == 0 {
+		return all, nil
+	}
+	var addrs []string
+	for _, ip := range ips {
+		found := false
+		for _, addr := range all {
+			if iputils.GetHostIP(addr) == iputils.GetHostIP(strings.TrimSpace(ip)) {
+				addrs = append(addrs, addr)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("host %s is not found in cluster %s", ip, cluster.Name)
+		}
+	}
+	return addrs, nil
+}
+
+// scanHostKeys returns the host keys indexed by the addresses connected by ssh, and the addresses in the order of hosts.
+func scanHostKeys(ctx context.Context, cluster *v2.Cluster, hosts []string, timeout time.Duration) ([]string, map[string]gossh.PublicKey, error) {
+	addrs := make([]string, len(hosts))
+	keys := make([]gossh.PublicKey, len(hosts))
+	eg, _ := errgroup.WithContext(ctx)
+	eg.SetLimit(system.GetParallelism())
+	for i := range hosts {
+		i := i
+		eg.Go(func() (err error) {
+			addrs[i], keys[i], err = ssh.ScanHostKey(cluster, hosts[i], timeout)
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, nil, err
+	}
+	result := make(map[string]gossh.PublicKey, len(hosts))
+	for i, addr := range addrs {
+		result[addr] = keys[i]
+	}
+	return addrs, result, nil
+}
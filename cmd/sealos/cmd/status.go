@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/labring/sealos/pkg/checker"
 	"github.com/labring/sealos/pkg/clusterfile"
@@ -23,22 +24,52 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// exitCodeStatusFailed is the exit code of status if any checker fails.
+const exitCodeStatusFailed = 2
+
+var exampleStatus = `
+print the status of default cluster:
+	sealos status
+
+print the status of cluster xxx in json, the exit code is 2 if any checker fails:
+	sealos status -c xxx -o json
+`
+
 // newStatusCmd
 func newStatusCmd() *cobra.Command {
+	var output string
 	checkCmd := &cobra.Command{
 		Use:   "status",
 		Short: "state of sealos",
-		Args:  cobra.NoArgs,
+		Long: `Run the registry, cri-shim, crictl, initsystem, node, pod, svc and cluster checkers and print their results.
+The exit code is 0 if all checkers pass, 2 if any checker fails, and 1 if the status can not be collected.`,
+		Example: exampleStatus,
+		Args:    cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			switch output {
+			case checker.OutputText, checker.OutputJSON, checker.OutputYAML:
+			default:
+				return fmt.Errorf("unsupported output format %s, only %s, %s and %s are allowed", output, checker.OutputText, checker.OutputJSON, checker.OutputYAML)
+			}
 			cluster, err := clusterfile.GetClusterFromName(clusterName)
 			if err != nil {
 				return fmt.Errorf("get default cluster failed, %v", err)
 			}
-			list := []checker.Interface{checker.NewRegistryChecker(), checker.NewCRIShimChecker(), checker.NewCRICtlChecker(), checker.NewInitSystemChecker(), checker.NewNodeChecker(), checker.NewPodChecker(), checker.NewSvcChecker(), checker.NewClusterChecker()}
-			return checker.RunCheckList(list, cluster, checker.PhasePost)
+			list := []checker.Reporter{checker.NewRegistryChecker(), checker.NewCRIShimChecker(), checker.NewCRICtlChecker(), checker.NewInitSystemChecker(), checker.NewNodeChecker(), checker.NewPodChecker(), checker.NewSvcChecker(), checker.NewClusterChecker()}
+			report := checker.RunReportList(list, cluster)
+			if err = report.Print(os.Stdout, output); err != nil {
+				return err
+			}
+			if !report.Passed {
+				cmd.SilenceUsage = true
+				return &exitCodeError{code: exitCodeStatusFailed, err: fmt.Errorf("status of cluster %s is unhealthy", cluster.Name)}
+			}
+			return nil
 		},
 	}
 	checkCmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to applied status action")
+	checkCmd.Flags().StringVarP(&output, "output", "o", checker.OutputText,
+		fmt.Sprintf("output format of the status, one of %s|%s|%s", checker.OutputText, checker.OutputJSON, checker.OutputYAML))
 	setCommandUnrelatedToBuildah(checkCmd)
 	return checkCmd
 }
@@ -0,0 +1,69 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/apply/applydrivers"
+	"github.com/labring/sealos/pkg/clusterfile"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/confirm"
+)
+
+var exampleUninstall = `
+uninstall an app from default cluster:
+	sealos uninstall labring/helm:v3.8.2
+
+uninstall apps from cluster xxx without confirmation:
+	sealos uninstall -c xxx labring/openebs:v3.4.0 labring/helm:v3.8.2 --force
+`
+
+func newUninstallCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "uninstall IMAGE...",
+		Short: "Uninstall application images from cluster",
+		Long: fmt.Sprintf(`Uninstall runs the command declared by the image label %q in the app workdir on master0,
+then removes the app from the Clusterfile, deletes its container and cleans its workdir on all hosts.
+The apps are uninstalled in the reverse order of the arguments.`, v2.ImageUninstallKey),
+		Example: exampleUninstall,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to uninstall these apps from cluster %s? \n%s\t", cluster.Name, strings.Join(args, "\n"))
+				if pass, err := confirm.Confirm(prompt, "you have canceled to uninstall these apps"); err != nil || !pass {
+					return err
+				}
+			}
+			applier, err := applydrivers.NewDefaultApplier(cluster, nil, nil)
+			if err != nil {
+				return err
+			}
+			return applier.Uninstall(cmd.Context(), args)
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to uninstall apps from")
+	cmd.Flags().BoolVar(&force, "force", false, "uninstall without confirmation")
+	setCommandDestructive(cmd)
+	return cmd
+}
@@ -0,0 +1,109 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/utils/confirm"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+var exampleUpgradeRollback = `
+roll back the failed upgrade of default cluster:
+	sealos upgrade rollback
+
+roll back and restore etcd from the snapshot taken before upgrading:
+	sealos upgrade rollback -c xxx --restore-etcd
+`
+
+func newUpgradeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Manage the kubernetes upgrade of cluster",
+		Long: `Kubernetes is upgraded by running a newer kubernetes image, e.g. sealos run labring/kubernetes:v1.25.6,
+the nodes are upgraded according to the upgrade strategy in Clusterfile and backed up before upgrading.`,
+	}
+	cmd.AddCommand(newUpgradeRollbackCmd())
+	return cmd
+}
+
+func newUpgradeRollbackCmd() *cobra.Command {
+	var (
+		restoreEtcd bool
+		force       bool
+	)
+	cmd := &cobra.Command{
+		Use:     "rollback",
+		Short:   "Roll back the last failed kubernetes upgrade from its backup",
+		Example: exampleUpgradeRollback,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cluster, err := clusterfile.GetClusterFromName(clusterName)
+			if err != nil {
+				return fmt.Errorf("get default cluster failed, %v", err)
+			}
+			if err = requireKubeadmDistribution(cluster, "rolling back an upgrade"); err != nil {
+				return err
+			}
+			backup := cluster.Status.UpgradeBackup
+			if backup == nil {
+				return fmt.Errorf("no upgrade backup found in cluster %s, only a failed upgrade can be rolled back", cluster.Name)
+			}
+			if !force {
+				prompt := fmt.Sprintf("are you sure to roll back kubernetes %s to %s?", backup.TargetVersion, backup.Version)
+				if pass, err := confirm.Confirm(prompt, "you have canceled to roll back the upgrade"); err != nil || !pass {
+					return err
+				}
+			}
+			r, cf, err := newRuntimeFromCluster(cmd.Context(), cluster)
+			if err != nil {
+				return err
+			}
+			if err = r.RollbackUpgrade(restoreEtcd); err != nil {
+				return err
+			}
+			bder, err := buildah.New(cluster.Name)
+			if err != nil {
+				return err
+			}
+			for _, mount := range cluster.RemoveRootfsImage(backup.TargetVersion) {
+				if err = bder.Delete(mount.Name); err != nil {
+					logger.Warn("failed to delete container %s of image %s: %v", mount.Name, mount.ImageName, err)
+				}
+			}
+			obj := []interface{}{cluster}
+			for _, config := range cf.GetConfigs() {
+				obj = append(obj, config)
+			}
+			if err = yaml.MarshalYamlToFile(constants.Clusterfile(cluster.Name), obj...); err != nil {
+				return err
+			}
+			logger.Info("succeeded in rolling back cluster %s to kubernetes %s", cluster.Name, backup.Version)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "default", "name of cluster to roll back")
+	cmd.Flags().BoolVar(&restoreEtcd, "restore-etcd", false, "restore etcd of all masters from the snapshot taken before upgrading, otherwise only the kubeadm-config ConfigMap is restored")
+	cmd.Flags().BoolVar(&force, "force", false, "roll back without confirmation")
+	setCommandDestructive(cmd)
+	return cmd
+}
@@ -164,7 +164,7 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	}
 
 	// apply new clusterfile
-	if err := applyClusterfile(c, EIP, newClusterFile, getSealosVersion(cluster), getSealosArch(infra)); err != nil {
+	if err := applyClusterfile(ctx, c, EIP, newClusterFile, getSealosVersion(cluster), getSealosArch(infra)); err != nil {
 		r.recorder.Event(cluster, corev1.EventTypeWarning, "ApplyClusterfile", err.Error())
 		if err := r.updateStatus(ctx, client.ObjectKeyFromObject(cluster), v1.Failed.String()); err != nil {
 			r.recorder.Event(cluster, corev1.EventTypeWarning, "UpdateClusterStatus", err.Error())
@@ -318,13 +318,13 @@ func getSSHclient(infra *infrav1.Infra) ssh.Interface {
 }
 
 // Apply clusterfile on infra
-func applyClusterfile(c ssh.Interface, EIP, clusterfile, sealosVersion string, sealosArch string) error {
+func applyClusterfile(ctx context.Context, c ssh.Interface, EIP, clusterfile, sealosVersion string, sealosArch string) error {
 	createClusterfile := fmt.Sprintf(`tee /root/Clusterfile <<EOF
 %s
 EOF`, clusterfile)
 
 	// check sealos version
-	out, err := c.Cmd(EIP, sealosVersionCmd)
+	out, err := c.Cmd(ctx, EIP, sealosVersionCmd)
 	downloadSealos := fmt.Sprintf(downloadSealosCmd, sealosVersion, sealosArch)
 	if err == nil {
 		currentVersion, err2 := parseSealosVersion(out)
@@ -332,20 +332,20 @@ EOF`, clusterfile)
 			return fmt.Errorf("parse sealos version failed: %v", err2)
 		}
 		if *currentVersion != sealosVersion {
-			if err = c.CmdAsync(EIP, downloadSealos); err != nil {
+			if err = c.CmdAsync(ctx, EIP, downloadSealos); err != nil {
 				return fmt.Errorf("download sealos failed: %v", err)
 			}
 		}
 	} else {
-		if err = c.CmdAsync(EIP, downloadSealos); err != nil {
+		if err = c.CmdAsync(ctx, EIP, downloadSealos); err != nil {
 			return fmt.Errorf("download sealos failed: %v", err)
 		}
 	}
 
-	if err = c.CmdAsync(EIP, createClusterfile); err != nil {
+	if err = c.CmdAsync(ctx, EIP, createClusterfile); err != nil {
 		return fmt.Errorf("create clusterfile failed: %v", err)
 	}
-	if err = c.CmdAsync(EIP, applyClusterfileCmd); err != nil {
+	if err = c.CmdAsync(ctx, EIP, applyClusterfileCmd); err != nil {
 		return fmt.Errorf("apply clusterfile failed: %v", err)
 	}
 	return nil
@@ -367,7 +367,7 @@ func parseSealosVersion(out []byte) (*string, error) {
 func getMasterClusterfile(c ssh.Interface, EIP string) (*v1.Cluster, error) {
 	path := filepath.Join(defaultWorkDir, defaultClusterName, defaultClusterFileName)
 	cmd := fmt.Sprintf(getClusterfileCmd, path)
-	out, err := c.Cmd(EIP, cmd)
+	out, err := c.Cmd(context.Background(), EIP, cmd)
 	if err != nil {
 		return nil, fmt.Errorf("get clusterfile on master failed")
 	}
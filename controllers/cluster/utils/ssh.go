@@ -14,7 +14,7 @@ func WaitSSHReady(ssh ssh.Interface, _ int, hosts ...string) error {
 	for i := range hosts {
 		host := hosts[i]
 		eg.Go(func() (err error) {
-			timestamp, err := ssh.CmdToString(host, "date +%s", "")
+			timestamp, err := ssh.CmdToString(context.Background(), host, "date +%s", "")
 			if err != nil {
 				return fmt.Errorf("ssh is not ready")
 			}
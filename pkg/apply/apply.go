@@ -61,5 +61,6 @@ func NewApplierFromFile(path string, args *Args) (applydrivers.Interface, error)
 		ClusterFile:    Clusterfile,
 		ClusterCurrent: currentCluster,
 		RunNewImages:   GetNewImages(currentCluster, cluster),
+		Resume:         args.Resume,
 	}, nil
 }
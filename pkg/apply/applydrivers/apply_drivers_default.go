@@ -15,20 +15,28 @@
 package applydrivers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/version"
 
 	"github.com/labring/sealos/pkg/apply/processor"
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/hook"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
@@ -71,9 +79,14 @@ type Applier struct {
 	Client             kubernetes.Client
 	CurrentClusterInfo *version.Info
 	RunNewImages       []string
+	// Resume continues the last failed apply of the same spec from its checkpoint.
+	Resume bool
 }
 
-func (c *Applier) Apply() error {
+func (c *Applier) Apply(ctx context.Context) error {
+	if err := hook.Validate(c.ClusterDesired.Spec.Hooks); err != nil {
+		return err
+	}
 	clusterPath := constants.Clusterfile(c.ClusterDesired.Name)
 	// clusterErr and appErr should not appear in the same time
 	var clusterErr, appErr error
@@ -88,17 +101,28 @@ func (c *Applier) Apply() error {
 		if saveErr != nil {
 			logger.Error("write cluster file to local storage: %s error, %s", clusterPath, saveErr)
 			logger.Debug("complete write back file: \n %v", c.getWriteBackObjects())
+			return
+		}
+		if clusterErr == nil && appErr == nil {
+			c.saveRevision()
 		}
 	}()
 	c.initStatus()
-	if c.ClusterDesired.CreationTimestamp.IsZero() && (c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero()) {
-		clusterErr = c.initCluster()
+	if checkpoint := c.getResumableCheckpoint(); checkpoint != nil {
+		clusterErr = c.resumeCluster(ctx, checkpoint)
+		if c.ClusterCurrent.CreationTimestamp.IsZero() {
+			c.ClusterDesired.CreationTimestamp = metav1.Now()
+		} else {
+			c.ClusterDesired.CreationTimestamp = c.ClusterCurrent.CreationTimestamp
+		}
+	} else if c.ClusterDesired.CreationTimestamp.IsZero() && (c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero()) {
+		clusterErr = c.initCluster(ctx)
 		c.ClusterDesired.CreationTimestamp = metav1.Now()
 	} else {
-		clusterErr, appErr = c.reconcileCluster()
+		clusterErr, appErr = c.reconcileCluster(ctx)
 		c.ClusterDesired.CreationTimestamp = c.ClusterCurrent.CreationTimestamp
 	}
-	c.updateStatus(clusterErr, appErr)
+	c.updateStatus(ctx, clusterErr, appErr)
 
 	// return app error if not nil
 	if appErr != nil && !errors.Is(appErr, processor.ErrCancelled) {
@@ -119,34 +143,41 @@ func (c *Applier) getWriteBackObjects() []interface{} {
 
 func (c *Applier) initStatus() {
 	c.ClusterDesired.Status.Phase = v2.ClusterInProcess
+	// checkpoint is only carried over when resuming
+	c.ClusterDesired.Status.Checkpoint = nil
 	if c.ClusterDesired.Status.Conditions == nil {
 		c.ClusterDesired.Status.Conditions = make([]v2.ClusterCondition, 0)
 	}
 }
 
-// todo: atomic updating status after each installation for better reconcile?
-// todo: set up signal handler
-func (c *Applier) updateStatus(clusterErr error, appErr error) {
+func (c *Applier) updateStatus(ctx context.Context, clusterErr error, appErr error) {
 	switch clusterErr.(type) {
 	case *processor.CheckError, *processor.PreProcessError:
 		return
 	}
 	// update cluster condition using clusterErr
 	var condition v2.ClusterCondition
-	if clusterErr != nil {
+	if ctx.Err() != nil && (clusterErr != nil || appErr != nil) {
+		// keep the phase failed, so that it can be resumed later
+		condition = v2.NewCancelledClusterCondition(ctx.Err().Error())
+		c.ClusterDesired.Status.Phase = v2.ClusterFailed
+		logger.Warn("Applying to cluster was cancelled, the cluster might be partially applied")
+	} else if clusterErr != nil {
 		condition = v2.NewFailedClusterCondition(clusterErr.Error())
 		c.ClusterDesired.Status.Phase = v2.ClusterFailed
 		logger.Error("Applied to cluster error: %v", clusterErr)
 	} else {
 		condition = v2.NewSuccessClusterCondition()
 		c.ClusterDesired.Status.Phase = v2.ClusterSuccess
+		// nothing to resume once succeeded
+		c.ClusterDesired.Status.Checkpoint = nil
 	}
 	c.ClusterDesired.Status.Conditions = v2.UpdateCondition(c.ClusterDesired.Status.Conditions, condition)
 
 	// update command condition using appErr
 	var cmdCondition v2.CommandCondition
 	if appErr != nil {
-		if errors.Is(appErr, processor.ErrCancelled) {
+		if errors.Is(appErr, processor.ErrCancelled) || ctx.Err() != nil {
 			cmdCondition = v2.NewCancelledCommandCondition(appErr.Error())
 		} else {
 			cmdCondition = v2.NewFailedCommandCondition(appErr.Error())
@@ -158,28 +189,84 @@ func (c *Applier) updateStatus(clusterErr error, appErr error) {
 	c.ClusterDesired.Status.CommandConditions = v2.UpdateCommandCondition(c.ClusterDesired.Status.CommandConditions, cmdCondition)
 }
 
-func (c *Applier) reconcileCluster() (clusterErr error, appErr error) {
+func (c *Applier) reconcileCluster(ctx context.Context) (clusterErr error, appErr error) {
 	// sync newVersion pki and etc dir in `.sealos/default/pki` and `.sealos/default/etc`
 	processor.SyncNewVersionConfig(c.ClusterDesired.Name)
 	if len(c.RunNewImages) != 0 {
 		logger.Debug("run new images: %+v", c.RunNewImages)
-		if appErr = c.installApp(c.RunNewImages); appErr != nil {
+		if appErr = c.installApp(ctx, c.RunNewImages); appErr != nil {
 			return nil, appErr
 		}
 	}
 	mj, md := iputils.GetDiffHosts(c.ClusterCurrent.GetMasterIPAndPortList(), c.ClusterDesired.GetMasterIPAndPortList())
 	nj, nd := iputils.GetDiffHosts(c.ClusterCurrent.GetNodeIPAndPortList(), c.ClusterDesired.GetNodeIPAndPortList())
-	return c.scaleCluster(mj, md, nj, nd), nil
+	ej, ed := iputils.GetDiffHosts(c.ClusterCurrent.GetEtcdIPAndPortList(), c.ClusterDesired.GetEtcdIPAndPortList())
+	if len(ed) > 0 {
+		return fmt.Errorf("deleting etcd members %v is not supported", ed), nil
+	}
+	if clusterErr = c.scaleCluster(ctx, mj, md, nj, nd, ej); clusterErr != nil {
+		return clusterErr, nil
+	}
+	return c.syncNodeConfigs(ctx), nil
 }
 
-func (c *Applier) initCluster() error {
+// syncNodeConfigs applies the changed labels, taints and kubelet configs of the host groups to the existing nodes,
+// the joined nodes have been synced while joining.
+func (c *Applier) syncNodeConfigs(ctx context.Context) error {
+	hosts := getNodeConfigChangedHosts(c.ClusterCurrent, c.ClusterDesired)
+	if len(hosts) == 0 {
+		return nil
+	}
+	logger.Info("start to sync node configs of %v", hosts)
+	if err := c.ClusterFile.Process(); err != nil {
+		return err
+	}
+	return processor.SyncNodeConfigs(ctx, c.ClusterDesired, c.ClusterFile, hosts)
+}
+
+// getNodeConfigChangedHosts returns the existing hosts whose labels, taints or kubelet config are changed.
+func getNodeConfigChangedHosts(current, desired *v2.Cluster) []string {
+	existing := append(current.GetMasterIPAndPortList(), current.GetNodeIPAndPortList()...)
+	var hosts []string
+	for _, host := range append(desired.GetMasterIPAndPortList(), desired.GetNodeIPAndPortList()...) {
+		if !stringsutil.InList(host, existing) {
+			continue
+		}
+		before, after := current.GetHostByIP(host), desired.GetHostByIP(host)
+		if !labels.Equals(before.Labels, after.Labels) ||
+			!equality.Semantic.DeepEqual(before.Taints, after.Taints) ||
+			!isRawEqual(before.KubeletConfig, after.KubeletConfig) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+func isRawEqual(a, b *runtime.RawExtension) bool {
+	decode := func(r *runtime.RawExtension) interface{} {
+		var obj interface{}
+		if r != nil && len(r.Raw) > 0 {
+			_ = json.Unmarshal(r.Raw, &obj)
+		}
+		return obj
+	}
+	return reflect.DeepEqual(decode(a), decode(b))
+}
+
+func (c *Applier) initCluster(ctx context.Context) error {
 	logger.Info("Start to create a new cluster: master %s, worker %s, registry %s", c.ClusterDesired.GetMasterIPList(), c.ClusterDesired.GetNodeIPList(), c.ClusterDesired.GetRegistryIP())
-	createProcessor, err := processor.NewCreateProcessor(c.ClusterDesired.Name, c.ClusterFile)
+	if c.ClusterDesired.Status.Checkpoint == nil {
+		c.ClusterDesired.Status.Checkpoint = &v2.Checkpoint{
+			SpecHash:  getSpecHash(c.ClusterDesired),
+			Processor: processor.CreateProcessorName,
+		}
+	}
+	createProcessor, err := processor.NewCreateProcessor(c.ClusterDesired.Name, c.ClusterFile, c.Resume)
 	if err != nil {
 		return err
 	}
 
-	if err = createProcessor.Execute(c.ClusterDesired); err != nil {
+	if err = createProcessor.Execute(ctx, c.ClusterDesired); err != nil {
 		return err
 	}
 
@@ -188,7 +275,7 @@ func (c *Applier) initCluster() error {
 	return nil
 }
 
-func (c *Applier) installApp(images []string) error {
+func (c *Applier) installApp(ctx context.Context, images []string) error {
 	logger.Info("start to install app in this cluster")
 	err := c.ClusterFile.Process()
 	if err != nil {
@@ -198,27 +285,38 @@ func (c *Applier) installApp(images []string) error {
 	if err != nil {
 		return err
 	}
-	err = installProcessor.Execute(c.ClusterDesired)
+	err = installProcessor.Execute(ctx, c.ClusterDesired)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (c *Applier) scaleCluster(mj, md, nj, nd []string) error {
-	if len(mj) == 0 && len(md) == 0 && len(nj) == 0 && len(nd) == 0 {
+func (c *Applier) scaleCluster(ctx context.Context, mj, md, nj, nd, ej []string) error {
+	if len(mj) == 0 && len(md) == 0 && len(nj) == 0 && len(nd) == 0 && len(ej) == 0 {
 		logger.Info("no nodes that need to be scaled")
 		return nil
 	}
 	logger.Info("start to scale this cluster")
 	logger.Debug("current cluster: master %s, worker %s", c.ClusterCurrent.GetMasterIPAndPortList(), c.ClusterCurrent.GetNodeIPAndPortList())
 	logger.Debug("desired cluster: master %s, worker %s", c.ClusterDesired.GetMasterIPAndPortList(), c.ClusterDesired.GetNodeIPAndPortList())
-	scaleProcessor, err := processor.NewScaleProcessor(c.ClusterFile, c.ClusterDesired.Name, c.ClusterDesired.Spec.Image, mj, md, nj, nd)
+	scaleProcessor, err := processor.NewScaleProcessor(c.ClusterFile, c.ClusterDesired.Name, c.ClusterDesired.Spec.Image, mj, md, nj, nd, ej, c.Resume)
 	if err != nil {
 		return err
 	}
+	if c.ClusterDesired.Status.Checkpoint == nil {
+		c.ClusterDesired.Status.Checkpoint = &v2.Checkpoint{
+			SpecHash:        getSpecHash(c.ClusterDesired),
+			Processor:       processor.ScaleProcessorName,
+			MastersToJoin:   mj,
+			MastersToDelete: md,
+			NodesToJoin:     nj,
+			NodesToDelete:   nd,
+			EtcdToJoin:      ej,
+		}
+	}
 	cluster := c.ClusterDesired
-	err = scaleProcessor.Execute(cluster)
+	err = scaleProcessor.Execute(ctx, cluster)
 	if err != nil {
 		return err
 	}
@@ -226,7 +324,7 @@ func (c *Applier) scaleCluster(mj, md, nj, nd []string) error {
 	return nil
 }
 
-func (c *Applier) Delete() error {
+func (c *Applier) Delete(ctx context.Context) error {
 	t := metav1.Now()
 	c.ClusterDesired.DeletionTimestamp = &t
 	defer func() {
@@ -238,16 +336,35 @@ func (c *Applier) Delete() error {
 		}
 		_ = os.Rename(cfPath, target)
 	}()
-	return c.deleteCluster()
+	return c.deleteCluster(ctx)
+}
+
+// Uninstall runs the uninstall commands of the app images and removes them from the cluster.
+func (c *Applier) Uninstall(ctx context.Context, images []string) error {
+	clusterPath := constants.Clusterfile(c.ClusterDesired.Name)
+	uninstallProcessor, err := processor.NewUninstallProcessor(c.ClusterFile, images)
+	if err != nil {
+		return err
+	}
+	err = uninstallProcessor.Execute(ctx, c.ClusterDesired)
+	// the apps might have been removed partially, always keep the Clusterfile in sync
+	if saveErr := yaml.MarshalYamlToFile(clusterPath, c.getWriteBackObjects()...); saveErr != nil {
+		logger.Error("write cluster file to local storage: %s error, %s", clusterPath, saveErr)
+		return err
+	}
+	if err == nil {
+		c.saveRevision()
+	}
+	return err
 }
 
-func (c *Applier) deleteCluster() error {
+func (c *Applier) deleteCluster(ctx context.Context) error {
 	deleteProcessor, err := processor.NewDeleteProcessor(c.ClusterDesired.Name, c.ClusterFile)
 	if err != nil {
 		return err
 	}
 
-	if err := deleteProcessor.Execute(c.ClusterDesired); err != nil {
+	if err := deleteProcessor.Execute(ctx, c.ClusterDesired); err != nil {
 		return err
 	}
 
@@ -0,0 +1,45 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func TestGetNodeConfigChangedHosts(t *testing.T) {
+	current := newTestCluster(true, []string{"192.168.0.2:22"}, []string{"192.168.0.3:22"})
+	current.Spec.Hosts[1].KubeletConfig = &runtime.RawExtension{Raw: []byte(`{"maxPods": 250}`)}
+
+	desired := current.DeepCopy()
+	desired.Spec.Hosts[1].KubeletConfig = &runtime.RawExtension{Raw: []byte(`{"maxPods":250}`)}
+	if got := getNodeConfigChangedHosts(current, desired); len(got) != 0 {
+		t.Errorf("getNodeConfigChangedHosts() = %v, want nothing changed", got)
+	}
+
+	desired.Spec.Hosts[0].Labels = map[string]string{"pool": "system"}
+	desired.Spec.Hosts[1].Taints = []v1.Taint{{Key: "dedicated", Effect: v1.TaintEffectNoSchedule}}
+	// the joined hosts are synced while joining
+	desired.Spec.Hosts = append(desired.Spec.Hosts, v2.Host{IPS: []string{"192.168.0.4:22"}, Roles: []string{v2.NODE}, Labels: map[string]string{"pool": "gpu"}})
+	want := []string{"192.168.0.2:22", "192.168.0.3:22"}
+	if got := getNodeConfigChangedHosts(current, desired); !reflect.DeepEqual(got, want) {
+		t.Errorf("getNodeConfigChangedHosts() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/labring/sealos/pkg/apply/processor"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/hash"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+func getSpecHash(cluster *v2.Cluster) string {
+	data, err := json.Marshal(cluster.Spec)
+	if err != nil {
+		logger.Warn("failed to marshal cluster spec: %v", err)
+		return ""
+	}
+	return hash.Digest(data)
+}
+
+// getResumableCheckpoint returns the checkpoint of the last failed apply if
+// resume is enabled and the desired spec has not been changed since then.
+func (c *Applier) getResumableCheckpoint() *v2.Checkpoint {
+	if !c.Resume || c.ClusterCurrent == nil || c.ClusterCurrent.Status.Checkpoint == nil {
+		return nil
+	}
+	checkpoint := c.ClusterCurrent.Status.Checkpoint
+	if checkpoint.SpecHash != getSpecHash(c.ClusterDesired) {
+		logger.Info("cluster spec has been changed since the last apply, ignore the checkpoint and start from scratch")
+		return nil
+	}
+	return checkpoint.DeepCopy()
+}
+
+func (c *Applier) resumeCluster(ctx context.Context, checkpoint *v2.Checkpoint) error {
+	logger.Info("resume the last apply of %s from checkpoint", checkpoint.Processor)
+	c.ClusterDesired.Status.Checkpoint = checkpoint
+	switch checkpoint.Processor {
+	case processor.CreateProcessorName:
+		return c.initCluster(ctx)
+	case processor.ScaleProcessorName:
+		return c.scaleCluster(ctx, checkpoint.MastersToJoin, checkpoint.MastersToDelete, checkpoint.NodesToJoin, checkpoint.NodesToDelete, checkpoint.EtcdToJoin)
+	default:
+		return fmt.Errorf("unable to resume from checkpoint of unknown processor %s", checkpoint.Processor)
+	}
+}
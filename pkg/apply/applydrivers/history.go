@@ -0,0 +1,45 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"github.com/labring/sealos/pkg/apply/history"
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// saveRevision records the applied cluster as a new revision, the failure of recording
+// does not fail the apply.
+func (c *Applier) saveRevision() {
+	digests := make(map[string]string)
+	if bder, err := buildah.New(c.ClusterDesired.Name); err != nil {
+		logger.Warn("failed to get image digests of cluster: %v", err)
+	} else {
+		for _, m := range c.ClusterDesired.Status.Mounts {
+			info, err := bder.InspectContainer(m.Name)
+			if err != nil {
+				logger.Debug("failed to inspect container %s: %v", m.Name, err)
+				continue
+			}
+			digests[m.Name] = info.FromImageDigest
+		}
+	}
+	r, err := history.Save(c.ClusterDesired, c.ClusterFile.GetConfigs(), c.ClusterFile.GetKubeadmConfig(), digests)
+	if err != nil {
+		logger.Warn("failed to record revision of cluster %s: %v", c.ClusterDesired.Name, err)
+		return
+	}
+	logger.Info("cluster %s is recorded as revision %d, use `sealos rollback --to-revision` to go back to it later", c.ClusterDesired.Name, r.Revision)
+}
@@ -14,7 +14,11 @@
 
 package applydrivers
 
+import "context"
+
 type Interface interface {
-	Apply() error
-	Delete() error
+	Apply(ctx context.Context) error
+	Delete(ctx context.Context) error
+	Uninstall(ctx context.Context, images []string) error
+	Plan() (*Plan, error)
 }
@@ -0,0 +1,141 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/labring/sealos/pkg/apply/processor"
+	"github.com/labring/sealos/pkg/utils/iputils"
+)
+
+const (
+	PlanOutputText = "text"
+	PlanOutputJSON = "json"
+)
+
+const (
+	PlanOperationCreate    = "create"
+	PlanOperationReconcile = "reconcile"
+)
+
+// Plan is what Apply would do against the current cluster, computed without
+// connecting to any host or writing the Clusterfile.
+type Plan struct {
+	ClusterName     string           `json:"clusterName"`
+	Operation       string           `json:"operation"`
+	NewImages       []string         `json:"newImages,omitempty"`
+	MastersToJoin   []string         `json:"mastersToJoin,omitempty"`
+	MastersToDelete []string         `json:"mastersToDelete,omitempty"`
+	NodesToJoin     []string         `json:"nodesToJoin,omitempty"`
+	NodesToDelete   []string         `json:"nodesToDelete,omitempty"`
+	EtcdToJoin      []string         `json:"etcdToJoin,omitempty"`
+	Steps           []processor.Step `json:"steps"`
+}
+
+func (c *Applier) Plan() (*Plan, error) {
+	plan := &Plan{
+		ClusterName: c.ClusterDesired.Name,
+		Steps:       make([]processor.Step, 0),
+	}
+	var planners []processor.Planner
+	if c.ClusterDesired.CreationTimestamp.IsZero() && (c.ClusterCurrent == nil || c.ClusterCurrent.CreationTimestamp.IsZero()) {
+		plan.Operation = PlanOperationCreate
+		plan.NewImages = c.ClusterDesired.Spec.Image
+		plan.MastersToJoin = c.ClusterDesired.GetMasterIPAndPortList()
+		plan.NodesToJoin = c.ClusterDesired.GetNodeIPAndPortList()
+		plan.EtcdToJoin = c.ClusterDesired.GetEtcdIPAndPortList()
+		planners = append(planners, &processor.CreateProcessor{})
+	} else {
+		plan.Operation = PlanOperationReconcile
+		plan.NewImages = c.RunNewImages
+		if len(c.RunNewImages) != 0 {
+			planners = append(planners, &processor.InstallProcessor{NewImages: c.RunNewImages})
+		}
+		plan.MastersToJoin, plan.MastersToDelete = iputils.GetDiffHosts(c.ClusterCurrent.GetMasterIPAndPortList(), c.ClusterDesired.GetMasterIPAndPortList())
+		plan.NodesToJoin, plan.NodesToDelete = iputils.GetDiffHosts(c.ClusterCurrent.GetNodeIPAndPortList(), c.ClusterDesired.GetNodeIPAndPortList())
+		plan.EtcdToJoin, _ = iputils.GetDiffHosts(c.ClusterCurrent.GetEtcdIPAndPortList(), c.ClusterDesired.GetEtcdIPAndPortList())
+		if len(plan.MastersToJoin) != 0 || len(plan.MastersToDelete) != 0 || len(plan.NodesToJoin) != 0 || len(plan.NodesToDelete) != 0 || len(plan.EtcdToJoin) != 0 {
+			planners = append(planners, &processor.ScaleProcessor{
+				MastersToJoin:   plan.MastersToJoin,
+				MastersToDelete: plan.MastersToDelete,
+				NodesToJoin:     plan.NodesToJoin,
+				NodesToDelete:   plan.NodesToDelete,
+				EtcdToJoin:      plan.EtcdToJoin,
+				IsScaleUp:       len(plan.MastersToJoin) > 0 || len(plan.NodesToJoin) > 0 || len(plan.EtcdToJoin) > 0,
+			})
+		}
+	}
+	for _, p := range planners {
+		steps, err := p.Plan(c.ClusterDesired)
+		if err != nil {
+			return nil, err
+		}
+		plan.Steps = append(plan.Steps, steps...)
+	}
+	return plan, nil
+}
+
+// Print writes the plan to w, format is one of PlanOutputText or PlanOutputJSON.
+func (p *Plan) Print(w io.Writer, format string) error {
+	switch format {
+	case PlanOutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	case PlanOutputText, "":
+		return p.printText(w)
+	default:
+		return fmt.Errorf("unsupported output format %s, only %s and %s are allowed", format, PlanOutputText, PlanOutputJSON)
+	}
+}
+
+func (p *Plan) printText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Cluster:\t%s\n", p.ClusterName)
+	fmt.Fprintf(tw, "Operation:\t%s\n", p.Operation)
+	for _, item := range []struct {
+		title string
+		list  []string
+	}{
+		{"New images:", p.NewImages},
+		{"Masters to join:", p.MastersToJoin},
+		{"Masters to delete:", p.MastersToDelete},
+		{"Nodes to join:", p.NodesToJoin},
+		{"Nodes to delete:", p.NodesToDelete},
+		{"Etcd to join:", p.EtcdToJoin},
+	} {
+		if len(item.list) > 0 {
+			fmt.Fprintf(tw, "%s\t%s\n", item.title, strings.Join(item.list, ", "))
+		}
+	}
+	if len(p.Steps) == 0 {
+		fmt.Fprintln(tw, "No changes, nothing to do.")
+		return tw.Flush()
+	}
+	fmt.Fprintln(tw, "\nPROCESSOR\tSTEP\tHOSTS")
+	for _, step := range p.Steps {
+		hosts := "local"
+		if len(step.Hosts) > 0 {
+			hosts = strings.Join(step.Hosts, ",")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", step.Processor, step.Name, hosts)
+	}
+	return tw.Flush()
+}
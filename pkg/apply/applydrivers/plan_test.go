@@ -0,0 +1,133 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applydrivers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func newTestCluster(created bool, masters, nodes []string) *v2.Cluster {
+	cluster := &v2.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: v2.ClusterSpec{
+			Image: []string{"labring/kubernetes:v1.25.0"},
+			Hosts: []v2.Host{
+				{IPS: masters, Roles: []string{v2.MASTER, string(v2.AMD64)}},
+			},
+		},
+	}
+	if len(nodes) > 0 {
+		cluster.Spec.Hosts = append(cluster.Spec.Hosts, v2.Host{IPS: nodes, Roles: []string{v2.NODE, string(v2.AMD64)}})
+	}
+	if created {
+		cluster.CreationTimestamp = metav1.Now()
+	}
+	return cluster
+}
+
+func stepNames(plan *Plan) []string {
+	var names []string
+	for _, step := range plan.Steps {
+		names = append(names, step.Processor+"."+step.Name)
+	}
+	return names
+}
+
+func TestApplier_Plan(t *testing.T) {
+	tests := []struct {
+		name          string
+		applier       *Applier
+		wantOperation string
+		wantSteps     []string
+		wantNodesJoin []string
+	}{
+		{
+			name: "create",
+			applier: &Applier{
+				ClusterDesired: newTestCluster(false, []string{"192.168.1.2:22"}, []string{"192.168.1.3:22"}),
+			},
+			wantOperation: PlanOperationCreate,
+			wantSteps: []string{
+				"CreateProcessor.Check", "CreateProcessor.PreProcess", "CreateProcessor.RunConfig",
+				"CreateProcessor.MountRootfs", "CreateProcessor.MirrorRegistry", "CreateProcessor.Bootstrap",
+				"CreateProcessor.Init", "CreateProcessor.Join", "CreateProcessor.RunGuest",
+			},
+			wantNodesJoin: []string{"192.168.1.3:22"},
+		},
+		{
+			name: "scale up",
+			applier: &Applier{
+				ClusterCurrent: newTestCluster(true, []string{"192.168.1.2:22"}, nil),
+				ClusterDesired: newTestCluster(true, []string{"192.168.1.2:22"}, []string{"192.168.1.3:22"}),
+			},
+			wantOperation: PlanOperationReconcile,
+			wantSteps: []string{
+				"ScaleProcessor.JoinCheck", "ScaleProcessor.PreProcess", "ScaleProcessor.PreProcessImage",
+				"ScaleProcessor.RunConfig", "ScaleProcessor.MountRootfs", "ScaleProcessor.Bootstrap", "ScaleProcessor.Join",
+			},
+			wantNodesJoin: []string{"192.168.1.3:22"},
+		},
+		{
+			name: "nothing changed",
+			applier: &Applier{
+				ClusterCurrent: newTestCluster(true, []string{"192.168.1.2:22"}, nil),
+				ClusterDesired: newTestCluster(true, []string{"192.168.1.2:22"}, nil),
+			},
+			wantOperation: PlanOperationReconcile,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := tt.applier.Plan()
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if plan.Operation != tt.wantOperation {
+				t.Errorf("Plan() operation = %s, want %s", plan.Operation, tt.wantOperation)
+			}
+			if got := stepNames(plan); !reflect.DeepEqual(got, tt.wantSteps) {
+				t.Errorf("Plan() steps = %v, want %v", got, tt.wantSteps)
+			}
+			if !reflect.DeepEqual(plan.NodesToJoin, tt.wantNodesJoin) {
+				t.Errorf("Plan() nodes to join = %v, want %v", plan.NodesToJoin, tt.wantNodesJoin)
+			}
+		})
+	}
+}
+
+func TestPlan_Print(t *testing.T) {
+	plan := &Plan{ClusterName: "default", Operation: PlanOperationCreate}
+	buf := &bytes.Buffer{}
+	if err := plan.Print(buf, PlanOutputJSON); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+	got := &Plan{}
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatalf("Print() output is not valid json: %v", err)
+	}
+	if got.ClusterName != plan.ClusterName {
+		t.Errorf("Print() cluster name = %s, want %s", got.ClusterName, plan.ClusterName)
+	}
+	if err := plan.Print(buf, "yaml"); err == nil {
+		t.Errorf("Print() expected error for unsupported format")
+	}
+}
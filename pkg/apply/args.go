@@ -22,18 +22,21 @@ import (
 
 	"github.com/spf13/pflag"
 
+	"github.com/labring/sealos/pkg/apply/applydrivers"
 	"github.com/labring/sealos/pkg/constants"
 )
 
 type Cluster struct {
 	Masters     string
 	Nodes       string
+	Etcd        string
 	ClusterName string
 }
 
 func (c *Cluster) RegisterFlags(fs *pflag.FlagSet, verb, action string) {
 	fs.StringVar(&c.Masters, "masters", "", fmt.Sprintf("masters to %s", verb))
 	fs.StringVar(&c.Nodes, "nodes", "", fmt.Sprintf("nodes to %s", verb))
+	fs.StringVar(&c.Etcd, "etcd", "", fmt.Sprintf("external etcd hosts to %s", verb))
 	fs.StringVar(&c.ClusterName, "cluster", "default", fmt.Sprintf("name of cluster to applied %s action", action))
 }
 
@@ -49,17 +52,37 @@ func (s *SSH) RegisterFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&s.User, "user", "u", "", "username to authenticate as")
 	fs.StringVarP(&s.Password, "passwd", "p", "", "use given password to authenticate with")
 	fs.StringVarP(&s.Pk, "pk", "i", path.Join(constants.GetHomeDir(), ".ssh", "id_rsa"),
-		"selects a file from which the identity (private key) for public key authentication is read")
+		"selects a file from which the identity (private key) for public key authentication is read, "+
+			"the default keys in ~/.ssh are tried if it does not exist, and so are the keys of ssh-agent by $SSH_AUTH_SOCK")
 	fs.StringVar(&s.PkPassword, "pk-passwd", "", "passphrase for decrypting a PEM encoded private key")
 	fs.Uint16Var(&s.Port, "port", 22, "port to connect to on the remote host")
 }
 
+type PlanArgs struct {
+	DryRun bool
+	Output string
+}
+
+func (p *PlanArgs) RegisterFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&p.DryRun, "dry-run", false, "print the plan of what would be changed without touching any host or the Clusterfile")
+	fs.StringVarP(&p.Output, "output", "o", applydrivers.PlanOutputText,
+		fmt.Sprintf("output format of the plan in dry-run mode, one of %s|%s", applydrivers.PlanOutputText, applydrivers.PlanOutputJSON))
+}
+
+// IsDryRun is safe to be called with a nil receiver, which is the case
+// when args are constructed without plan options.
+func (p *PlanArgs) IsDryRun() bool {
+	return p != nil && p.DryRun
+}
+
 type RunArgs struct {
 	*Cluster
 	*SSH
+	*PlanArgs
 	CustomEnv         []string
 	CustomCMD         []string
 	CustomConfigFiles []string
+	Resume            bool
 	fs                *pflag.FlagSet
 }
 
@@ -69,14 +92,19 @@ func (arg *RunArgs) RegisterFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVarP(&arg.CustomEnv, "env", "e", []string{}, "environment variables to set during command execution")
 	fs.StringSliceVar(&arg.CustomCMD, "cmd", []string{}, "override CMD directive in images")
 	fs.StringSliceVar(&arg.CustomConfigFiles, "config-file", []string{}, "path of custom config files, to use to replace the resource")
+	if arg.PlanArgs != nil {
+		arg.PlanArgs.RegisterFlags(fs)
+	}
 	arg.fs = fs
 }
 
 type Args struct {
+	*PlanArgs
 	Values            []string
 	Sets              []string
 	CustomEnv         []string
 	CustomConfigFiles []string
+	Resume            bool
 }
 
 func (arg *Args) RegisterFlags(fs *pflag.FlagSet) {
@@ -84,6 +112,9 @@ func (arg *Args) RegisterFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&arg.Sets, "set", []string{}, "set values on the command line")
 	fs.StringSliceVar(&arg.CustomEnv, "env", []string{}, "environment variables to set during command execution")
 	fs.StringSliceVar(&arg.CustomConfigFiles, "config-file", []string{}, "path of custom config files, to use to replace the resource")
+	if arg.PlanArgs != nil {
+		arg.PlanArgs.RegisterFlags(fs)
+	}
 }
 
 type ResetArgs struct {
@@ -100,8 +131,12 @@ func (arg *ResetArgs) RegisterFlags(fs *pflag.FlagSet) {
 
 type ScaleArgs struct {
 	*Cluster
+	*PlanArgs
 }
 
 func (arg *ScaleArgs) RegisterFlags(fs *pflag.FlagSet, verb, action string) {
 	arg.Cluster.RegisterFlags(fs, verb, action)
+	if arg.PlanArgs != nil {
+		arg.PlanArgs.RegisterFlags(fs)
+	}
 }
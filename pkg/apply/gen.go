@@ -17,6 +17,7 @@ limitations under the License.
 package apply
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/labring/sealos/pkg/apply/processor"
@@ -43,7 +44,7 @@ func NewClusterFromGenArgs(imageNames []string, args *RunArgs) ([]byte, error) {
 		return nil, fmt.Errorf("input first image %s is not kubernetes image", imageNames)
 	}
 	cluster.Status.Mounts = append(cluster.Status.Mounts, *img)
-	rtInterface, err := runtime.NewDefaultRuntime(cluster, &runtime.KubeadmConfig{})
+	rtInterface, err := runtime.NewDefaultRuntime(context.Background(), cluster, &runtime.KubeadmConfig{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,258 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/runtime"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/hash"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+const (
+	revisionsDirName    = "revisions"
+	metadataFileName    = "revision.json"
+	DefaultHistoryLimit = 10
+)
+
+// Revision is a snapshot of the Clusterfile which has been applied successfully.
+type Revision struct {
+	Revision    int             `json:"revision"`
+	ClusterName string          `json:"clusterName"`
+	Hash        string          `json:"hash"`
+	Masters     []string        `json:"masters,omitempty"`
+	Nodes       []string        `json:"nodes,omitempty"`
+	Images      []RevisionImage `json:"images,omitempty"`
+	// +optional
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// RevisionImage is an image mounted in the cluster when the revision is recorded.
+type RevisionImage struct {
+	Name   string       `json:"name"`
+	Type   v2.ImageType `json:"type"`
+	Digest string       `json:"digest,omitempty"`
+}
+
+// RevisionsDir returns the local directory of the revisions of the cluster.
+func RevisionsDir(clusterName string) string {
+	return filepath.Join(constants.ClusterDir(clusterName), revisionsDirName)
+}
+
+func (r *Revision) dir() string {
+	return filepath.Join(RevisionsDir(r.ClusterName), strconv.Itoa(r.Revision))
+}
+
+// Clusterfile returns the path of the Clusterfile of the revision, which is able to be applied directly.
+func (r *Revision) Clusterfile() string {
+	return filepath.Join(r.dir(), constants.DefaultClusterFileName)
+}
+
+// AppImages returns the names of the app images of the revision in the order they were applied.
+func (r *Revision) AppImages() []string {
+	var images []string
+	for _, img := range r.Images {
+		if img.Type == v2.AppImage {
+			images = append(images, img.Name)
+		}
+	}
+	return images
+}
+
+// Reference returns the image pinned to its digest, e.g. labring/helm:v3.8.2@sha256:xxx,
+// the name is returned if no digest is recorded.
+func (img RevisionImage) Reference() string {
+	if img.Digest == "" || strings.Contains(img.Name, "@") {
+		return img.Name
+	}
+	return img.Name + "@" + img.Digest
+}
+
+// AppImageReferences returns the app images of the revision pinned to their digests in the order they were applied.
+func (r *Revision) AppImageReferences() []string {
+	var images []string
+	for _, img := range r.Images {
+		if img.Type == v2.AppImage {
+			images = append(images, img.Reference())
+		}
+	}
+	return images
+}
+
+// RootfsImage returns the name of the rootfs image of the revision.
+func (r *Revision) RootfsImage() string {
+	for _, img := range r.Images {
+		if img.Type == v2.RootfsImage {
+			return img.Name
+		}
+	}
+	return ""
+}
+
+// Save records the applied cluster, configs and kubeadm config as a new revision, digests are
+// the image digests indexed by the name of mounts. Nothing is recorded if the latest revision is the same.
+func Save(cluster *v2.Cluster, configs []v2.Config, kubeadm *runtime.KubeadmConfig, digests map[string]string) (*Revision, error) {
+	applied := cluster.DeepCopy()
+	// status is reconciled by the apply itself
+	applied.Status = v2.ClusterStatus{}
+	objects := []interface{}{applied}
+	for i := range configs {
+		objects = append(objects, configs[i])
+	}
+	objects = append(objects, kubeadmObjects(kubeadm)...)
+	data, err := yaml.MarshalYamlConfigs(objects...)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Revision{
+		ClusterName:       cluster.Name,
+		Masters:           cluster.GetMasterIPAndPortList(),
+		Nodes:             cluster.GetNodeIPAndPortList(),
+		CreationTimestamp: metav1.Now(),
+	}
+	for _, m := range cluster.Status.Mounts {
+		r.Images = append(r.Images, RevisionImage{Name: m.ImageName, Type: m.Type, Digest: digests[m.Name]})
+	}
+	images, err := json.Marshal(r.Images)
+	if err != nil {
+		return nil, err
+	}
+	r.Hash = hash.Digest(append(data, images...))
+
+	revisions, err := List(cluster.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) > 0 {
+		latest := revisions[len(revisions)-1]
+		if latest.Hash == r.Hash {
+			logger.Debug("cluster %s is the same as revision %d, skip recording", cluster.Name, latest.Revision)
+			return &latest, nil
+		}
+		r.Revision = latest.Revision
+	}
+	r.Revision++
+	if err = file.WriteFile(r.Clusterfile(), data); err != nil {
+		return nil, err
+	}
+	metadata, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = file.WriteFile(filepath.Join(r.dir(), metadataFileName), metadata); err != nil {
+		return nil, err
+	}
+	logger.Debug("cluster %s is recorded as revision %d", cluster.Name, r.Revision)
+	return r, Prune(cluster.Name, DefaultHistoryLimit)
+}
+
+// kubeadmObjects returns the kubeadm configurations which are set in the Clusterfile.
+func kubeadmObjects(kubeadm *runtime.KubeadmConfig) []interface{} {
+	if kubeadm == nil {
+		return nil
+	}
+	var objects []interface{}
+	if kubeadm.InitConfiguration.Kind != "" {
+		objects = append(objects, kubeadm.InitConfiguration)
+	}
+	if kubeadm.ClusterConfiguration.Kind != "" {
+		objects = append(objects, kubeadm.ClusterConfiguration)
+	}
+	if kubeadm.JoinConfiguration.Kind != "" {
+		objects = append(objects, kubeadm.JoinConfiguration)
+	}
+	if kubeadm.KubeProxyConfiguration.Kind != "" {
+		objects = append(objects, kubeadm.KubeProxyConfiguration)
+	}
+	if kubeadm.KubeletConfiguration.Kind != "" {
+		objects = append(objects, kubeadm.KubeletConfiguration)
+	}
+	return objects
+}
+
+// List returns the revisions of the cluster sorted by the revision number.
+func List(clusterName string) ([]Revision, error) {
+	entries, err := os.ReadDir(RevisionsDir(clusterName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var revisions []Revision
+	for _, entry := range entries {
+		n, err := strconv.Atoi(entry.Name())
+		if !entry.IsDir() || err != nil {
+			continue
+		}
+		r, err := Get(clusterName, n)
+		if err != nil {
+			logger.Warn("skip invalid revision %s: %v", entry.Name(), err)
+			continue
+		}
+		revisions = append(revisions, *r)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// Get returns the revision of the cluster by number.
+func Get(clusterName string, revision int) (*Revision, error) {
+	r := &Revision{ClusterName: clusterName, Revision: revision}
+	data, err := os.ReadFile(filepath.Join(r.dir(), metadataFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("revision %d of cluster %s not found", revision, clusterName)
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse revision %d: %v", revision, err)
+	}
+	if !file.IsExist(r.Clusterfile()) {
+		return nil, fmt.Errorf("the Clusterfile of revision %d is missing", revision)
+	}
+	return r, nil
+}
+
+// Prune removes the oldest revisions of the cluster and keeps the latest limit ones.
+func Prune(clusterName string, limit int) error {
+	revisions, err := List(clusterName)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(revisions)-limit; i++ {
+		if err = os.RemoveAll(revisions[i].dir()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
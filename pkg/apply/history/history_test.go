@@ -0,0 +1,103 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func newTestCluster(apps ...string) *v2.Cluster {
+	cluster := &v2.Cluster{
+		TypeMeta:   metav1.TypeMeta{Kind: "Cluster", APIVersion: v2.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: v2.ClusterSpec{
+			Image: append([]string{"labring/kubernetes:v1.25.0"}, apps...),
+			Hosts: []v2.Host{{IPS: []string{"192.168.0.2:22"}, Roles: []string{v2.MASTER}}},
+		},
+	}
+	cluster.Status.Mounts = append(cluster.Status.Mounts, v2.MountImage{Name: "rootfs", ImageName: "labring/kubernetes:v1.25.0", Type: v2.RootfsImage})
+	for _, app := range apps {
+		cluster.Status.Mounts = append(cluster.Status.Mounts, v2.MountImage{Name: app, ImageName: app, Type: v2.AppImage})
+	}
+	return cluster
+}
+
+func TestSave(t *testing.T) {
+	old := constants.DefaultRuntimeRootDir
+	constants.DefaultRuntimeRootDir = t.TempDir()
+	defer func() { constants.DefaultRuntimeRootDir = old }()
+
+	digests := map[string]string{"rootfs": "sha256:aaa"}
+	r, err := Save(newTestCluster(), nil, nil, digests)
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if r.Revision != 1 {
+		t.Errorf("Save() revision = %d, want 1", r.Revision)
+	}
+	if r, _ = Save(newTestCluster(), nil, nil, digests); r.Revision != 1 {
+		t.Errorf("Save() the same cluster revision = %d, want 1", r.Revision)
+	}
+	digests["rootfs"] = "sha256:bbb"
+	if r, _ = Save(newTestCluster(), nil, nil, digests); r.Revision != 2 {
+		t.Errorf("Save() with new digest revision = %d, want 2", r.Revision)
+	}
+	for i := 0; i < DefaultHistoryLimit; i++ {
+		if _, err = Save(newTestCluster(fmt.Sprintf("app:v%d", i)), nil, nil, digests); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	revisions, err := List("default")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(revisions) != DefaultHistoryLimit || revisions[0].Revision != 3 || revisions[len(revisions)-1].Revision != 12 {
+		t.Fatalf("List() = %v, want revisions 3 to 12", revisions)
+	}
+	latest, err := Get("default", 12)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if apps := latest.AppImages(); len(apps) != 1 || apps[0] != "app:v9" {
+		t.Errorf("AppImages() = %v, want [app:v9]", apps)
+	}
+	if rootfs := latest.RootfsImage(); rootfs != "labring/kubernetes:v1.25.0" {
+		t.Errorf("RootfsImage() = %s", rootfs)
+	}
+	if _, err = Get("default", 1); err == nil {
+		t.Errorf("Get() pruned revision should fail")
+	}
+}
+
+func TestAppImageReferences(t *testing.T) {
+	r := &Revision{Images: []RevisionImage{
+		{Name: "labring/kubernetes:v1.25.0", Type: v2.RootfsImage, Digest: "sha256:aaa"},
+		{Name: "labring/helm:v3.8.2", Type: v2.AppImage, Digest: "sha256:bbb"},
+		{Name: "labring/calico:v3.24.1", Type: v2.AppImage},
+		{Name: "labring/cilium:v1.12.0@sha256:ccc", Type: v2.AppImage, Digest: "sha256:ccc"},
+	}}
+	want := []string{"labring/helm:v3.8.2@sha256:bbb", "labring/calico:v3.24.1", "labring/cilium:v1.12.0@sha256:ccc"}
+	if got := r.AppImageReferences(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AppImageReferences() = %v, want %v", got, want)
+	}
+}
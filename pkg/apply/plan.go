@@ -0,0 +1,31 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"os"
+
+	"github.com/labring/sealos/pkg/apply/applydrivers"
+)
+
+// PrintPlan computes the plan of applier and prints it to stdout in the
+// format given by args, nothing is executed.
+func PrintPlan(applier applydrivers.Interface, args *PlanArgs) error {
+	plan, err := applier.Plan()
+	if err != nil {
+		return err
+	}
+	return plan.Print(os.Stdout, args.Output)
+}
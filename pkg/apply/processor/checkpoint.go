@@ -0,0 +1,161 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+// checkpointSteps are the steps whose results are recorded in the cluster status.
+// Checks and pre-processing are cheap and only build in-memory state for the
+// following steps, so they always run.
+var checkpointSteps = sets.NewString(
+	"MountRootfs",
+	"MirrorRegistry",
+	"Bootstrap",
+	"Init",
+	"Join",
+	"RunGuest",
+	"Delete",
+	"UndoBootstrap",
+	"UnMountRootfs",
+)
+
+// hostOperations are the operations of the steps which are done per host, the hosts on which
+// they have succeeded are recorded, so that a resumed step does not run them on those hosts again.
+var hostOperations = map[string]sets.String{
+	"Join":   sets.NewString("JoinEtcdMember", "JoinMaster", "JoinNode"),
+	"Delete": sets.NewString("DeleteMaster", "DeleteNode"),
+}
+
+// hostRecorder records the hosts on which the operations have succeeded from the runtime events.
+type hostRecorder struct {
+	mu         sync.Mutex
+	operations sets.String
+	succeeded  sets.String
+}
+
+func (r *hostRecorder) Emit(e events.Event) {
+	if e.Type != events.StepFinished || e.Source != events.SourceRuntime || e.Error != "" || !r.operations.Has(e.Step) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.succeeded.Insert(e.Host)
+}
+
+// pendingHosts filters out the hosts on which the step has succeeded in the resumed apply.
+func pendingHosts(cluster *v2.Cluster, step string, hosts []string) []string {
+	done := sets.NewString(cluster.Status.Checkpoint.GetSucceededHosts(step)...)
+	if done.Len() == 0 {
+		return hosts
+	}
+	var pending []string
+	for _, host := range hosts {
+		if done.Has(host) {
+			logger.Info("Skipping %s of host %s, it has succeeded in the last apply.", step, host)
+			continue
+		}
+		pending = append(pending, host)
+	}
+	return pending
+}
+
+// runStep runs the step at index of the pipeline and emits its events.
+func runStep(cluster *v2.Cluster, f func(cluster *v2.Cluster) error, step Step, index, total int) error {
+	s := events.StartPipelineStep(events.SourceProcessor, step.Processor+"."+step.Name, "", index, total)
+	err := f(cluster)
+	s.Finish(err)
+	return err
+}
+
+// executePipeline runs the pipeline until ctx is done, if the cluster status carries a checkpoint,
+// the result of each step is recorded and persisted into the local Clusterfile.
+// When resume is true, the steps succeeded in the checkpoint are skipped.
+func executePipeline(ctx context.Context, cluster *v2.Cluster, clusterFile clusterfile.Interface, pipeLine []func(cluster *v2.Cluster) error, steps []Step, resume bool) error {
+	checkpoint := cluster.Status.Checkpoint
+	for i, f := range pipeLine {
+		step := steps[i]
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("pipeline %s in %s interrupted: %w", step.Name, step.Processor, err)
+		}
+		if checkpoint == nil || checkpoint.Processor != step.Processor || !checkpointSteps.Has(step.Name) {
+			if err := runStep(cluster, f, step, i+1, len(pipeLine)); err != nil {
+				return err
+			}
+			continue
+		}
+		if resume && checkpoint.IsStepSucceeded(step.Name) {
+			logger.Info("Skipping pipeline %s in %s, it has succeeded in the last apply.", step.Name, step.Processor)
+			continue
+		}
+		succeeded := sets.NewString(checkpoint.GetSucceededHosts(step.Name)...)
+		var recorder *hostRecorder
+		if operations, ok := hostOperations[step.Name]; ok {
+			recorder = &hostRecorder{operations: operations, succeeded: sets.NewString()}
+			events.Register(recorder)
+		}
+		err := runStep(cluster, f, step, i+1, len(pipeLine))
+		if recorder != nil {
+			events.Unregister(recorder)
+			succeeded = succeeded.Union(recorder.succeeded)
+		}
+		result := v2.StepCheckpoint{
+			Name:              step.Name,
+			Phase:             v2.StepSucceeded,
+			Hosts:             step.Hosts,
+			LastHeartbeatTime: metav1.Now(),
+		}
+		if succeeded.Len() > 0 {
+			result.SucceededHosts = succeeded.List()
+		}
+		if err != nil {
+			result.Phase = v2.StepFailed
+			result.Message = err.Error()
+		}
+		checkpoint.UpdateStep(result)
+		// cluster status might be replaced by the step itself
+		cluster.Status.Checkpoint = checkpoint
+		if saveErr := saveClusterfile(cluster, clusterFile); saveErr != nil {
+			logger.Warn("failed to save checkpoint of pipeline %s: %v", step.Name, saveErr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveClusterfile(cluster *v2.Cluster, clusterFile clusterfile.Interface) error {
+	obj := []interface{}{cluster}
+	if configs := clusterFile.GetConfigs(); len(configs) > 0 {
+		for i := range configs {
+			obj = append(obj, configs[i])
+		}
+	}
+	return yaml.MarshalYamlToFile(constants.Clusterfile(cluster.Name), obj...)
+}
@@ -0,0 +1,134 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func TestExecutePipelineResume(t *testing.T) {
+	name := "test-checkpoint-resume"
+	defer os.RemoveAll(constants.ClusterDir(name))
+
+	cluster := &v2.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	cluster.Status.Checkpoint = &v2.Checkpoint{
+		Processor: CreateProcessorName,
+		Steps:     []v2.StepCheckpoint{{Name: "Init", Phase: v2.StepSucceeded}},
+	}
+	var called []string
+	record := func(name string, err error) func(*v2.Cluster) error {
+		return func(*v2.Cluster) error {
+			called = append(called, name)
+			return err
+		}
+	}
+	pipeLine := []func(*v2.Cluster) error{
+		record("PreProcess", nil),
+		record("Init", nil),
+		record("Join", errors.New("join failed")),
+	}
+	steps := []Step{
+		{Processor: CreateProcessorName, Name: "PreProcess"},
+		{Processor: CreateProcessorName, Name: "Init"},
+		{Processor: CreateProcessorName, Name: "Join", Hosts: []string{"192.168.1.3:22"}},
+	}
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(name))
+	if err := executePipeline(context.Background(), cluster, cf, pipeLine, steps, true); err == nil {
+		t.Fatalf("executePipeline() expected error of failed step")
+	}
+	if len(called) != 2 || called[0] != "PreProcess" || called[1] != "Join" {
+		t.Errorf("executePipeline() called steps = %v, want [PreProcess Join]", called)
+	}
+	checkpoint := cluster.Status.Checkpoint
+	if !checkpoint.IsStepSucceeded("Init") || checkpoint.IsStepSucceeded("Join") {
+		t.Errorf("executePipeline() unexpected checkpoint steps %+v", checkpoint.Steps)
+	}
+	if len(checkpoint.Steps) != 2 || checkpoint.Steps[1].Phase != v2.StepFailed {
+		t.Errorf("executePipeline() failed step not recorded, got %+v", checkpoint.Steps)
+	}
+}
+
+func TestExecutePipelineResumeHosts(t *testing.T) {
+	name := "test-checkpoint-resume-hosts"
+	defer os.RemoveAll(constants.ClusterDir(name))
+
+	cluster := &v2.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	cluster.Status.Checkpoint = &v2.Checkpoint{
+		Processor: ScaleProcessorName,
+		Steps: []v2.StepCheckpoint{
+			{Name: "Join", Phase: v2.StepFailed, SucceededHosts: []string{"192.168.1.3:22"}},
+		},
+	}
+	nodes := []string{"192.168.1.3:22", "192.168.1.4:22", "192.168.1.5:22"}
+	var joined []string
+	pipeLine := []func(*v2.Cluster) error{
+		func(cluster *v2.Cluster) error {
+			for _, node := range pendingHosts(cluster, "Join", nodes) {
+				joined = append(joined, node)
+				step := events.StartStep(events.SourceRuntime, "JoinNode", node)
+				if node == "192.168.1.5:22" {
+					step.Finish(errors.New("join failed"))
+					return errors.New("join failed")
+				}
+				step.Finish(nil)
+			}
+			return nil
+		},
+	}
+	steps := []Step{{Processor: ScaleProcessorName, Name: "Join", Hosts: nodes}}
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(name))
+	if err := executePipeline(context.Background(), cluster, cf, pipeLine, steps, true); err == nil {
+		t.Fatalf("executePipeline() expected error of failed step")
+	}
+	if !reflect.DeepEqual(joined, []string{"192.168.1.4:22", "192.168.1.5:22"}) {
+		t.Errorf("executePipeline() joined hosts = %v, want the hosts not succeeded", joined)
+	}
+	want := []string{"192.168.1.3:22", "192.168.1.4:22"}
+	if got := cluster.Status.Checkpoint.GetSucceededHosts("Join"); !reflect.DeepEqual(got, want) {
+		t.Errorf("executePipeline() succeeded hosts = %v, want %v", got, want)
+	}
+}
+
+func TestExecutePipelineCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	pipeLine := []func(*v2.Cluster) error{
+		func(*v2.Cluster) error {
+			called = true
+			return nil
+		},
+	}
+	steps := []Step{{Processor: CreateProcessorName, Name: "Init"}}
+	err := executePipeline(ctx, &v2.Cluster{}, nil, pipeLine, steps, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("executePipeline() error = %v, want %v", err, context.Canceled)
+	}
+	if called {
+		t.Errorf("executePipeline() should not run steps after cancelled")
+	}
+}
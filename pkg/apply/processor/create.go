@@ -28,31 +28,35 @@ import (
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/filesystem"
 	"github.com/labring/sealos/pkg/guest"
+	"github.com/labring/sealos/pkg/hook"
 	"github.com/labring/sealos/pkg/runtime"
+	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
 type CreateProcessor struct {
+	ctx         context.Context
 	ClusterFile clusterfile.Interface
 	Buildah     buildah.Interface
 	Runtime     runtime.Interface
 	Guest       guest.Interface
+	// resume skips the steps and hosts which have succeeded in the checkpoint
+	resume bool
 }
 
-func (c *CreateProcessor) Execute(cluster *v2.Cluster) error {
+func (c *CreateProcessor) Execute(ctx context.Context, cluster *v2.Cluster) error {
+	c.ctx = ctx
 	pipeLine, err := c.GetPipeLine()
 	if err != nil {
 		return err
 	}
-	for _, f := range pipeLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
+	steps, err := c.Plan(cluster)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return executePipeline(ctx, cluster, c.ClusterFile, pipeLine, steps, c.resume)
 }
 
 func (c *CreateProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
@@ -90,7 +94,7 @@ func (c *CreateProcessor) preProcess(cluster *v2.Cluster) error {
 	if err := MountClusterImages(cluster, c.Buildah); err != nil {
 		return err
 	}
-	runTime, err := runtime.NewDefaultRuntime(cluster, c.ClusterFile.GetKubeadmConfig())
+	runTime, err := runtime.NewDefaultRuntime(c.ctx, cluster, c.ClusterFile.GetKubeadmConfig())
 	if err != nil {
 		return fmt.Errorf("failed to init runtime, %v", err)
 	}
@@ -113,39 +117,42 @@ func (c *CreateProcessor) RunConfig(cluster *v2.Cluster) error {
 
 func (c *CreateProcessor) MountRootfs(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline MountRootfs in CreateProcessor.")
-	hosts := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+	hosts := append(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...), cluster.GetEtcdIPAndPortList()...)
 	fs, err := filesystem.NewRootfsMounter(cluster.Status.Mounts)
 	if err != nil {
 		return err
 	}
-	return fs.MountRootfs(cluster, hosts)
+	return fs.MountRootfs(c.ctx, cluster, hosts)
 }
 
 func (c *CreateProcessor) MirrorRegistry(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline MirrorRegistry in CreateProcessor.")
-	return MirrorRegistry(cluster, cluster.Status.Mounts)
+	return MirrorRegistry(c.ctx, cluster, cluster.Status.Mounts)
 }
 
 func (c *CreateProcessor) Bootstrap(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Bootstrap in CreateProcessor")
-	hosts := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
-	bs := bootstrap.New(cluster)
+	hosts := append(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...), cluster.GetEtcdIPAndPortList()...)
+	bs := bootstrap.New(c.ctx, cluster)
 	return bs.Apply(hosts...)
 }
 
-func (c *CreateProcessor) Init(_ *v2.Cluster) error {
+func (c *CreateProcessor) Init(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Init in CreateProcessor.")
 	// move init runtime here?
-	return c.Runtime.Init()
+	if err := c.Runtime.Init(); err != nil {
+		return err
+	}
+	return hook.RunOnCluster(c.ctx, cluster, ssh.NewSSHClientWithCluster(cluster, true), v2.HookPostInit)
 }
 
 func (c *CreateProcessor) Join(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Join in CreateProcessor.")
-	err := c.Runtime.JoinMasters(cluster.GetMasterIPAndPortList()[1:])
+	err := c.Runtime.JoinMasters(pendingHosts(cluster, "Join", cluster.GetMasterIPAndPortList()[1:]))
 	if err != nil {
 		return err
 	}
-	err = c.Runtime.JoinNodes(cluster.GetNodeIPAndPortList())
+	err = c.Runtime.JoinNodes(pendingHosts(cluster, "Join", cluster.GetNodeIPAndPortList()))
 	if err != nil {
 		return err
 	}
@@ -153,15 +160,26 @@ func (c *CreateProcessor) Join(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
+	err = c.Runtime.SyncNodeConfigs(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...))
+	if err != nil {
+		return err
+	}
+	joined := append(cluster.GetMasterIPAndPortList()[1:], cluster.GetNodeIPAndPortList()...)
+	if err = hook.Run(c.ctx, cluster, ssh.NewSSHClientWithCluster(cluster, true), v2.HookPostJoin, joined...); err != nil {
+		return err
+	}
 	return yaml.MarshalYamlToFile(constants.Clusterfile(cluster.Name), cluster)
 }
 
 func (c *CreateProcessor) RunGuest(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline RunGuest in CreateProcessor.")
-	return c.Guest.Apply(cluster, cluster.Status.Mounts)
+	if err := c.Guest.Apply(c.ctx, cluster, cluster.Status.Mounts); err != nil {
+		return err
+	}
+	return hook.RunOnCluster(c.ctx, cluster, ssh.NewSSHClientWithCluster(cluster, true), v2.HookPostGuest)
 }
 
-func NewCreateProcessor(name string, clusterFile clusterfile.Interface) (Interface, error) {
+func NewCreateProcessor(name string, clusterFile clusterfile.Interface, resume bool) (Interface, error) {
 	bder, err := buildah.New(name)
 	if err != nil {
 		return nil, err
@@ -175,5 +193,6 @@ func NewCreateProcessor(name string, clusterFile clusterfile.Interface) (Interfa
 		ClusterFile: clusterFile,
 		Buildah:     bder,
 		Guest:       gs,
+		resume:      resume,
 	}, nil
 }
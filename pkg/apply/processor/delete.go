@@ -38,19 +38,28 @@ import (
 var ForceDelete bool
 
 type DeleteProcessor struct {
+	ctx         context.Context
 	Buildah     buildah.Interface
 	ClusterFile clusterfile.Interface
 }
 
 // Execute :according to the different of desired cluster to delete cluster.
-func (d DeleteProcessor) Execute(cluster *v2.Cluster) (err error) {
+func (d DeleteProcessor) Execute(ctx context.Context, cluster *v2.Cluster) (err error) {
+	d.ctx = ctx
 	pipLine, err := d.GetPipeLine()
 	if err != nil {
 		return err
 	}
+	steps, err := d.Plan(cluster)
+	if err != nil {
+		return err
+	}
 	// TODO if error is exec net process ???
-	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
+	for i, f := range pipLine {
+		if err = ctx.Err(); err != nil {
+			return fmt.Errorf("delete process interrupted: %w", err)
+		}
+		if err = runStep(cluster, f, steps[i], i+1, len(pipLine)); err != nil {
 			logger.Warn("failed to exec delete process, %s", err.Error())
 		}
 	}
@@ -76,13 +85,13 @@ func (d *DeleteProcessor) PreProcess(cluster *v2.Cluster) error {
 
 func (d *DeleteProcessor) UndoBootstrap(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Bootstrap in DeleteProcessor")
-	hosts := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
-	bs := bootstrap.New(cluster)
+	hosts := append(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...), cluster.GetEtcdIPAndPortList()...)
+	bs := bootstrap.New(d.ctx, cluster)
 	return bs.Delete(hosts...)
 }
 
 func (d *DeleteProcessor) Reset(cluster *v2.Cluster) error {
-	runTime, err := runtime.NewDefaultRuntime(cluster, d.ClusterFile.GetKubeadmConfig())
+	runTime, err := runtime.NewDefaultRuntime(d.ctx, cluster, d.ClusterFile.GetKubeadmConfig())
 	if err != nil {
 		return fmt.Errorf("failed to delete runtime, %v", err)
 	}
@@ -90,7 +99,7 @@ func (d *DeleteProcessor) Reset(cluster *v2.Cluster) error {
 }
 
 func (d *DeleteProcessor) UnMountRootfs(cluster *v2.Cluster) error {
-	hosts := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+	hosts := append(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...), cluster.GetEtcdIPAndPortList()...)
 	if strings.NotInIPList(cluster.GetRegistryIPAndPort(), hosts) {
 		hosts = append(hosts, cluster.GetRegistryIPAndPort())
 	}
@@ -99,7 +108,7 @@ func (d *DeleteProcessor) UnMountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return fs.UnMountRootfs(cluster, hosts)
+	return fs.UnMountRootfs(d.ctx, cluster, hosts)
 }
 
 func (d *DeleteProcessor) UnMountImage(cluster *v2.Cluster) error {
@@ -27,7 +27,9 @@ import (
 	"github.com/labring/sealos/pkg/config"
 	"github.com/labring/sealos/pkg/filesystem"
 	"github.com/labring/sealos/pkg/guest"
+	"github.com/labring/sealos/pkg/hook"
 	runtime "github.com/labring/sealos/pkg/runtime"
+	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/confirm"
 	"github.com/labring/sealos/pkg/utils/images"
@@ -38,6 +40,7 @@ import (
 var ForceOverride bool
 
 type InstallProcessor struct {
+	ctx              context.Context
 	ClusterFile      clusterfile.Interface
 	Buildah          buildah.Interface
 	Runtime          runtime.Interface
@@ -47,19 +50,17 @@ type InstallProcessor struct {
 	imagesToOverride []string
 }
 
-func (c *InstallProcessor) Execute(cluster *v2.Cluster) error {
+func (c *InstallProcessor) Execute(ctx context.Context, cluster *v2.Cluster) error {
+	c.ctx = ctx
 	pipLine, err := c.GetPipeLine()
 	if err != nil {
 		return err
 	}
-
-	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
+	steps, err := c.Plan(cluster)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return executePipeline(ctx, cluster, c.ClusterFile, pipLine, steps, false)
 }
 
 func (c *InstallProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
@@ -166,7 +167,7 @@ func (c *InstallProcessor) PreProcess(cluster *v2.Cluster) error {
 		cluster.SetMountImage(mount)
 		c.NewMounts = append(c.NewMounts, *mount)
 	}
-	runtime, err := runtime.NewDefaultRuntime(cluster, c.ClusterFile.GetKubeadmConfig())
+	runtime, err := runtime.NewDefaultRuntime(c.ctx, cluster, c.ClusterFile.GetKubeadmConfig())
 	if err != nil {
 		return fmt.Errorf("failed to init runtime, %v", err)
 	}
@@ -238,19 +239,22 @@ func (c *InstallProcessor) MountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return fs.MountRootfs(cluster, hosts)
+	return fs.MountRootfs(c.ctx, cluster, hosts)
 }
 
 func (c *InstallProcessor) MirrorRegistry(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline MirrorRegistry in InstallProcessor.")
-	return MirrorRegistry(cluster, c.NewMounts)
+	return MirrorRegistry(c.ctx, cluster, c.NewMounts)
 }
 
 func (c *InstallProcessor) RunGuest(cluster *v2.Cluster) error {
 	if len(c.NewMounts) == 0 {
 		return nil
 	}
-	return c.Guest.Apply(cluster, c.NewMounts)
+	if err := c.Guest.Apply(c.ctx, cluster, c.NewMounts); err != nil {
+		return err
+	}
+	return hook.RunOnCluster(c.ctx, cluster, ssh.NewSSHClientWithCluster(cluster, true), v2.HookPostGuest)
 }
 
 func NewInstallProcessor(clusterFile clusterfile.Interface, images []string) (Interface, error) {
@@ -24,8 +24,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/filesystem/registry"
+	"github.com/labring/sealos/pkg/runtime"
 	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/confirm"
@@ -37,8 +39,9 @@ import (
 )
 
 type Interface interface {
-	// Execute :according to the difference of desired cluster to do cluster apply.
-	Execute(cluster *v2.Cluster) error
+	// Execute :according to the difference of desired cluster to do cluster apply,
+	// the pipeline is interrupted once ctx is done.
+	Execute(ctx context.Context, cluster *v2.Cluster) error
 }
 
 func SyncNewVersionConfig(clusterName string) {
@@ -145,12 +148,21 @@ func ConfirmDeleteNodes() error {
 	return nil
 }
 
-func MirrorRegistry(cluster *v2.Cluster, mounts []v2.MountImage) error {
+func MirrorRegistry(ctx context.Context, cluster *v2.Cluster, mounts []v2.MountImage) error {
 	registries := cluster.GetRegistryIPAndPortList()
 	logger.Debug("registry nodes is: %+v", registries)
-	sshClient := ssh.NewSSHClient(&cluster.Spec.SSH, true)
+	sshClient := ssh.NewSSHClientWithCluster(cluster, true)
 	mirror := registry.New(constants.NewData(cluster.GetName()).RootFSPath(), sshClient, mounts)
-	return mirror.MirrorTo(context.Background(), registries...)
+	return mirror.MirrorTo(ctx, registries...)
+}
+
+// SyncNodeConfigs applies the labels, taints and kubelet configs declared in the host groups to the existing nodes.
+func SyncNodeConfigs(ctx context.Context, cluster *v2.Cluster, clusterFile clusterfile.Interface, hosts []string) error {
+	runTime, err := runtime.NewDefaultRuntime(ctx, cluster, clusterFile.GetKubeadmConfig())
+	if err != nil {
+		return fmt.Errorf("failed to init runtime: %v", err)
+	}
+	return runTime.SyncNodeConfigs(hosts)
 }
 
 func CheckImageType(cluster *v2.Cluster, bd buildah.Interface) error {
@@ -0,0 +1,150 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"reflect"
+	goruntime "runtime"
+	"strings"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+const (
+	CreateProcessorName    = "CreateProcessor"
+	ScaleProcessorName     = "ScaleProcessor"
+	InstallProcessorName   = "InstallProcessor"
+	DeleteProcessorName    = "DeleteProcessor"
+	UninstallProcessorName = "UninstallProcessor"
+)
+
+// Step describes one pipeline function of a processor and the hosts it would touch.
+// An empty host list means the step only runs on the local machine.
+type Step struct {
+	Processor string   `json:"processor"`
+	Name      string   `json:"name"`
+	Hosts     []string `json:"hosts,omitempty"`
+}
+
+// Planner is implemented by processors which are able to describe their
+// pipeline without executing it.
+type Planner interface {
+	Plan(cluster *v2.Cluster) ([]Step, error)
+}
+
+func (c *CreateProcessor) Plan(cluster *v2.Cluster) ([]Step, error) {
+	pipeLine, err := c.GetPipeLine()
+	if err != nil {
+		return nil, err
+	}
+	masters, nodes := cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()
+	all := append(append(append([]string{}, masters...), nodes...), cluster.GetEtcdIPAndPortList()...)
+	var joins []string
+	if len(masters) > 0 {
+		joins = append(joins, masters[1:]...)
+	}
+	joins = append(joins, nodes...)
+	return toSteps(CreateProcessorName, pipeLine, map[string][]string{
+		"Check":          all,
+		"MountRootfs":    all,
+		"MirrorRegistry": cluster.GetRegistryIPAndPortList(),
+		"Bootstrap":      all,
+		"Init":           append([]string{cluster.GetMaster0IPAndPort()}, cluster.GetEtcdIPAndPortList()...),
+		"Join":           joins,
+		"RunGuest":       {cluster.GetMaster0IPAndPort()},
+	}), nil
+}
+
+func (c *ScaleProcessor) Plan(cluster *v2.Cluster) ([]Step, error) {
+	pipeLine, err := c.GetPipeLine()
+	if err != nil {
+		return nil, err
+	}
+	joins := c.hostsToJoin()
+	deletes := append(append([]string{}, c.MastersToDelete...), c.NodesToDelete...)
+	return toSteps(ScaleProcessorName, pipeLine, map[string][]string{
+		"JoinCheck":     append([]string{cluster.GetMaster0IPAndPort()}, joins...),
+		"DeleteCheck":   {cluster.GetMaster0IPAndPort()},
+		"MountRootfs":   joins,
+		"Bootstrap":     joins,
+		"Join":          joins,
+		"Delete":        deletes,
+		"UndoBootstrap": deletes,
+		"UnMountRootfs": deletes,
+	}), nil
+}
+
+func (c *InstallProcessor) Plan(cluster *v2.Cluster) ([]Step, error) {
+	pipeLine, err := c.GetPipeLine()
+	if err != nil {
+		return nil, err
+	}
+	all := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+	return toSteps(InstallProcessorName, pipeLine, map[string][]string{
+		"MountRootfs":    all,
+		"MirrorRegistry": cluster.GetRegistryIPAndPortList(),
+		"UpgradeIfNeed":  all,
+		"RunGuest":       {cluster.GetMaster0IPAndPort()},
+	}), nil
+}
+
+func (c *UninstallProcessor) Plan(cluster *v2.Cluster) ([]Step, error) {
+	pipeLine, err := c.GetPipeLine()
+	if err != nil {
+		return nil, err
+	}
+	all := append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+	return toSteps(UninstallProcessorName, pipeLine, map[string][]string{
+		"RunGuest":     {cluster.GetMaster0IPAndPort()},
+		"CleanWorkDir": all,
+	}), nil
+}
+
+func (d DeleteProcessor) Plan(cluster *v2.Cluster) ([]Step, error) {
+	pipeLine, err := d.GetPipeLine()
+	if err != nil {
+		return nil, err
+	}
+	all := append(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...), cluster.GetEtcdIPAndPortList()...)
+	return toSteps(DeleteProcessorName, pipeLine, map[string][]string{
+		"Reset":         all,
+		"UndoBootstrap": all,
+		"UnMountRootfs": all,
+	}), nil
+}
+
+func toSteps(processor string, pipeLine []func(cluster *v2.Cluster) error, hosts map[string][]string) []Step {
+	steps := make([]Step, 0, len(pipeLine))
+	for _, f := range pipeLine {
+		name := funcName(f)
+		steps = append(steps, Step{
+			Processor: processor,
+			Name:      name,
+			Hosts:     hosts[name],
+		})
+	}
+	return steps
+}
+
+// funcName returns the method name of a bound pipeline function,
+// e.g. "(*CreateProcessor).Check-fm" becomes "Check".
+func funcName(f interface{}) string {
+	name := goruntime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
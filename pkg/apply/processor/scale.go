@@ -25,16 +25,17 @@ import (
 	"github.com/labring/sealos/pkg/checker"
 	"github.com/labring/sealos/pkg/clusterfile"
 	"github.com/labring/sealos/pkg/config"
-	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/filesystem"
+	"github.com/labring/sealos/pkg/hook"
 	"github.com/labring/sealos/pkg/runtime"
+	"github.com/labring/sealos/pkg/ssh"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	fileutil "github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
-	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
 type ScaleProcessor struct {
+	ctx             context.Context
 	ClusterFile     clusterfile.Interface
 	Runtime         runtime.Interface
 	Buildah         buildah.Interface
@@ -43,22 +44,23 @@ type ScaleProcessor struct {
 	MastersToDelete []string
 	NodesToJoin     []string
 	NodesToDelete   []string
+	EtcdToJoin      []string
 	IsScaleUp       bool
+	// resume skips the steps and hosts which have succeeded in the checkpoint
+	resume bool
 }
 
-func (c *ScaleProcessor) Execute(cluster *v2.Cluster) error {
+func (c *ScaleProcessor) Execute(ctx context.Context, cluster *v2.Cluster) error {
+	c.ctx = ctx
 	pipLine, err := c.GetPipeLine()
 	if err != nil {
 		return err
 	}
-
-	for _, f := range pipLine {
-		if err = f(cluster); err != nil {
-			return err
-		}
+	steps, err := c.Plan(cluster)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return executePipeline(ctx, cluster, c.ClusterFile, pipLine, steps, c.resume)
 }
 
 func (c *ScaleProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
@@ -91,11 +93,11 @@ func (c *ScaleProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error
 
 func (c *ScaleProcessor) Delete(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Delete in ScaleProcessor.")
-	err := c.Runtime.DeleteMasters(c.MastersToDelete)
+	err := c.Runtime.DeleteMasters(pendingHosts(cluster, "Delete", c.MastersToDelete))
 	if err != nil {
 		return err
 	}
-	if err = c.Runtime.DeleteNodes(c.NodesToDelete); err != nil {
+	if err = c.Runtime.DeleteNodes(pendingHosts(cluster, "Delete", c.NodesToDelete)); err != nil {
 		return err
 	}
 	if len(c.MastersToDelete) > 0 {
@@ -106,18 +108,33 @@ func (c *ScaleProcessor) Delete(cluster *v2.Cluster) error {
 
 func (c *ScaleProcessor) Join(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Join in ScaleProcessor.")
-	err := c.Runtime.JoinMasters(c.MastersToJoin)
+	// etcd members join first, so that the new masters use the updated etcd endpoints.
+	// The hosts joined in the resumed apply are not joined again, but synced as the others.
+	err := c.Runtime.JoinEtcdMembers(pendingHosts(cluster, "Join", c.EtcdToJoin))
 	if err != nil {
 		return err
 	}
-	err = c.Runtime.JoinNodes(c.NodesToJoin)
+	err = c.Runtime.JoinMasters(pendingHosts(cluster, "Join", c.MastersToJoin))
+	if err != nil {
+		return err
+	}
+	err = c.Runtime.JoinNodes(pendingHosts(cluster, "Join", c.NodesToJoin))
 	if err != nil {
 		return err
 	}
 	if len(c.MastersToJoin) > 0 {
-		return c.Runtime.SyncNodeIPVS(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList())
+		err = c.Runtime.SyncNodeIPVS(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList())
+	} else {
+		err = c.Runtime.SyncNodeIPVS(cluster.GetMasterIPAndPortList(), c.NodesToJoin)
 	}
-	return c.Runtime.SyncNodeIPVS(cluster.GetMasterIPAndPortList(), c.NodesToJoin)
+	if err != nil {
+		return err
+	}
+	err = c.Runtime.SyncNodeConfigs(append(append([]string{}, c.MastersToJoin...), c.NodesToJoin...))
+	if err != nil {
+		return err
+	}
+	return hook.Run(c.ctx, cluster, ssh.NewSSHClientWithCluster(cluster, true), v2.HookPostJoin, c.hostsToJoin()...)
 }
 
 func (c ScaleProcessor) UnMountRootfs(cluster *v2.Cluster) error {
@@ -131,7 +148,7 @@ func (c ScaleProcessor) UnMountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return fs.UnMountRootfs(cluster, hosts)
+	return fs.UnMountRootfs(c.ctx, cluster, hosts)
 }
 
 func (c *ScaleProcessor) JoinCheck(cluster *v2.Cluster) error {
@@ -140,6 +157,7 @@ func (c *ScaleProcessor) JoinCheck(cluster *v2.Cluster) error {
 	ips = append(ips, cluster.GetMaster0IPAndPort())
 	ips = append(ips, c.MastersToJoin...)
 	ips = append(ips, c.NodesToJoin...)
+	ips = append(ips, c.EtcdToJoin...)
 	return NewCheckError(checker.RunCheckList([]checker.Interface{checker.NewIPsHostChecker(ips)}, cluster, checker.PhasePre))
 }
 
@@ -167,21 +185,14 @@ func (c *ScaleProcessor) preProcess(cluster *v2.Cluster) error {
 		return err
 	}
 	if c.IsScaleUp {
-		clusterPath := constants.Clusterfile(cluster.Name)
-		obj := []interface{}{cluster}
-		if configs := c.ClusterFile.GetConfigs(); len(configs) > 0 {
-			for i := range configs {
-				obj = append(obj, configs[i])
-			}
-		}
-		if err = yaml.MarshalYamlToFile(clusterPath, obj...); err != nil {
+		if err = saveClusterfile(cluster, c.ClusterFile); err != nil {
 			return err
 		}
 	}
 	if err = SyncClusterStatus(cluster, c.Buildah, false); err != nil {
 		return err
 	}
-	runTime, err := runtime.NewDefaultRuntime(cluster, c.ClusterFile.GetKubeadmConfig())
+	runTime, err := runtime.NewDefaultRuntime(c.ctx, cluster, c.ClusterFile.GetKubeadmConfig())
 	if err != nil {
 		return fmt.Errorf("failed to init runtime: %v", err)
 	}
@@ -227,7 +238,7 @@ func (c *ScaleProcessor) RunConfig(cluster *v2.Cluster) error {
 
 func (c *ScaleProcessor) MountRootfs(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline MountRootfs in ScaleProcessor.")
-	hosts := append(c.MastersToJoin, c.NodesToJoin...)
+	hosts := c.hostsToJoin()
 	// since app type images are only sent to the first master, in
 	// cluster scaling scenario we don't need to sent app images repeatedly.
 	// so filter out rootfs/patch type
@@ -235,7 +246,7 @@ func (c *ScaleProcessor) MountRootfs(cluster *v2.Cluster) error {
 	if err != nil {
 		return err
 	}
-	return fs.MountRootfs(cluster, hosts)
+	return fs.MountRootfs(c.ctx, cluster, hosts)
 }
 
 func filterNoneApplicationMounts(images []v2.MountImage) []v2.MountImage {
@@ -250,19 +261,23 @@ func filterNoneApplicationMounts(images []v2.MountImage) []v2.MountImage {
 
 func (c *ScaleProcessor) Bootstrap(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline Bootstrap in ScaleProcessor")
-	hosts := append(c.MastersToJoin, c.NodesToJoin...)
-	bs := bootstrap.New(cluster)
+	hosts := c.hostsToJoin()
+	bs := bootstrap.New(c.ctx, cluster)
 	return bs.Apply(hosts...)
 }
 
+func (c *ScaleProcessor) hostsToJoin() []string {
+	return append(append(append([]string{}, c.MastersToJoin...), c.NodesToJoin...), c.EtcdToJoin...)
+}
+
 func (c *ScaleProcessor) UndoBootstrap(cluster *v2.Cluster) error {
 	logger.Info("Executing pipeline UndoBootstrap in ScaleProcessor")
 	hosts := append(c.MastersToDelete, c.NodesToDelete...)
-	bs := bootstrap.New(cluster)
+	bs := bootstrap.New(c.ctx, cluster)
 	return bs.Delete(hosts...)
 }
 
-func NewScaleProcessor(clusterFile clusterfile.Interface, name string, images v2.ImageList, masterToJoin, masterToDelete, nodeToJoin, nodeToDelete []string) (Interface, error) {
+func NewScaleProcessor(clusterFile clusterfile.Interface, name string, images v2.ImageList, masterToJoin, masterToDelete, nodeToJoin, nodeToDelete, etcdToJoin []string, resume bool) (Interface, error) {
 	bder, err := buildah.New(name)
 	if err != nil {
 		return nil, err
@@ -272,9 +287,11 @@ func NewScaleProcessor(clusterFile clusterfile.Interface, name string, images v2
 		MastersToJoin:   masterToJoin,
 		NodesToDelete:   nodeToDelete,
 		NodesToJoin:     nodeToJoin,
+		EtcdToJoin:      etcdToJoin,
 		ClusterFile:     clusterFile,
 		Buildah:         bder,
 		pullImages:      images,
-		IsScaleUp:       len(masterToJoin) > 0 || len(nodeToJoin) > 0,
+		IsScaleUp:       len(masterToJoin) > 0 || len(nodeToJoin) > 0 || len(etcdToJoin) > 0,
+		resume:          resume,
 	}, nil
 }
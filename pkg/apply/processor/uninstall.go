@@ -0,0 +1,149 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/guest"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/system"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+type UninstallProcessor struct {
+	ctx         context.Context
+	ClusterFile clusterfile.Interface
+	Buildah     buildah.Interface
+	Guest       guest.Interface
+	Images      []string
+	mounts      []v2.MountImage
+}
+
+func (c *UninstallProcessor) Execute(ctx context.Context, cluster *v2.Cluster) error {
+	c.ctx = ctx
+	pipLine, err := c.GetPipeLine()
+	if err != nil {
+		return err
+	}
+	steps, err := c.Plan(cluster)
+	if err != nil {
+		return err
+	}
+	return executePipeline(ctx, cluster, c.ClusterFile, pipLine, steps, false)
+}
+
+func (c *UninstallProcessor) GetPipeLine() ([]func(cluster *v2.Cluster) error, error) {
+	var todoList []func(cluster *v2.Cluster) error
+	todoList = append(todoList,
+		c.SyncStatusAndCheck,
+		c.RunGuest,
+		c.CleanWorkDir,
+		c.UnMountImage,
+		c.PostProcess,
+	)
+	return todoList, nil
+}
+
+func (c *UninstallProcessor) SyncStatusAndCheck(cluster *v2.Cluster) error {
+	logger.Info("Executing SyncStatusAndCheck Pipeline in UninstallProcessor")
+	if err := SyncClusterStatus(cluster, c.Buildah, false); err != nil {
+		return err
+	}
+	c.mounts = nil
+	for _, img := range c.Images {
+		mount := cluster.FindImage(img)
+		if mount == nil {
+			return fmt.Errorf("app %s is not installed in cluster %s", img, cluster.Name)
+		}
+		if mount.Type != v2.AppImage {
+			return fmt.Errorf("%s is a %s image, only application images can be uninstalled", img, mount.Type)
+		}
+		c.mounts = append(c.mounts, *mount)
+	}
+	return nil
+}
+
+func (c *UninstallProcessor) RunGuest(cluster *v2.Cluster) error {
+	logger.Info("Executing RunGuest Pipeline in UninstallProcessor")
+	// uninstall in the reverse order of installing
+	mounts := make([]v2.MountImage, 0, len(c.mounts))
+	for i := len(c.mounts) - 1; i >= 0; i-- {
+		mounts = append(mounts, c.mounts[i])
+	}
+	return c.Guest.Delete(c.ctx, cluster, mounts)
+}
+
+func (c *UninstallProcessor) CleanWorkDir(cluster *v2.Cluster) error {
+	logger.Info("Executing CleanWorkDir Pipeline in UninstallProcessor")
+	var cmds []string
+	for _, mount := range c.mounts {
+		// the parent of workdir holds everything of the app
+		cmds = append(cmds, fmt.Sprintf("rm -rf %s", filepath.Dir(constants.GetAppWorkDir(cluster.Name, mount.Name))))
+	}
+	sshClient := ssh.NewSSHClientWithCluster(cluster, true)
+	eg, ctx := errgroup.WithContext(c.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, ip := range append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...) {
+		ip := ip
+		eg.Go(func() error {
+			return sshClient.CmdAsync(ctx, ip, cmds...)
+		})
+	}
+	return eg.Wait()
+}
+
+func (c *UninstallProcessor) UnMountImage(cluster *v2.Cluster) error {
+	logger.Info("Executing UnMountImage Pipeline in UninstallProcessor")
+	for _, mount := range c.mounts {
+		if err := c.Buildah.Delete(mount.Name); err != nil {
+			return fmt.Errorf("failed to delete container %s of app %s: %v", mount.Name, mount.ImageName, err)
+		}
+		cluster.RemoveAppImage(mount.ImageName)
+	}
+	return nil
+}
+
+func (c *UninstallProcessor) PostProcess(*v2.Cluster) error {
+	logger.Info("succeeded in uninstalling %d apps from this cluster", len(c.mounts))
+	return nil
+}
+
+func NewUninstallProcessor(clusterFile clusterfile.Interface, images []string) (Interface, error) {
+	bder, err := buildah.New(clusterFile.GetCluster().Name)
+	if err != nil {
+		return nil, err
+	}
+
+	gs, err := guest.NewGuestManager()
+	if err != nil {
+		return nil, err
+	}
+
+	return &UninstallProcessor{
+		ClusterFile: clusterFile,
+		Buildah:     bder,
+		Guest:       gs,
+		Images:      images,
+	}, nil
+}
@@ -75,15 +75,18 @@ func (r *ClusterArgs) resetArgs(args *ResetArgs) error {
 	if len(args.Cluster.Masters) > 0 {
 		masters := stringsutil.SplitRemoveEmpty(args.Cluster.Masters, ",")
 		nodes := stringsutil.SplitRemoveEmpty(args.Cluster.Nodes, ",")
+		etcd := stringsutil.SplitRemoveEmpty(args.Cluster.Etcd, ",")
 		r.hosts = []v2.Host{}
 
-		clusterSSH := r.cluster.GetSSH()
-		sshClient := ssh.NewSSHClient(&clusterSSH, true)
+		sshClient := ssh.NewSSHClientWithCluster(r.cluster, true)
 
 		r.setHostWithIpsPort(masters, []string{v2.MASTER, GetHostArch(sshClient, masters[0])})
 		if len(nodes) > 0 {
 			r.setHostWithIpsPort(nodes, []string{v2.NODE, GetHostArch(sshClient, nodes[0])})
 		}
+		if len(etcd) > 0 {
+			r.setHostWithIpsPort(etcd, []string{v2.ETCD, GetHostArch(sshClient, etcd[0])})
+		}
 		r.cluster.Spec.Hosts = r.hosts
 	}
 	logger.Debug("cluster info: %v", r.cluster)
@@ -0,0 +1,86 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apply
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/labring/sealos/pkg/apply/applydrivers"
+	"github.com/labring/sealos/pkg/apply/history"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// NewRollbackApplier returns an applier which re-applies the app images and the hosts of the revision.
+// The rootfs image is never changed by rollback, use `sealos upgrade rollback` to restore kubernetes instead.
+func NewRollbackApplier(clusterName string, revision *history.Revision) (applydrivers.Interface, error) {
+	cf := clusterfile.NewClusterFile(constants.Clusterfile(clusterName))
+	if err := cf.Process(); err != nil {
+		return nil, fmt.Errorf("failed to load cluster %s: %v", clusterName, err)
+	}
+	current := cf.GetCluster()
+
+	revisionFile := clusterfile.NewClusterFile(revision.Clusterfile())
+	if err := revisionFile.Process(); err != nil {
+		return nil, fmt.Errorf("failed to load revision %d: %v", revision.Revision, err)
+	}
+	cluster := revisionFile.GetCluster()
+	if cluster.Name != clusterName {
+		return nil, fmt.Errorf("revision %d belongs to cluster %s rather than %s", revision.Revision, cluster.Name, clusterName)
+	}
+	if rootfs := current.GetRootfsImage(""); rootfs != nil && revision.RootfsImage() != "" && rootfs.ImageName != revision.RootfsImage() {
+		return nil, fmt.Errorf("rootfs image of revision %d is %s but the cluster is running %s, use `sealos upgrade rollback` to restore kubernetes first",
+			revision.Revision, revision.RootfsImage(), rootfs.ImageName)
+	}
+	// the mounted images are still the ones of current cluster
+	cluster.Status = *current.Status.DeepCopy()
+	cluster.CreationTimestamp = current.CreationTimestamp
+
+	// the app images are pinned to the digests of revision, since their tags might have been pushed again
+	names, refs := revision.AppImages(), revision.AppImageReferences()
+	pinned := make(map[string]string, len(names))
+	for i := range names {
+		pinned[names[i]] = refs[i]
+	}
+	for i, img := range cluster.Spec.Image {
+		if ref, ok := pinned[img]; ok {
+			cluster.Spec.Image[i] = ref
+		}
+	}
+	// so that the containers of the apps are overridden by the pinned images rather than mounted again
+	for i := range cluster.Status.Mounts {
+		if m := &cluster.Status.Mounts[i]; m.Type == v2.AppImage && pinned[m.ImageName] != "" {
+			m.ImageName = pinned[m.ImageName]
+		}
+	}
+	apps := sets.NewString(names...).Insert(refs...)
+	for _, m := range current.Status.Mounts {
+		if m.Type == v2.AppImage && !apps.Has(m.ImageName) {
+			logger.Warn("app %s is installed after revision %d, it will be kept after rollback", m.ImageName, revision.Revision)
+			cluster.Spec.Image = append(cluster.Spec.Image, m.ImageName)
+		}
+	}
+
+	return &applydrivers.Applier{
+		ClusterDesired: cluster,
+		ClusterFile:    revisionFile,
+		ClusterCurrent: current,
+		RunNewImages:   refs,
+	}, nil
+}
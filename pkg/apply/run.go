@@ -63,7 +63,12 @@ func NewApplierFromArgs(imageName []string, args *RunArgs) (applydrivers.Interfa
 	if err = c.runArgs(imageName, args); err != nil {
 		return nil, err
 	}
-	return applydrivers.NewDefaultApplier(c.cluster, cf, imageName)
+	applier, err := applydrivers.NewDefaultApplier(c.cluster, cf, imageName)
+	if err != nil {
+		return nil, err
+	}
+	applier.(*applydrivers.Applier).Resume = args.Resume
+	return applier, nil
 }
 
 func (r *ClusterArgs) runArgs(imageList []string, args *RunArgs) error {
@@ -79,7 +84,7 @@ func (r *ClusterArgs) runArgs(imageList []string, args *RunArgs) error {
 			return fmt.Errorf("master ip(s) must specified")
 		}
 	} else {
-		if r.cluster.Status.Phase != v2.ClusterSuccess {
+		if r.cluster.Status.Phase != v2.ClusterSuccess && !args.Resume {
 			return fmt.Errorf("cluster status is not %s", v2.ClusterSuccess)
 		}
 	}
@@ -117,15 +122,25 @@ func (r *ClusterArgs) runArgs(imageList []string, args *RunArgs) error {
 
 	masters := stringsutil.SplitRemoveEmpty(args.Cluster.Masters, ",")
 	nodes := stringsutil.SplitRemoveEmpty(args.Cluster.Nodes, ",")
+	etcd := stringsutil.SplitRemoveEmpty(args.Cluster.Etcd, ",")
 	r.hosts = []v2.Host{}
 
-	clusterSSH := r.cluster.GetSSH()
-	sshClient := ssh.NewSSHClient(&clusterSSH, true)
+	sshClient := ssh.NewSSHClientWithCluster(r.cluster, true)
+	getRoles := func(role, ip string) []string {
+		// arch detection needs ssh, which is not allowed in dry-run mode
+		if args.IsDryRun() {
+			return []string{role}
+		}
+		return []string{role, GetHostArch(sshClient, ip)}
+	}
 	if len(masters) > 0 {
-		r.setHostWithIpsPort(masters, []string{v2.MASTER, GetHostArch(sshClient, masters[0])})
+		r.setHostWithIpsPort(masters, getRoles(v2.MASTER, masters[0]))
 	}
 	if len(nodes) > 0 {
-		r.setHostWithIpsPort(nodes, []string{v2.NODE, GetHostArch(sshClient, nodes[0])})
+		r.setHostWithIpsPort(nodes, getRoles(v2.NODE, nodes[0]))
+	}
+	if len(etcd) > 0 {
+		r.setHostWithIpsPort(etcd, getRoles(v2.ETCD, etcd[0]))
 	}
 	r.cluster.Spec.Hosts = append(r.cluster.Spec.Hosts, r.hosts...)
 	logger.Debug("cluster info: %v", r.cluster)
@@ -51,8 +51,8 @@ func NewScaleApplierFromArgs(scaleArgs *ScaleArgs, flag string) (applydrivers.In
 		curr = clusterFile.GetCluster().DeepCopy()
 	}
 
-	if scaleArgs.Cluster.Nodes == "" && scaleArgs.Cluster.Masters == "" {
-		return nil, fmt.Errorf("the node or master parameter was not committed")
+	if scaleArgs.Cluster.Nodes == "" && scaleArgs.Cluster.Masters == "" && scaleArgs.Cluster.Etcd == "" {
+		return nil, fmt.Errorf("the node, master or etcd parameter was not committed")
 	}
 	var err error
 	switch flag {
@@ -76,7 +76,7 @@ func joinNodes(cluster *v2.Cluster, scaleArgs *ScaleArgs) error {
 	if err := PreProcessIPList(scaleArgs.Cluster); err != nil {
 		return err
 	}
-	masters, nodes := scaleArgs.Cluster.Masters, scaleArgs.Cluster.Nodes
+	masters, nodes, etcd := scaleArgs.Cluster.Masters, scaleArgs.Cluster.Nodes, scaleArgs.Cluster.Etcd
 	if len(masters) > 0 {
 		if err := validateIPList(masters); err != nil {
 			return fmt.Errorf("%s in master list %s", err, masters)
@@ -87,6 +87,15 @@ func joinNodes(cluster *v2.Cluster, scaleArgs *ScaleArgs) error {
 			return fmt.Errorf("%s in node list %s", err, nodes)
 		}
 	}
+	if len(etcd) > 0 {
+		if err := validateIPList(etcd); err != nil {
+			return fmt.Errorf("%s in etcd list %s", err, etcd)
+		}
+		// the topology of etcd can't be changed after the cluster is created
+		if !cluster.IsExternalEtcd() {
+			return fmt.Errorf("etcd members can only be added to the cluster with external etcd")
+		}
+	}
 
 	defaultPort := strconv.Itoa(int(cluster.Spec.SSH.Port))
 
@@ -103,9 +112,12 @@ func joinNodes(cluster *v2.Cluster, scaleArgs *ScaleArgs) error {
 		ips := iputils.GetHostIPAndPortSlice(h.IPS, defaultPort)
 		alreadyIn.Insert(ips...)
 		hosts = append(hosts, v2.Host{
-			IPS:   ips,
-			Roles: h.Roles,
-			Env:   h.Env,
+			IPS:           ips,
+			Roles:         h.Roles,
+			Env:           h.Env,
+			Labels:        h.Labels,
+			Taints:        h.Taints,
+			KubeletConfig: h.KubeletConfig,
 		})
 	}
 	if !hasMaster {
@@ -128,8 +140,10 @@ func joinNodes(cluster *v2.Cluster, scaleArgs *ScaleArgs) error {
 			}
 		}
 		if len(addrs) > 0 {
-			clusterSSH := cluster.GetSSH()
-			sshClient := ssh.NewSSHClient(&clusterSSH, true)
+			if scaleArgs.IsDryRun() {
+				return &v2.Host{IPS: addrs, Roles: []string{role}}, nil
+			}
+			sshClient := ssh.NewSSHClientWithCluster(cluster, true)
 
 			return &v2.Host{
 				IPS:   addrs,
@@ -149,6 +163,11 @@ func joinNodes(cluster *v2.Cluster, scaleArgs *ScaleArgs) error {
 	} else if nodesToAdded != nil {
 		hosts = append(hosts, *nodesToAdded)
 	}
+	if etcdToAdded, err := getHostFunc(etcd, v2.ETCD, cluster.GetEtcdIPAndPortList()); err != nil {
+		return err
+	} else if etcdToAdded != nil {
+		hosts = append(hosts, *etcdToAdded)
+	}
 	cluster.Spec.Hosts = hosts
 	return nil
 }
@@ -161,6 +180,9 @@ func deleteNodes(cluster *v2.Cluster, scaleArgs *ScaleArgs) error {
 	if err := PreProcessIPList(scaleArgs.Cluster); err != nil {
 		return err
 	}
+	if scaleArgs.Cluster.Etcd != "" {
+		return fmt.Errorf("deleting etcd members is not supported")
+	}
 	masters, nodes := scaleArgs.Cluster.Masters, scaleArgs.Cluster.Nodes
 	if len(masters) > 0 {
 		if err := validateIPList(masters); err != nil {
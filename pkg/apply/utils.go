@@ -17,6 +17,7 @@ limitations under the License.
 package apply
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strings"
@@ -51,14 +52,20 @@ func PreProcessIPList(joinArgs *Cluster) error {
 	if err != nil {
 		return err
 	}
+	etcd, err := iputils.ParseIPList(joinArgs.Etcd)
+	if err != nil {
+		return err
+	}
 	mset := sets.NewString(masters...)
 	nset := sets.NewString(nodes...)
-	ret := mset.Intersection(nset)
+	eset := sets.NewString(etcd...)
+	ret := mset.Intersection(nset).Union(mset.Intersection(eset)).Union(nset.Intersection(eset))
 	if len(ret.List()) > 0 {
 		return fmt.Errorf("has duplicate ip: %v", ret.List())
 	}
 	joinArgs.Masters = strings.Join(masters, ",")
 	joinArgs.Nodes = strings.Join(nodes, ",")
+	joinArgs.Etcd = strings.Join(etcd, ",")
 	return nil
 }
 
@@ -88,7 +95,7 @@ func validateIPList(s string) error {
 
 func getHostArch(sshClient ssh.Interface) func(string) v2.Arch {
 	return func(ip string) v2.Arch {
-		out, err := sshClient.Cmd(ip, "arch")
+		out, err := sshClient.Cmd(context.Background(), ip, "arch")
 		if err != nil {
 			logger.Warn("failed to get host arch: %v, defaults to amd64", err)
 			return v2.AMD64
@@ -20,6 +20,8 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/system"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
@@ -45,10 +47,10 @@ type realBootstrap struct {
 	postflights  []Applier
 }
 
-func New(cluster *v2.Cluster) Interface {
-	ctx := NewContextFrom(cluster)
+func New(ctx context.Context, cluster *v2.Cluster) Interface {
+	bctx := NewContextFrom(ctx, cluster)
 	bs := &realBootstrap{
-		ctx:          ctx,
+		ctx:          bctx,
 		preflights:   make([]Applier, 0),
 		initializers: make([]Applier, 0),
 		postflights:  make([]Applier, 0),
@@ -57,6 +59,9 @@ func New(cluster *v2.Cluster) Interface {
 	_ = bs.RegisterApplier(Preflight, defaultPreflights...)
 	_ = bs.RegisterApplier(Init, defaultInitializers...)
 	_ = bs.RegisterApplier(Postflight, defaultPostflights...)
+	// hooks of the Clusterfile run around the builtin appliers
+	bs.preflights = append([]Applier{&hookApplier{phase: v2.HookPreBootstrap}}, bs.preflights...)
+	_ = bs.RegisterApplier(Postflight, &hookApplier{phase: v2.HookPostBootstrap})
 	return bs
 }
 
@@ -68,12 +73,15 @@ func (bs *realBootstrap) Apply(hosts ...string) error {
 	logger.Debug("apply %+v on hosts %+v", appliers, hosts)
 	for i := range appliers {
 		applier := appliers[i]
-		if err := runParallel(hosts, func(host string) error {
+		if err := runParallel(bs.ctx.GetContext(), hosts, func(host string) error {
 			if !applier.Filter(bs.ctx, host) {
 				return nil
 			}
 			logger.Debug("apply %s on host %s", applier, host)
-			return applier.Apply(bs.ctx, host)
+			step := events.StartStep(events.SourceBootstrap, fmt.Sprint(applier), host)
+			err := applier.Apply(bs.ctx, host)
+			step.Finish(err)
+			return err
 		}); err != nil {
 			return err
 		}
@@ -100,7 +108,7 @@ func (bs *realBootstrap) Delete(hosts ...string) error {
 	appliers = append(appliers, bs.postflights...)
 	appliers = append(appliers, bs.initializers...)
 	appliers = append(appliers, bs.preflights...)
-	return runParallel(hosts, func(host string) error {
+	return runParallel(bs.ctx.GetContext(), hosts, func(host string) error {
 		logger.Debug("delete runParallel %+v on host %s", appliers, host)
 		for i := range appliers {
 			applier := appliers[i]
@@ -108,7 +116,10 @@ func (bs *realBootstrap) Delete(hosts ...string) error {
 				continue
 			}
 			logger.Debug("undo %s on host %s", applier, host)
-			if err := applier.Undo(bs.ctx, host); err != nil {
+			step := events.StartStep(events.SourceBootstrap, "undo "+fmt.Sprint(applier), host)
+			err := applier.Undo(bs.ctx, host)
+			step.Finish(err)
+			if err != nil {
 				return err
 			}
 		}
@@ -116,11 +127,16 @@ func (bs *realBootstrap) Delete(hosts ...string) error {
 	})
 }
 
-func runParallel(hosts []string, fn func(string) error) error {
-	eg, _ := errgroup.WithContext(context.Background())
+func runParallel(ctx context.Context, hosts []string, fn func(string) error) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(system.GetParallelism())
 	for i := range hosts {
 		host := hosts[i]
 		eg.Go(func() error {
+			// do not start on the rest hosts once cancelled or any host failed
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			return fn(host)
 		})
 	}
@@ -139,7 +155,7 @@ func (c *defaultChecker) Filter(_ Context, _ string) bool {
 
 func (c *defaultChecker) Apply(ctx Context, host string) error {
 	cmds := []string{ctx.GetBash().CheckBash(host)}
-	return ctx.GetExecer().CmdAsync(host, cmds...)
+	return ctx.GetExecer().CmdAsync(ctx.GetContext(), host, cmds...)
 }
 
 func (c *defaultChecker) Undo(_ Context, _ string) error {
@@ -156,12 +172,12 @@ func (initializer *defaultInitializer) Filter(_ Context, _ string) bool {
 
 func (initializer *defaultInitializer) Apply(ctx Context, host string) error {
 	cmds := []string{ctx.GetBash().InitBash(host)}
-	return ctx.GetExecer().CmdAsync(host, cmds...)
+	return ctx.GetExecer().CmdAsync(ctx.GetContext(), host, cmds...)
 }
 
 func (initializer *defaultInitializer) Undo(ctx Context, host string) error {
 	cmds := []string{ctx.GetBash().CleanBash(host)}
-	return ctx.GetExecer().CmdAsync(host, cmds...)
+	return ctx.GetExecer().CmdAsync(ctx.GetContext(), host, cmds...)
 }
 
 func init() {
@@ -15,6 +15,8 @@
 package bootstrap
 
 import (
+	"context"
+
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/env"
 	"github.com/labring/sealos/pkg/remote"
@@ -23,6 +25,8 @@ import (
 )
 
 type Context interface {
+	// GetContext returns the context which cancels remote operations.
+	GetContext() context.Context
 	GetBash() constants.Bash
 	GetCluster() *v2.Cluster
 	GetData() constants.Data
@@ -31,6 +35,7 @@ type Context interface {
 }
 
 type realContext struct {
+	ctx     context.Context
 	bash    constants.Bash
 	cluster *v2.Cluster
 	data    constants.Data
@@ -38,6 +43,10 @@ type realContext struct {
 	remoter remote.Interface
 }
 
+func (ctx realContext) GetContext() context.Context {
+	return ctx.ctx
+}
+
 func (ctx realContext) GetBash() constants.Bash {
 	return ctx.bash
 }
@@ -58,11 +67,12 @@ func (ctx realContext) GetRemoter() remote.Interface {
 	return ctx.remoter
 }
 
-func NewContextFrom(cluster *v2.Cluster) Context {
-	execer := ssh.NewSSHClient(&cluster.Spec.SSH, true)
+func NewContextFrom(ctx context.Context, cluster *v2.Cluster) Context {
+	execer := ssh.NewSSHClientWithCluster(cluster, true)
 	envProcessor := env.NewEnvProcessor(cluster, cluster.Status.Mounts)
 	remoter := remote.New(cluster.GetName(), execer)
 	return &realContext{
+		ctx:     ctx,
 		cluster: cluster,
 		execer:  execer,
 		bash:    constants.NewBash(cluster.GetName(), cluster.GetImageLabels(), envProcessor.WrapperShell),
@@ -15,6 +15,7 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
 	"path"
 
@@ -28,7 +29,7 @@ import (
 	"github.com/labring/sealos/pkg/utils/yaml"
 )
 
-func GetRegistryInfo(sshInterface ssh.Interface, rootfs, defaultRegistry string) *v1beta1.RegistryConfig {
+func GetRegistryInfo(ctx context.Context, sshInterface ssh.Interface, rootfs, defaultRegistry string) *v1beta1.RegistryConfig {
 	const registryCustomConfig = "registry.yml"
 	var DefaultConfig = &v1beta1.RegistryConfig{
 		IP:       iputils.GetHostIP(defaultRegistry),
@@ -39,7 +40,7 @@ func GetRegistryInfo(sshInterface ssh.Interface, rootfs, defaultRegistry string)
 		Data:     constants.DefaultRegistryData,
 	}
 	etcPath := path.Join(rootfs, constants.EtcDirName, registryCustomConfig)
-	out, _ := sshInterface.Cmd(defaultRegistry, fmt.Sprintf("cat %s", etcPath))
+	out, _ := sshInterface.Cmd(ctx, defaultRegistry, fmt.Sprintf("cat %s", etcPath))
 	logger.Debug("image shim data info: %s", string(out))
 	registryConfig, err := yaml.UnmarshalData(out)
 	if err != nil {
@@ -0,0 +1,46 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"github.com/labring/sealos/pkg/hook"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+// hookApplier runs the hooks of a phase declared in the Clusterfile on each bootstrapped host.
+type hookApplier struct {
+	phase v2.HookPhase
+}
+
+func (a *hookApplier) String() string {
+	return "hook_" + string(a.phase)
+}
+
+func (a *hookApplier) Filter(ctx Context, host string) bool {
+	for _, h := range hook.Hooks(ctx.GetCluster(), a.phase) {
+		if len(hook.Targets(ctx.GetCluster(), h, []string{host})) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *hookApplier) Apply(ctx Context, host string) error {
+	return hook.Run(ctx.GetContext(), ctx.GetCluster(), ctx.GetExecer(), a.phase, host)
+}
+
+func (a *hookApplier) Undo(_ Context, _ string) error {
+	return nil
+}
@@ -39,10 +39,10 @@ func (*registryApplier) Filter(ctx Context, host string) bool {
 }
 
 func (a *registryApplier) Apply(ctx Context, host string) error {
-	rc := GetRegistryInfo(ctx.GetExecer(), ctx.GetData().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
+	rc := GetRegistryInfo(ctx.GetContext(), ctx.GetExecer(), ctx.GetData().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
 	lnCmd := fmt.Sprintf(constants.DefaultLnFmt, ctx.GetData().RootFSRegistryPath(), rc.Data)
 	logger.Debug("make soft link: %s", lnCmd)
-	if err := ctx.GetExecer().CmdAsync(host, lnCmd); err != nil {
+	if err := ctx.GetExecer().CmdAsync(ctx.GetContext(), host, lnCmd); err != nil {
 		return fmt.Errorf("failed to make link: %v", err)
 	}
 	htpasswdPath, err := a.configLocalHtpasswd(ctx.GetData().EtcPath(), rc)
@@ -50,11 +50,11 @@ func (a *registryApplier) Apply(ctx Context, host string) error {
 		return err
 	}
 	if len(htpasswdPath) > 0 {
-		if err = ctx.GetExecer().Copy(host, htpasswdPath, path.Join(ctx.GetData().RootFSEtcPath(), "registry_htpasswd")); err != nil {
+		if err = ctx.GetExecer().Copy(ctx.GetContext(), host, htpasswdPath, path.Join(ctx.GetData().RootFSEtcPath(), "registry_htpasswd")); err != nil {
 			return err
 		}
 	}
-	return ctx.GetExecer().CmdAsync(host, ctx.GetBash().InitRegistryBash(host))
+	return ctx.GetExecer().CmdAsync(ctx.GetContext(), host, ctx.GetBash().InitRegistryBash(host))
 }
 
 func (a *registryApplier) configLocalHtpasswd(cfgBasedir string, rc *v2.RegistryConfig) (string, error) {
@@ -98,7 +98,7 @@ func newHtpasswdMaker(root string) *htpasswdMaker {
 }
 
 func (*registryApplier) Undo(ctx Context, host string) error {
-	return ctx.GetExecer().CmdAsync(host, ctx.GetBash().CleanRegistryBash(host))
+	return ctx.GetExecer().CmdAsync(ctx.GetContext(), host, ctx.GetBash().CleanRegistryBash(host))
 }
 
 type registryHostApplier struct {
@@ -110,12 +110,12 @@ func (*registryHostApplier) Filter(_ Context, _ string) bool {
 }
 
 func (*registryHostApplier) Undo(ctx Context, host string) error {
-	rc := GetRegistryInfo(ctx.GetExecer(), ctx.GetData().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
+	rc := GetRegistryInfo(ctx.GetContext(), ctx.GetExecer(), ctx.GetData().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
 	return ctx.GetRemoter().HostsDelete(host, rc.Domain)
 }
 
 func (a *registryHostApplier) Apply(ctx Context, host string) error {
-	rc := GetRegistryInfo(ctx.GetExecer(), ctx.GetData().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
+	rc := GetRegistryInfo(ctx.GetContext(), ctx.GetExecer(), ctx.GetData().RootFSPath(), ctx.GetCluster().GetRegistryIPAndPort())
 
 	if err := ctx.GetRemoter().HostsAdd(host, iputils.GetHostIP(rc.IP), rc.Domain); err != nil {
 		return fmt.Errorf("failed to add hosts: %v", err)
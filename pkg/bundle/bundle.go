@@ -0,0 +1,243 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/containers/common/libimage"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/buildah"
+	"github.com/labring/sealos/pkg/clusterfile"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/utils/archive"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/hash"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/version"
+)
+
+const (
+	ManifestFileName = "manifest.json"
+	binaryFileName   = "sealos"
+	imagesDirName    = "images"
+)
+
+// Manifest describes the content of a bundle, the paths are relative to the root of the bundle.
+type Manifest struct {
+	ClusterName   string `json:"clusterName"`
+	SealosVersion string `json:"sealosVersion"`
+	Clusterfile   File   `json:"clusterfile"`
+	// +optional
+	Binary *File   `json:"binary,omitempty"`
+	Images []Image `json:"images"`
+	// +optional
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// File is a file in the bundle.
+type File struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+}
+
+// Image is an image saved in the bundle as an oci archive, the registry content embedded in the image is kept.
+type Image struct {
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Digest  string `json:"digest,omitempty"`
+	Archive File   `json:"archive"`
+}
+
+func newFile(dir, rel string) (File, error) {
+	digest := hash.FileDigest(filepath.Join(dir, rel))
+	if digest == "" {
+		return File{}, fmt.Errorf("failed to get digest of %s", rel)
+	}
+	return File{Path: rel, Digest: "sha256:" + digest}, nil
+}
+
+// Create saves the images in Spec.Image of the Clusterfile, the Clusterfile itself and the running sealos binary
+// if withBinary is set into a tar archive. The images missing in local storage are pulled first.
+func Create(ctx context.Context, bd buildah.Interface, clusterFile, output string, withBinary bool) (*Manifest, error) {
+	cf := clusterfile.NewClusterFile(clusterFile)
+	if err := cf.Process(); err != nil {
+		return nil, err
+	}
+	cluster := cf.GetCluster()
+	if cluster == nil || len(cluster.Spec.Image) == 0 {
+		return nil, fmt.Errorf("no images found in Clusterfile %s", clusterFile)
+	}
+	dir, err := os.MkdirTemp("", "sealos-bundle")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{
+		ClusterName:       cluster.Name,
+		SealosVersion:     version.Get().GitVersion,
+		CreationTimestamp: metav1.Now(),
+	}
+	if err = file.RecursionCopy(clusterFile, filepath.Join(dir, constants.DefaultClusterFileName)); err != nil {
+		return nil, err
+	}
+	if m.Clusterfile, err = newFile(dir, constants.DefaultClusterFileName); err != nil {
+		return nil, err
+	}
+	if withBinary {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		if err = file.RecursionCopy(executable, filepath.Join(dir, binaryFileName)); err != nil {
+			return nil, err
+		}
+		binary, err := newFile(dir, binaryFileName)
+		if err != nil {
+			return nil, err
+		}
+		m.Binary = &binary
+	}
+
+	if err = bd.Pull(cluster.Spec.Image, buildah.WithPullPolicyOption(buildah.PullIfMissing.String())); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Join(dir, imagesDirName), 0755); err != nil {
+		return nil, err
+	}
+	for i, name := range cluster.Spec.Image {
+		info, err := bd.InspectImage(name)
+		if err != nil {
+			return nil, err
+		}
+		rel := filepath.Join(imagesDirName, strconv.Itoa(i)+".tar")
+		logger.Info("save image %s into bundle", name)
+		if err = bd.Runtime().Save(ctx, []string{name}, buildah.OCIArchive, filepath.Join(dir, rel), &libimage.SaveOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to save image %s: %v", name, err)
+		}
+		archiveFile, err := newFile(dir, rel)
+		if err != nil {
+			return nil, err
+		}
+		m.Images = append(m.Images, Image{
+			Name:    name,
+			ID:      info.FromImageID.String(),
+			Digest:  info.FromImageDigest.String(),
+			Archive: archiveFile,
+		})
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = file.WriteFile(filepath.Join(dir, ManifestFileName), data); err != nil {
+		return nil, err
+	}
+	return m, writeArchive(dir, output)
+}
+
+func writeArchive(dir, output string) error {
+	reader, err := archive.NewArchive(false, false).TarOrGzip(dir)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if err = os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(filepath.Clean(output))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err = io.Copy(out, reader); err != nil {
+		return fmt.Errorf("failed to write bundle %s: %v", output, err)
+	}
+	return nil
+}
+
+// Extract extracts the bundle into dir and verifies the digests of its files.
+func Extract(input, dir string) (*Manifest, error) {
+	in, err := os.Open(filepath.Clean(input))
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	if _, err = archive.NewArchive(false, false).UnTarOrGzip(in, dir); err != nil {
+		return nil, fmt.Errorf("failed to extract bundle %s: %v", input, err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest of bundle: %v", err)
+	}
+	m := &Manifest{}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of bundle: %v", err)
+	}
+	return m, Verify(dir, m)
+}
+
+// Verify makes sure that the files in dir are the ones recorded in the manifest.
+func Verify(dir string, m *Manifest) error {
+	files := []File{m.Clusterfile}
+	if m.Binary != nil {
+		files = append(files, *m.Binary)
+	}
+	for _, img := range m.Images {
+		files = append(files, img.Archive)
+	}
+	for _, f := range files {
+		if !file.IsExist(filepath.Join(dir, f.Path)) {
+			return fmt.Errorf("%s is missing in bundle", f.Path)
+		}
+		actual, err := newFile(dir, f.Path)
+		if err != nil {
+			return err
+		}
+		if actual.Digest != f.Digest {
+			return fmt.Errorf("digest of %s is %s, but %s is expected, the bundle might be corrupted", f.Path, actual.Digest, f.Digest)
+		}
+	}
+	return nil
+}
+
+// Load loads the images of the bundle extracted in dir into local storage, and tags them with the names
+// recorded in the manifest. It returns the path of the Clusterfile in the bundle.
+func Load(bd buildah.Interface, dir string, m *Manifest) (string, error) {
+	for _, img := range m.Images {
+		logger.Info("load image %s from bundle", img.Name)
+		loaded, err := bd.Load(filepath.Join(dir, img.Archive.Path), buildah.OCIArchive)
+		if err != nil {
+			return "", fmt.Errorf("failed to load image %s: %v", img.Name, err)
+		}
+		image, _, err := bd.Runtime().LookupImage(loaded, nil)
+		if err != nil {
+			return "", err
+		}
+		if err = image.Tag(img.Name); err != nil {
+			return "", fmt.Errorf("failed to tag image %s: %v", img.Name, err)
+		}
+	}
+	return filepath.Join(dir, m.Clusterfile.Path), nil
+}
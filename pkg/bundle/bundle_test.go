@@ -0,0 +1,129 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/utils/file"
+)
+
+func newTestBundle(t *testing.T) (string, *Manifest) {
+	dir := t.TempDir()
+	files := map[string]string{
+		constants.DefaultClusterFileName:      "apiVersion: apps.sealos.io/v1beta1\nkind: Cluster\n",
+		binaryFileName:                        "binary",
+		filepath.Join(imagesDirName, "0.tar"): "image",
+	}
+	for name, content := range files {
+		if err := file.WriteFile(filepath.Join(dir, name), []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := &Manifest{ClusterName: "default"}
+	var err error
+	if m.Clusterfile, err = newFile(dir, constants.DefaultClusterFileName); err != nil {
+		t.Fatal(err)
+	}
+	binary, err := newFile(dir, binaryFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Binary = &binary
+	archiveFile, err := newFile(dir, filepath.Join(imagesDirName, "0.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Images = []Image{{Name: "labring/kubernetes:v1.25.0", Archive: archiveFile}}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = file.WriteFile(filepath.Join(dir, ManifestFileName), data); err != nil {
+		t.Fatal(err)
+	}
+	return dir, m
+}
+
+func TestExtract(t *testing.T) {
+	dir, want := newTestBundle(t)
+	output := filepath.Join(t.TempDir(), "site.tar")
+	if err := writeArchive(dir, output); err != nil {
+		t.Fatalf("writeArchive() error = %v", err)
+	}
+	extracted := t.TempDir()
+	got, err := Extract(output, extracted)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if got.ClusterName != want.ClusterName || got.Clusterfile != want.Clusterfile || len(got.Images) != 1 || got.Images[0] != want.Images[0] {
+		t.Errorf("Extract() manifest = %+v, want %+v", got, want)
+	}
+	if !file.IsExist(filepath.Join(extracted, constants.DefaultClusterFileName)) {
+		t.Errorf("Extract() Clusterfile is missing")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(dir string, m *Manifest)
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			modify: func(dir string, m *Manifest) {},
+		},
+		{
+			name: "no binary",
+			modify: func(dir string, m *Manifest) {
+				_ = os.Remove(filepath.Join(dir, binaryFileName))
+				m.Binary = nil
+			},
+		},
+		{
+			name: "missing image",
+			modify: func(dir string, m *Manifest) {
+				_ = os.Remove(filepath.Join(dir, imagesDirName, "0.tar"))
+			},
+			wantErr: "missing",
+		},
+		{
+			name: "corrupted clusterfile",
+			modify: func(dir string, m *Manifest) {
+				_ = file.WriteFile(filepath.Join(dir, constants.DefaultClusterFileName), []byte("modified"))
+			},
+			wantErr: "corrupted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, m := newTestBundle(t)
+			tt.modify(dir, m)
+			err := Verify(dir, m)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -312,3 +312,26 @@ func (meta *SealosCertMetaData) GenerateAll() error {
 	}
 	return nil
 }
+
+// GenerateEtcdMember generates the server, peer and healthcheck client certs of an etcd member into
+// certPath, they are signed by the etcd ca in caPath which must exist.
+func GenerateEtcdMember(caPath, certPath, nodeName, nodeIP string) error {
+	meta := &SealosCertMetaData{NodeName: nodeName, NodeIP: nodeIP}
+	certs := List(certPath, certPath)
+	meta.etcdAltAndCommonName(&certs)
+	etcdCA := CaList(KubeDefaultCertPath, caPath)[2]
+	caCert, caKey, err := LoadCaCertAndKeyFromDisk(etcdCA)
+	if err != nil {
+		return fmt.Errorf("failed to load etcd ca: %v", err)
+	}
+	for _, i := range []int{EtcdServerCert, EtcdPeerCert, EtcdHealthcheckClientCert} {
+		cert, key, err := NewCaCertAndKeyFromRoot(certs[i], caCert, caKey)
+		if err != nil {
+			return err
+		}
+		if err = WriteCertAndKey(certs[i].Path, certs[i].BaseName, cert, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,7 +15,10 @@
 package cert
 
 import (
+	"path/filepath"
 	"testing"
+
+	certutil "k8s.io/client-go/util/cert"
 )
 
 func TestGenerateAll(t *testing.T) {
@@ -43,3 +46,39 @@ func TestGenerateAll(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateEtcdMember(t *testing.T) {
+	dir := t.TempDir()
+	certPath, certEtcdPath := filepath.Join(dir, "pki"), filepath.Join(dir, "pki", "etcd")
+	certMeta, err := NewSealosCertMetaData(certPath, certEtcdPath, nil, "10.96.0.0/12", "master1", "192.168.0.2", "cluster.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = certMeta.GenerateAll(); err != nil {
+		t.Fatal(err)
+	}
+	memberPath := filepath.Join(dir, "member")
+	if err = GenerateEtcdMember(certEtcdPath, memberPath, "etcd1", "192.168.0.10"); err != nil {
+		t.Fatalf("GenerateEtcdMember() error = %v", err)
+	}
+	ca, err := certutil.CertsFromFile(filepath.Join(certEtcdPath, "ca.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"server", "peer", "healthcheck-client"} {
+		certs, err := certutil.CertsFromFile(filepath.Join(memberPath, name+".crt"))
+		if err != nil {
+			t.Fatalf("failed to load %s cert: %v", name, err)
+		}
+		if err = certs[0].CheckSignatureFrom(ca[0]); err != nil {
+			t.Errorf("%s cert is not signed by etcd ca: %v", name, err)
+		}
+	}
+	certs, err := certutil.CertsFromFile(filepath.Join(memberPath, "peer.crt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = certs[0].VerifyHostname("192.168.0.10"); err != nil {
+		t.Errorf("peer cert is not valid for the member ip: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// Expiration is the expiration of a certificate.
+type Expiration struct {
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	NotAfter  time.Time `json:"notAfter"`
+	IsCA      bool      `json:"isCA"`
+	Renewable bool      `json:"renewable"`
+}
+
+// CA is a certificate authority which signs the certs.
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// LoadCAs loads the ca certs and keys of kubernetes, front proxy and etcd from disk.
+func LoadCAs(certPath, certEtcdPath string) ([]CA, error) {
+	var cas []CA
+	for _, cfg := range CaList(certPath, certEtcdPath) {
+		caCert, caKey, err := LoadCaCertAndKeyFromDisk(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ca %s: %v", cfg.CommonName, err)
+		}
+		cas = append(cas, CA{Cert: caCert, Key: caKey})
+	}
+	return cas, nil
+}
+
+// findIssuer returns the ca which issued the cert, nil if not found.
+func findIssuer(cas []CA, cert *x509.Certificate) *CA {
+	for i := range cas {
+		if bytes.Equal(cas[i].Cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cas[i].Cert) == nil {
+			return &cas[i]
+		}
+	}
+	return nil
+}
+
+// ParseExpiration returns the expiration of the first cert in the PEM data, the other blocks such as keys are ignored.
+// A cert is renewable if it is not a ca and issued by one of the cas.
+func ParseExpiration(name string, data []byte, cas []CA) (*Expiration, error) {
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cert %s: %v", name, err)
+	}
+	c := certs[0]
+	return &Expiration{
+		Name:      name,
+		Subject:   c.Subject.CommonName,
+		NotAfter:  c.NotAfter,
+		IsCA:      c.IsCA,
+		Renewable: !c.IsCA && findIssuer(cas, c) != nil,
+	}, nil
+}
+
+// KubeConfigClientCert returns the client cert of the current context in the kubeconfig, nil if it is not embedded.
+func KubeConfigClientCert(data []byte) ([]byte, error) {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, err
+	}
+	if ctx, ok := config.Contexts[config.CurrentContext]; ok {
+		if auth, ok := config.AuthInfos[ctx.AuthInfo]; ok {
+			return auth.ClientCertificateData, nil
+		}
+	}
+	return nil, nil
+}
+
+// RenewCertPEM renews the cert with the same subject, alt names, usages and key as `kubeadm certs renew` does,
+// the cert is valid for the same period as before but never longer than its ca.
+func RenewCertPEM(data []byte, cas []CA) ([]byte, error) {
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	old := certs[0]
+	ca := findIssuer(cas, old)
+	if ca == nil {
+		return nil, fmt.Errorf("the issuer %s of cert %s is not found", old.Issuer.CommonName, old.Subject.CommonName)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	notAfter := now.Add(old.NotAfter.Sub(old.NotBefore))
+	if notAfter.After(ca.Cert.NotAfter) {
+		notAfter = ca.Cert.NotAfter
+	}
+	tmpl := x509.Certificate{
+		Subject:        old.Subject,
+		DNSNames:       old.DNSNames,
+		IPAddresses:    old.IPAddresses,
+		EmailAddresses: old.EmailAddresses,
+		URIs:           old.URIs,
+		SerialNumber:   serial,
+		NotBefore:      now,
+		NotAfter:       notAfter,
+		KeyUsage:       old.KeyUsage,
+		ExtKeyUsage:    old.ExtKeyUsage,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, ca.Cert, old.PublicKey, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	renewed, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeCertPEM(renewed), nil
+}
+
+// RenewKubeConfig renews the embedded client certs of the users in the kubeconfig.
+func RenewKubeConfig(data []byte, cas []CA) ([]byte, error) {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, err
+	}
+	for name, auth := range config.AuthInfos {
+		if len(auth.ClientCertificateData) == 0 {
+			continue
+		}
+		if auth.ClientCertificateData, err = RenewCertPEM(auth.ClientCertificateData, cas); err != nil {
+			return nil, fmt.Errorf("failed to renew client cert of user %s: %v", name, err)
+		}
+	}
+	return clientcmd.Write(*config)
+}
@@ -0,0 +1,118 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"crypto/x509"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+func newTestCA(t *testing.T, commonName string) CA {
+	key, err := NewPrivateKey(x509.UnknownPublicKeyAlgorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := NewSelfSignedCACert(key, commonName, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return CA{Cert: caCert, Key: key}
+}
+
+func TestRenewCertPEM(t *testing.T) {
+	cas := []CA{newTestCA(t, "kubernetes"), newTestCA(t, "etcd-ca")}
+	cfg := List("", "")[APIserverCert]
+	cfg.Year = 1
+	cfg.AltNames.DNSNames["apiserver.cluster.local"] = "apiserver.cluster.local"
+	old, key, err := NewCaCertAndKeyFromRoot(cfg, cas[0].Cert, cas[0].Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := RenewCertPEM(EncodeCertPEM(old), cas)
+	if err != nil {
+		t.Fatalf("RenewCertPEM() error = %v", err)
+	}
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed := certs[0]
+	if err = renewed.CheckSignatureFrom(cas[0].Cert); err != nil {
+		t.Errorf("renewed cert is not signed by the ca: %v", err)
+	}
+	if renewed.Subject.CommonName != old.Subject.CommonName || !reflect.DeepEqual(renewed.DNSNames, old.DNSNames) ||
+		!reflect.DeepEqual(renewed.ExtKeyUsage, old.ExtKeyUsage) {
+		t.Errorf("renewed cert %v is different from %v", renewed.Subject, old.Subject)
+	}
+	if !reflect.DeepEqual(renewed.PublicKey, key.Public()) {
+		t.Errorf("the key of renewed cert is changed")
+	}
+	if renewed.SerialNumber.Cmp(old.SerialNumber) == 0 {
+		t.Errorf("the serial number of renewed cert is not changed")
+	}
+	if validity := renewed.NotAfter.Sub(renewed.NotBefore); validity > old.NotAfter.Sub(old.NotBefore)+time.Minute {
+		t.Errorf("the renewed cert is valid for %s, longer than before", validity)
+	}
+
+	if _, err = RenewCertPEM(EncodeCertPEM(old), cas[1:]); err == nil {
+		t.Errorf("RenewCertPEM() should fail when the issuer is not found")
+	}
+}
+
+func TestRenewKubeConfig(t *testing.T) {
+	cas := []CA{newTestCA(t, "kubernetes")}
+	cfg := Config{CommonName: "kubernetes-admin", Organization: []string{"system:masters"}, Year: 1,
+		Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+	clientCert, clientKey, err := NewCaCertAndKeyFromRoot(cfg, cas[0].Cert, cas[0].Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyData, err := keyutil.MarshalPrivateKeyToPEM(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := CreateWithCerts("https://apiserver.cluster.local:6443", "kubernetes", "kubernetes-admin",
+		EncodeCertPEM(cas[0].Cert), keyData, EncodeCertPEM(clientCert))
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renewed, err := RenewKubeConfig(data, cas)
+	if err != nil {
+		t.Fatalf("RenewKubeConfig() error = %v", err)
+	}
+	certData, err := KubeConfigClientCert(renewed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := ParseExpiration("admin.conf", certData, cas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Subject != "kubernetes-admin" || !e.Renewable || e.IsCA {
+		t.Errorf("unexpected expiration of renewed client cert: %+v", e)
+	}
+	if reflect.DeepEqual(certData, EncodeCertPEM(clientCert)) {
+		t.Errorf("the client cert is not renewed")
+	}
+}
@@ -18,9 +18,12 @@ package checker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
@@ -34,28 +37,35 @@ type ClusterChecker struct {
 }
 
 type ClusterStatus struct {
-	IP                    string
-	Node                  string
-	KubeAPIServer         string
-	KubeControllerManager string
-	KubeScheduler         string
-	KubeletErr            string
+	IP                    string `json:"ip"`
+	Node                  string `json:"node"`
+	KubeAPIServer         string `json:"kubeAPIServer,omitempty"`
+	KubeControllerManager string `json:"kubeControllerManager,omitempty"`
+	KubeScheduler         string `json:"kubeScheduler,omitempty"`
+	KubeletErr            string `json:"kubeletErr"`
 }
 
+// healthyPodStatus is the status of a healthy pod rendered by kubernetes.Healthy.ForHealthyPod.
+const healthyPodStatus = string(corev1.PodRunning)
+
 func (n *ClusterChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
+	return n.Report(cluster).Output()
+}
 
+func (n *ClusterChecker) Report(cluster *v2.Cluster) *Report {
+	report := newReport("cluster")
 	// checker if all the node is ready
 	data := constants.NewData(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 	nodes, err := c.Kubernetes().CoreV1().Nodes().List(context.Background(), v1.ListOptions{})
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 	healthyClient := kubernetes.NewKubeHealthy(c.Kubernetes(), 30*time.Second)
 	var NodeList []ClusterStatus
@@ -65,33 +75,58 @@ func (n *ClusterChecker) Check(cluster *v2.Cluster, phase string) error {
 			IP:   ip,
 			Node: node.Name,
 		}
-		apiPod, err := kubernetes.GetStaticPod(c.Kubernetes(), node.Name, kubernetes.KubeAPIServer)
-		if err != nil {
-			return err
-		}
-		cStatus.KubeAPIServer = healthyClient.ForHealthyPod(apiPod)
-
-		controllerPod, err := kubernetes.GetStaticPod(c.Kubernetes(), node.Name, kubernetes.KubeControllerManager)
-		if err != nil {
-			return err
-		}
-		cStatus.KubeControllerManager = healthyClient.ForHealthyPod(controllerPod)
-
-		schedulerPod, err := kubernetes.GetStaticPod(c.Kubernetes(), node.Name, kubernetes.KubeScheduler)
-		if err != nil {
-			return err
+		var unhealthy []string
+		// the control plane components only run on masters
+		if isControlPlaneNode(node) {
+			for _, component := range []struct {
+				name   string
+				status *string
+			}{
+				{kubernetes.KubeAPIServer, &cStatus.KubeAPIServer},
+				{kubernetes.KubeControllerManager, &cStatus.KubeControllerManager},
+				{kubernetes.KubeScheduler, &cStatus.KubeScheduler},
+			} {
+				pod, err := kubernetes.GetStaticPod(c.Kubernetes(), node.Name, component.name)
+				if err != nil {
+					*component.status = err.Error()
+				} else {
+					*component.status = healthyClient.ForHealthyPod(pod)
+				}
+				if *component.status != healthyPodStatus {
+					unhealthy = append(unhealthy, fmt.Sprintf("%s: %s", component.name, *component.status))
+				}
+			}
 		}
-		cStatus.KubeScheduler = healthyClient.ForHealthyPod(schedulerPod)
 
 		if err = healthyClient.ForHealthyKubelet(5*time.Second, ip); err != nil {
 			cStatus.KubeletErr = err.Error()
+			unhealthy = append(unhealthy, fmt.Sprintf("kubelet: %s", cStatus.KubeletErr))
 		} else {
 			cStatus.KubeletErr = Nil
 		}
 		NodeList = append(NodeList, cStatus)
+		report.Nodes = append(report.Nodes, NodeReport{
+			Name:    node.Name,
+			IP:      ip,
+			Passed:  len(unhealthy) == 0,
+			Message: strings.Join(unhealthy, "; "),
+		})
+		if len(unhealthy) > 0 {
+			report.fail("components on node %s are not healthy", node.Name)
+		}
 	}
+	report.Details = NodeList
+	report.output = func() error { return n.Output(NodeList) }
+	return report
+}
 
-	return n.Output(NodeList)
+func isControlPlaneNode(node corev1.Node) bool {
+	for _, label := range []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"} {
+		if _, ok := node.Labels[label]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (n *ClusterChecker) Output(clusterStatus []ClusterStatus) error {
@@ -117,6 +152,6 @@ Cluster Status
 	return tpl.Execute(os.Stdout, map[string][]ClusterStatus{"ClusterStatusList": clusterStatus})
 }
 
-func NewClusterChecker() Interface {
+func NewClusterChecker() Reporter {
 	return &ClusterChecker{}
 }
@@ -33,22 +33,29 @@ type CRIShimChecker struct {
 }
 
 type CRIShimStatus struct {
-	Config    map[string]string
-	ImageList []string
-	Error     string
+	Config    map[string]string `json:"config,omitempty"`
+	ImageList []string          `json:"imageList,omitempty"`
+	Error     string            `json:"error"`
 }
 
-func (n *CRIShimChecker) Check(_ *v2.Cluster, phase string) error {
+func (n *CRIShimChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
+	return n.Report(cluster).Output()
+}
+
+func (n *CRIShimChecker) Report(_ *v2.Cluster) *Report {
+	report := newReport("cri-shim")
+	status := n.status()
+	report.setError(status.Error)
+	report.Details = status
+	report.output = func() error { return n.Output(status) }
+	return report
+}
+
+func (n *CRIShimChecker) status() *CRIShimStatus {
 	status := &CRIShimStatus{}
-	defer func() {
-		err := n.Output(status)
-		if err != nil {
-			logger.Error("error output: %+v", err)
-		}
-	}()
 
 	criShimConfig := "/etc/image-cri-shim.yaml"
 	if shimCfg, err := types.Unmarshal(criShimConfig); err != nil {
@@ -74,8 +81,10 @@ func (n *CRIShimChecker) Check(_ *v2.Cluster, phase string) error {
 		}
 	}
 
-	status.Error = Nil
-	return nil
+	if status.Error == "" {
+		status.Error = Nil
+	}
+	return status
 }
 
 func (n *CRIShimChecker) Output(status *CRIShimStatus) error {
@@ -103,6 +112,6 @@ CRIShim Service Status
 	return tpl.Execute(os.Stdout, status)
 }
 
-func NewCRIShimChecker() Interface {
+func NewCRIShimChecker() Reporter {
 	return &CRIShimChecker{}
 }
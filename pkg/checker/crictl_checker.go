@@ -17,6 +17,7 @@ limitations under the License.
 package checker
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -41,33 +42,40 @@ type CRICtlChecker struct {
 }
 
 type Container struct {
-	Container string
-	State     string
-	Name      string
-	Attempt   int
-	PodName   string
+	Container string `json:"container"`
+	State     string `json:"state"`
+	Name      string `json:"name"`
+	Attempt   int    `json:"attempt"`
+	PodName   string `json:"podName"`
 }
 
 type CRICtlStatus struct {
-	Config              map[string]string
-	ImageList           []string
-	ContainerList       []Container
-	RegistryPullStatus  string
-	ImageShimPullStatus string
-	Error               string
+	Config              map[string]string `json:"config,omitempty"`
+	ImageList           []string          `json:"imageList,omitempty"`
+	ContainerList       []Container       `json:"containerList,omitempty"`
+	RegistryPullStatus  string            `json:"registryPullStatus"`
+	ImageShimPullStatus string            `json:"imageShimPullStatus"`
+	Error               string            `json:"error"`
 }
 
 func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
+	return n.Report(cluster).Output()
+}
+
+func (n *CRICtlChecker) Report(cluster *v2.Cluster) *Report {
+	report := newReport("crictl")
+	status := n.status(cluster)
+	report.setError(status.Error)
+	report.Details = status
+	report.output = func() error { return n.Output(status) }
+	return report
+}
+
+func (n *CRICtlChecker) status(cluster *v2.Cluster) *CRICtlStatus {
 	status := &CRICtlStatus{}
-	defer func() {
-		err := n.Output(status)
-		if err != nil {
-			logger.Error("error output: %+v", err)
-		}
-	}()
 
 	criShimConfig := "/etc/crictl.yaml"
 	if cfg, err := fileutil.ReadAll(criShimConfig); err != nil {
@@ -82,7 +90,7 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	crictlPath, err := execer.LookPath("crictl")
 	if err != nil {
 		status.Error = fmt.Errorf("error looking for path of crictl: %w", err).Error()
-		return nil
+		return status
 	}
 
 	imageList, err := n.getCRICtlImageList(crictlPath)
@@ -106,11 +114,11 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 	sshCtx, err := ssh.NewSSHByCluster(cluster, false)
 	if err != nil {
 		status.Error = fmt.Errorf("get ssh interface error: %w", err).Error()
-		return nil
+		return status
 	}
 
 	root := constants.NewData(cluster.Name).RootFSPath()
-	regInfo := bootstrap.GetRegistryInfo(sshCtx, root, cluster.GetRegistryIPAndPort())
+	regInfo := bootstrap.GetRegistryInfo(context.Background(), sshCtx, root, cluster.GetRegistryIPAndPort())
 
 	regStatus, err := n.getRegistryStatus(crictlPath, pauseImage, fmt.Sprintf("%s:%s", regInfo.Domain, regInfo.Port))
 	if err != nil {
@@ -123,8 +131,10 @@ func (n *CRICtlChecker) Check(cluster *v2.Cluster, phase string) error {
 		status.Error = fmt.Errorf("pull shim image error: %w", err).Error()
 	}
 	status.ImageShimPullStatus = shimStatus
-	status.Error = Nil
-	return nil
+	if status.Error == "" {
+		status.Error = Nil
+	}
+	return status
 }
 
 func (n *CRICtlChecker) Output(status *CRICtlStatus) error {
@@ -157,7 +167,7 @@ CRI Status
 	return tpl.Execute(os.Stdout, status)
 }
 
-func NewCRICtlChecker() Interface {
+func NewCRICtlChecker() Reporter {
 	return &CRICtlChecker{}
 }
 
@@ -15,6 +15,7 @@
 package checker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -62,7 +63,7 @@ func checkHostnameUnique(s ssh.Interface, ipList []string) error {
 	logger.Info("checker:hostname %v", ipList)
 	hostnameList := map[string]bool{}
 	for _, ip := range ipList {
-		hostname, err := s.CmdToString(ip, "hostname", "")
+		hostname, err := s.CmdToString(context.Background(), ip, "hostname", "")
 		if err != nil {
 			return fmt.Errorf("failed to get host %s hostname, %v", ip, err)
 		}
@@ -79,7 +80,7 @@ func checkHostnameUnique(s ssh.Interface, ipList []string) error {
 func checkTimeSync(s ssh.Interface, ipList []string) error {
 	logger.Info("checker:timeSync %v", ipList)
 	for _, ip := range ipList {
-		timestamp, err := s.CmdToString(ip, "date +%s", "")
+		timestamp, err := s.CmdToString(context.Background(), ip, "date +%s", "")
 		if err != nil {
 			return fmt.Errorf("failed to get %s timestamp, %v", ip, err)
 		}
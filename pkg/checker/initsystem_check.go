@@ -31,31 +31,37 @@ type InitSystemChecker struct {
 }
 
 type systemStatus struct {
-	Name   string
-	Status string
+	Name   string `json:"name"`
+	Status string `json:"status"`
 }
 
 type InitSystemStatus struct {
-	Error       string
-	ServiceList []systemStatus
+	Error       string         `json:"error"`
+	ServiceList []systemStatus `json:"serviceList,omitempty"`
 }
 
-func (n *InitSystemChecker) Check(_ *v2.Cluster, phase string) error {
+func (n *InitSystemChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
-	status := &InitSystemStatus{}
+	return n.Report(cluster).Output()
+}
 
-	defer func() {
-		err := n.Output(status)
-		if err != nil {
-			logger.Error("error output: %+v", err)
-		}
-	}()
+func (n *InitSystemChecker) Report(_ *v2.Cluster) *Report {
+	report := newReport("initsystem")
+	status := n.status()
+	report.setError(status.Error)
+	report.Details = status
+	report.output = func() error { return n.Output(status) }
+	return report
+}
+
+func (n *InitSystemChecker) status() *InitSystemStatus {
+	status := &InitSystemStatus{}
 	initsystemvar, err := initsystem.GetInitSystem()
 	if err != nil {
 		status.Error = fmt.Errorf("get initsystem error: %w", err).Error()
-		return nil
+		return status
 	}
 
 	serviceNames := []string{"kubelet", "containerd", "cri-docker", "docker", "registry", "image-cri-shim"}
@@ -68,7 +74,7 @@ func (n *InitSystemChecker) Check(_ *v2.Cluster, phase string) error {
 	}
 
 	status.Error = Nil
-	return nil
+	return status
 }
 
 func (n *InitSystemChecker) Output(status *InitSystemStatus) error {
@@ -92,7 +98,7 @@ Systemd Service Status
 	return tpl.Execute(os.Stdout, status)
 }
 
-func NewInitSystemChecker() Interface {
+func NewInitSystemChecker() Reporter {
 	return &InitSystemChecker{}
 }
 
@@ -38,25 +38,30 @@ type NodeChecker struct {
 }
 
 type NodeClusterStatus struct {
-	ReadyCount       uint32
-	NotReadyCount    uint32
-	NodeCount        uint32
-	NotReadyNodeList []string
+	ReadyCount       uint32   `json:"readyCount"`
+	NotReadyCount    uint32   `json:"notReadyCount"`
+	NodeCount        uint32   `json:"nodeCount"`
+	NotReadyNodeList []string `json:"notReadyNodeList,omitempty"`
 }
 
 func (n *NodeChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
+	return n.Report(cluster).Output()
+}
+
+func (n *NodeChecker) Report(cluster *v2.Cluster) *Report {
+	report := newReport("node")
 	// checker if all the node is ready
 	data := constants.NewData(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 	nodes, err := c.Kubernetes().CoreV1().Nodes().List(context.Background(), v1.ListOptions{})
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 	var notReadyNodeList []string
 	var readyCount uint32
@@ -70,6 +75,12 @@ func (n *NodeChecker) Check(cluster *v2.Cluster, phase string) error {
 		} else {
 			readyCount++
 		}
+		report.Nodes = append(report.Nodes, NodeReport{
+			Name:    node.Name,
+			IP:      nodeIP,
+			Passed:  nodePhase == ReadyNodeStatus,
+			Message: nodePhase,
+		})
 	}
 	nodeCount = notReadyCount + readyCount
 	nodeClusterStatus := NodeClusterStatus{
@@ -78,7 +89,12 @@ func (n *NodeChecker) Check(cluster *v2.Cluster, phase string) error {
 		NodeCount:        nodeCount,
 		NotReadyNodeList: notReadyNodeList,
 	}
-	return n.Output(nodeClusterStatus)
+	if notReadyCount > 0 {
+		report.fail("%d/%d nodes are not ready", notReadyCount, nodeCount)
+	}
+	report.Details = nodeClusterStatus
+	report.output = func() error { return n.Output(nodeClusterStatus) }
+	return report
 }
 
 func (n *NodeChecker) Output(nodeCLusterStatus NodeClusterStatus) error {
@@ -127,6 +143,6 @@ func getNodeStatus(node corev1.Node) (IP string, Phase string) {
 	return IP, Phase
 }
 
-func NewNodeChecker() Interface {
+func NewNodeChecker() Reporter {
 	return &NodeChecker{}
 }
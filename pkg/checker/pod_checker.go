@@ -34,48 +34,60 @@ type PodChecker struct {
 }
 
 type PodNamespaceStatus struct {
-	NamespaceName     string
-	RunningCount      uint32
-	NotRunningCount   uint32
-	PodCount          uint32
-	NotRunningPodList []*corev1.Pod
+	NamespaceName     string        `json:"namespace"`
+	RunningCount      uint32        `json:"runningCount"`
+	NotRunningCount   uint32        `json:"notRunningCount"`
+	PodCount          uint32        `json:"podCount"`
+	NotRunningPodList []*corev1.Pod `json:"-"`
+	NotRunningPods    []string      `json:"notRunningPods,omitempty"`
 }
 
-var PodNamespaceStatusList []PodNamespaceStatus
-
 func (n *PodChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
+	return n.Report(cluster).Output()
+}
+
+func (n *PodChecker) Report(cluster *v2.Cluster) *Report {
+	report := newReport("pod")
 	// checker if all the node is ready
 	data := constants.NewData(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 
 	n.client = c
 
 	nsList, err := n.client.Kubernetes().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 
+	var podNamespaceStatusList []PodNamespaceStatus
+	var notRunningTotal uint32
 	for _, podNamespace := range nsList.Items {
 		var runningCount uint32
 		var notRunningCount uint32
 		var podCount uint32
 		var notRunningPodList []*corev1.Pod
+		var notRunningPods []string
 		namespacePodList, err := n.client.Kubernetes().CoreV1().Pods(podNamespace.Name).List(context.TODO(), metav1.ListOptions{})
 		if err != nil {
-			return err
+			return report.fail(err.Error())
 		}
 
 		for _, pod := range namespacePodList.Items {
+			// the pods of completed jobs are not expected to be running
+			if pod.Status.Phase == corev1.PodSucceeded {
+				continue
+			}
 			if err := getPodReadyStatus(pod); err != nil {
 				notRunningCount++
 				newPod := pod
 				notRunningPodList = append(notRunningPodList, &newPod)
+				notRunningPods = append(notRunningPods, pod.Name)
 			} else {
 				runningCount++
 			}
@@ -87,10 +99,17 @@ func (n *PodChecker) Check(cluster *v2.Cluster, phase string) error {
 			NotRunningCount:   notRunningCount,
 			PodCount:          podCount,
 			NotRunningPodList: notRunningPodList,
+			NotRunningPods:    notRunningPods,
 		}
-		PodNamespaceStatusList = append(PodNamespaceStatusList, podNamespaceStatus)
+		podNamespaceStatusList = append(podNamespaceStatusList, podNamespaceStatus)
+		notRunningTotal += notRunningCount
+	}
+	if notRunningTotal > 0 {
+		report.fail("%d pods are not running", notRunningTotal)
 	}
-	return n.Output(PodNamespaceStatusList)
+	report.Details = podNamespaceStatusList
+	report.output = func() error { return n.Output(podNamespaceStatusList) }
+	return report
 }
 
 func (n *PodChecker) Output(podNamespaceStatusList []PodNamespaceStatus) error {
@@ -127,6 +146,6 @@ func getPodReadyStatus(pod corev1.Pod) error {
 	return &NotFindReadyTypeError{}
 }
 
-func NewPodChecker() Interface {
+func NewPodChecker() Reporter {
 	return &PodChecker{}
 }
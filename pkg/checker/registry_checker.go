@@ -17,6 +17,7 @@ limitations under the License.
 package checker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -42,34 +43,46 @@ type RegistryChecker struct {
 }
 
 type RegistryStatus struct {
-	Port           string
-	DebugPort      string
-	Storage        string
-	Delete         bool
-	Htpasswd       string
-	RegistryDomain string
-	Auth           string
-	Ping           string
-	Error          string
+	Port           string `json:"port"`
+	DebugPort      string `json:"debugPort"`
+	Storage        string `json:"storage"`
+	Delete         bool   `json:"delete"`
+	Htpasswd       string `json:"-"`
+	RegistryDomain string `json:"registryDomain"`
+	Auth           string `json:"-"`
+	Ping           string `json:"ping"`
+	Error          string `json:"error"`
 }
 
 func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
+	report := n.Report(cluster)
+	if report.Skipped {
+		logger.Info(report.Message)
+		return nil
+	}
+	return report.Output()
+}
+
+func (n *RegistryChecker) Report(cluster *v2.Cluster) *Report {
+	report := newReport("registry")
 	localAddr, _ := iputils.ListLocalHostAddrs()
 	if !iputils.IsLocalIP(cluster.GetRegistryIP(), localAddr) {
-		logger.Info("current registry ip is %s,not local addr,skip check.", cluster.GetRegistryIP())
-		return nil
+		report.Skipped = true
+		report.Message = fmt.Sprintf("current registry ip is %s,not local addr,skip check.", cluster.GetRegistryIP())
+		return report
 	}
-	status := &RegistryStatus{}
-	defer func() {
-		err := n.Output(status)
-		if err != nil {
-			logger.Error("error output: %+v", err)
-		}
-	}()
+	status := n.status(cluster)
+	report.setError(status.Error)
+	report.Details = status
+	report.output = func() error { return n.Output(status) }
+	return report
+}
 
+func (n *RegistryChecker) status(cluster *v2.Cluster) *RegistryStatus {
+	status := &RegistryStatus{}
 	registryConfig := "/etc/registry/registry_config.yml"
 	if cfg, err := fileutil.ReadAll(registryConfig); err != nil {
 		status.Error = fmt.Errorf("read registry config error: %w", err).Error()
@@ -89,10 +102,10 @@ func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 	sshCtx, err := ssh.NewSSHByCluster(cluster, false)
 	if err != nil {
 		status.Error = fmt.Errorf("get ssh interface error: %w", err).Error()
-		return nil
+		return status
 	}
 	root := constants.NewData(cluster.Name).RootFSPath()
-	regInfo := bootstrap.GetRegistryInfo(sshCtx, root, cluster.GetRegistryIPAndPort())
+	regInfo := bootstrap.GetRegistryInfo(context.Background(), sshCtx, root, cluster.GetRegistryIPAndPort())
 	status.Auth = fmt.Sprintf("%s:%s", regInfo.Username, regInfo.Password)
 	status.RegistryDomain = fmt.Sprintf("%s:%s", regInfo.Domain, regInfo.Port)
 	cfg := types.AuthConfig{
@@ -102,11 +115,13 @@ func (n *RegistryChecker) Check(cluster *v2.Cluster, phase string) error {
 	_, err = registry.NewRegistry(status.RegistryDomain, cfg)
 	if err != nil {
 		status.Error = fmt.Errorf("get registry interface error: %w", err).Error()
-		return nil
+		return status
 	}
 	status.Ping = "ok"
-	status.Error = Nil
-	return nil
+	if status.Error == "" {
+		status.Error = Nil
+	}
+	return status
 }
 
 func (n *RegistryChecker) Output(status *RegistryStatus) error {
@@ -132,6 +147,6 @@ func (n *RegistryChecker) Output(status *RegistryStatus) error {
 	return tpl.Execute(os.Stdout, status)
 }
 
-func NewRegistryChecker() Interface {
+func NewRegistryChecker() Reporter {
 	return &RegistryChecker{}
 }
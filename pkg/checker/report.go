@@ -0,0 +1,142 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// Reporter is a post install checker which reports its result in a structured way.
+type Reporter interface {
+	Interface
+	// Report runs the checks without printing the result.
+	Report(cluster *v2.Cluster) *Report
+}
+
+// Report is the structured result of a checker.
+type Report struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Nodes are the results of each node if the checker runs against the nodes of cluster.
+	Nodes []NodeReport `json:"nodes,omitempty"`
+	// Details are the raw status collected by the checker.
+	Details interface{} `json:"details,omitempty"`
+
+	output func() error
+}
+
+// NodeReport is the result of a checker on a single node.
+type NodeReport struct {
+	Name    string `json:"name"`
+	IP      string `json:"ip,omitempty"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// ClusterReport is the results of all checkers of a cluster.
+type ClusterReport struct {
+	Cluster  string    `json:"cluster"`
+	Passed   bool      `json:"passed"`
+	Checkers []*Report `json:"checkers"`
+}
+
+func newReport(name string) *Report {
+	return &Report{Name: name, Passed: true}
+}
+
+// fail marks the report as failed, the checker is considered not able to run if it has no text output.
+func (r *Report) fail(format string, a ...interface{}) *Report {
+	r.Passed = false
+	r.Message = fmt.Sprintf(format, a...)
+	return r
+}
+
+// setError marks the report as failed if the error message of checker status is not empty.
+func (r *Report) setError(msg string) {
+	if msg != "" && msg != Nil {
+		r.Passed = false
+		r.Message = msg
+	}
+}
+
+// Output renders the report in the text format of the checker.
+func (r *Report) Output() error {
+	if r.output == nil {
+		if !r.Passed {
+			return errors.New(r.Message)
+		}
+		return nil
+	}
+	return r.output()
+}
+
+// RunReportList runs the checkers in turn and collects their reports.
+func RunReportList(list []Reporter, cluster *v2.Cluster) *ClusterReport {
+	report := &ClusterReport{Cluster: cluster.Name, Passed: true}
+	for _, l := range list {
+		r := l.Report(cluster)
+		if !r.Passed {
+			report.Passed = false
+		}
+		report.Checkers = append(report.Checkers, r)
+	}
+	return report
+}
+
+// Print writes the reports to w, format is one of OutputText, OutputJSON or OutputYAML.
+func (c *ClusterReport) Print(w io.Writer, format string) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	case OutputYAML:
+		data, err := yaml.Marshal(c)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	case OutputText, "":
+		for _, r := range c.Checkers {
+			if r.Skipped {
+				logger.Info(r.Message)
+				continue
+			}
+			if err := r.Output(); err != nil {
+				logger.Error("failed to run %s checker: %v", r.Name, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %s, only %s, %s and %s are allowed", format, OutputText, OutputJSON, OutputYAML)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+type fakeReporter struct {
+	report *Report
+}
+
+func (f *fakeReporter) Check(_ *v2.Cluster, _ string) error {
+	return f.report.Output()
+}
+
+func (f *fakeReporter) Report(_ *v2.Cluster) *Report {
+	return f.report
+}
+
+func TestRunReportList(t *testing.T) {
+	cluster := &v2.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	passed := newReport("registry")
+	passed.setError(Nil)
+	failed := newReport("node")
+	failed.Nodes = []NodeReport{{Name: "node1", IP: "1.1.1.1", Passed: false, Message: NotReadyNodeStatus}}
+	failed.fail("%d/%d nodes are not ready", 1, 1)
+
+	report := RunReportList([]Reporter{&fakeReporter{passed}, &fakeReporter{failed}}, cluster)
+	if report.Passed {
+		t.Errorf("RunReportList() should fail if any checker fails")
+	}
+	if err := failed.Output(); err == nil {
+		t.Errorf("Output() of a failed report without text output should return error")
+	}
+
+	var buf bytes.Buffer
+	if err := report.Print(&buf, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var got ClusterReport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Cluster != "default" || len(got.Checkers) != 2 || !got.Checkers[0].Passed || got.Checkers[1].Nodes[0].Name != "node1" {
+		t.Errorf("Print() json = %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.Print(&buf, OutputYAML); err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(buf.Bytes(), &got); err != nil || got.Passed {
+		t.Errorf("Print() yaml = %s, err = %v", buf.String(), err)
+	}
+	if err := report.Print(&buf, "xml"); err == nil {
+		t.Errorf("Print() expected error of unsupported format")
+	}
+}
@@ -36,10 +36,10 @@ type SvcChecker struct {
 }
 
 type SvcNamespaceStatus struct {
-	NamespaceName       string
-	ServiceCount        int
-	EndpointCount       int
-	UnhealthServiceList []string
+	NamespaceName       string   `json:"namespace"`
+	ServiceCount        int      `json:"serviceCount"`
+	EndpointCount       int      `json:"endpointCount"`
+	UnhealthServiceList []string `json:"unhealthyServiceList,omitempty"`
 }
 
 type SvcClusterStatus struct {
@@ -50,11 +50,16 @@ func (n *SvcChecker) Check(cluster *v2.Cluster, phase string) error {
 	if phase != PhasePost {
 		return nil
 	}
+	return n.Report(cluster).Output()
+}
+
+func (n *SvcChecker) Report(cluster *v2.Cluster) *Report {
+	report := newReport("svc")
 	// checker if all the node is ready
 	data := constants.NewData(cluster.Name)
 	c, err := kubernetes.NewKubernetesClient(data.AdminFile(), "")
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 
 	n.client = c
@@ -63,13 +68,11 @@ func (n *SvcChecker) Check(cluster *v2.Cluster, phase string) error {
 
 	nsList, err := n.client.Kubernetes().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return err
+		return report.fail(err.Error())
 	}
 
 	var svcNamespaceStatusList []*SvcNamespaceStatus
-	if err != nil {
-		return err
-	}
+	var unhealthyTotal int
 	for _, svcNamespace := range nsList.Items {
 		namespaceSVCList, err := n.client.Kubernetes().CoreV1().Services(svcNamespace.Name).List(context.TODO(), metav1.ListOptions{})
 		if err != nil {
@@ -99,8 +102,14 @@ func (n *SvcChecker) Check(cluster *v2.Cluster, phase string) error {
 			UnhealthServiceList: unhaelthService,
 		}
 		svcNamespaceStatusList = append(svcNamespaceStatusList, &svcNamespaceStatus)
+		unhealthyTotal += len(unhaelthService)
+	}
+	if unhealthyTotal > 0 {
+		report.fail("%d services have no endpoints", unhealthyTotal)
 	}
-	return n.Output(svcNamespaceStatusList)
+	report.Details = svcNamespaceStatusList
+	report.output = func() error { return n.Output(svcNamespaceStatusList) }
+	return report
 }
 
 func (n *SvcChecker) Output(svcNamespaceStatusList []*SvcNamespaceStatus) error {
@@ -135,6 +144,6 @@ func IsExistEndpoint(endpointList *corev1.EndpointsList, serviceName string) boo
 	return false
 }
 
-func NewSvcChecker() Interface {
+func NewSvcChecker() Reporter {
 	return &SvcChecker{}
 }
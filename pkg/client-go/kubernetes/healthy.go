@@ -44,6 +44,8 @@ type Healthy interface {
 	SetTimeout(timeout time.Duration)
 	// ForHealthyPod pod status
 	ForHealthyPod(pod *v1.Pod) string
+	// ForNodeReady waits for the node to report the Ready condition
+	ForNodeReady(name string) error
 }
 
 // KubeHealthy is an implementation of Healthy that is backed by a Kubernetes client
@@ -112,6 +114,25 @@ func (w *kubeHealthy) ForHealthyKubelet(initialTimeout time.Duration, host strin
 	}, 5) // a failureThreshold of five means waiting for a total of 155 seconds
 }
 
+// ForNodeReady waits for the Ready condition of the node to become true
+func (w *kubeHealthy) ForNodeReady(name string) error {
+	start := time.Now()
+	return wait.PollImmediate(APICallRetryInterval, w.timeout, func() (bool, error) {
+		node, err := w.client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			logger.Debug("[node-check] failed to get node %s: %v", name, err)
+			return false, nil
+		}
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == v1.NodeReady && cond.Status == v1.ConditionTrue {
+				logger.Debug("[node-check] node %s is ready after %f seconds\n", name, time.Since(start).Seconds())
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
 // SetTimeout adjusts the timeout to the specified duration
 func (w *kubeHealthy) SetTimeout(timeout time.Duration) {
 	w.timeout = timeout
@@ -0,0 +1,121 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/utils/file"
+)
+
+// ResetArchive is a Clusterfile renamed with the unix time suffix after the cluster is reset.
+type ResetArchive struct {
+	ClusterName string
+	Path        string
+	ResetTime   time.Time
+}
+
+// ListClusterNames returns the names of the clusters which have a Clusterfile under the runtime root.
+func ListClusterNames() ([]string, error) {
+	entries, err := os.ReadDir(constants.Workdir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && file.IsExist(constants.Clusterfile(entry.Name())) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// GetCurrentClusterName returns the cluster selected by SetCurrentClusterName, empty if no one is selected.
+func GetCurrentClusterName() string {
+	data, err := os.ReadFile(constants.CurrentClusterFile())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// SetCurrentClusterName selects the cluster as the default one of the commands.
+func SetCurrentClusterName(name string) error {
+	if !file.IsExist(constants.Clusterfile(name)) {
+		return fmt.Errorf("cluster %s not found", name)
+	}
+	return file.WriteFile(constants.CurrentClusterFile(), []byte(name+"\n"))
+}
+
+// ListResetArchives returns the reset archives of all clusters sorted by reset time, the latest first.
+func ListResetArchives() ([]ResetArchive, error) {
+	entries, err := os.ReadDir(constants.Workdir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var archives []ResetArchive
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		files, err := filepath.Glob(constants.Clusterfile(entry.Name()) + ".*")
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			sec, err := strconv.ParseInt(strings.TrimPrefix(filepath.Ext(f), "."), 10, 64)
+			if err != nil {
+				continue
+			}
+			archives = append(archives, ResetArchive{
+				ClusterName: entry.Name(),
+				Path:        f,
+				ResetTime:   time.Unix(sec, 0),
+			})
+		}
+	}
+	sort.SliceStable(archives, func(i, j int) bool {
+		return archives[i].ResetTime.After(archives[j].ResetTime)
+	})
+	return archives, nil
+}
+
+// PruneResetArchives selects the archives to remove, the latest keep archives of each cluster
+// and the archives reset within olderThan are retained.
+func PruneResetArchives(archives []ResetArchive, keep int, olderThan time.Duration, now time.Time) []ResetArchive {
+	kept := make(map[string]int)
+	var pruned []ResetArchive
+	for _, a := range archives {
+		if kept[a.ClusterName] < keep || now.Sub(a.ResetTime) < olderThan {
+			kept[a.ClusterName]++
+			continue
+		}
+		pruned = append(pruned, a)
+	}
+	return pruned
+}
@@ -0,0 +1,67 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clusterfile
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/utils/file"
+)
+
+func TestInventory(t *testing.T) {
+	old := constants.DefaultRuntimeRootDir
+	constants.DefaultRuntimeRootDir = t.TempDir()
+	defer func() { constants.DefaultRuntimeRootDir = old }()
+
+	now := time.Now()
+	for _, f := range []string{
+		constants.Clusterfile("a"),
+		fmt.Sprintf("%s.%d", constants.Clusterfile("a"), now.Add(-time.Hour).Unix()),
+		fmt.Sprintf("%s.%d", constants.Clusterfile("b"), now.Add(-48*time.Hour).Unix()),
+		fmt.Sprintf("%s.%d", constants.Clusterfile("b"), now.Add(-24*time.Hour).Unix()),
+		fmt.Sprintf("%s.%d", constants.Clusterfile("b"), now.Add(-2*time.Hour).Unix()),
+		constants.Clusterfile("c") + ".bak",
+	} {
+		if err := file.WriteFile(f, []byte("")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := ListClusterNames()
+	if err != nil || len(names) != 1 || names[0] != "a" {
+		t.Errorf("ListClusterNames() = %v, %v, want [a]", names, err)
+	}
+	if err = SetCurrentClusterName("b"); err == nil {
+		t.Errorf("SetCurrentClusterName() should fail for the reset cluster")
+	}
+	if err = SetCurrentClusterName("a"); err != nil || GetCurrentClusterName() != "a" {
+		t.Errorf("GetCurrentClusterName() = %s, %v, want a", GetCurrentClusterName(), err)
+	}
+
+	archives, err := ListResetArchives()
+	if err != nil || len(archives) != 4 {
+		t.Fatalf("ListResetArchives() = %v, %v, want 4 archives", archives, err)
+	}
+	if pruned := PruneResetArchives(archives, 1, 0, now); len(pruned) != 2 {
+		t.Errorf("PruneResetArchives() keep 1 = %v, want 2 archives of b", pruned)
+	}
+	pruned := PruneResetArchives(archives, 0, 36*time.Hour, now)
+	if len(pruned) != 1 || pruned[0].ClusterName != "b" || now.Sub(pruned[0].ResetTime) < 36*time.Hour {
+		t.Errorf("PruneResetArchives() older than 36h = %v, want the oldest archive of b", pruned)
+	}
+}
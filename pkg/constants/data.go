@@ -39,6 +39,7 @@ const (
 	DefaultKubeadmTokenFileName      = "kubeadm-token.json"
 	DefaultCertificateKeyFileName    = "kubeadm-certificate-key.txt"
 	DefaultUpdateKubeadmFileName     = "kubeadm-update.yaml"
+	DefaultEtcdKubeadmFileName       = "kubeadm-etcd.yaml"
 	DefaultRootfsKubeadmFileName     = "kubeadm.yml"
 	DataDirName                      = "rootfs"
 	EtcDirName                       = "etc"
@@ -52,6 +53,7 @@ const (
 	PkiEtcdDirName                   = "etcd"
 	ScriptsDirName                   = "scripts"
 	StaticsDirName                   = "statics"
+	BackupDirName                    = "backup"
 )
 
 func GetRuntimeRootDir(name string) string {
@@ -94,6 +96,7 @@ type Data interface {
 	AdminFile() string
 	EtcPath() string
 	TmpPath() string
+	BackupPath() string
 
 	RootFSCharsPath() string
 	RootFSManifestsPath() string
@@ -160,6 +163,10 @@ func (d *data) RootFSStaticsPath() string {
 	return filepath.Join(d.RootFSPath(), StaticsDirName)
 }
 
+func (d *data) BackupPath() string {
+	return filepath.Join(d.Homedir(), BackupDirName)
+}
+
 func (d *data) Homedir() string {
 	return filepath.Join(DefaultClusterRootFsDir, "data", d.clusterName)
 }
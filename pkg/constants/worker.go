@@ -20,6 +20,7 @@ import "path/filepath"
 
 const (
 	DefaultClusterFileName = "Clusterfile"
+	currentClusterFileName = ".current-cluster"
 )
 
 func Workdir() string {
@@ -33,3 +34,8 @@ func ClusterDir(clusterName string) string {
 func Clusterfile(clusterName string) string {
 	return filepath.Join(Workdir(), clusterName, DefaultClusterFileName)
 }
+
+// CurrentClusterFile is the file which records the cluster selected by `sealos cluster use`.
+func CurrentClusterFile() string {
+	return filepath.Join(Workdir(), currentClusterFileName)
+}
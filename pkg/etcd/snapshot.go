@@ -0,0 +1,223 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/runtime"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	snapshotDirName = "etcd-snapshots"
+	snapshotFileExt = ".db"
+	metadataFileExt = ".json"
+)
+
+// Snapshot is the metadata of an etcd snapshot which is stored alongside the Clusterfile.
+type Snapshot struct {
+	Name              string      `json:"name"`
+	ClusterName       string      `json:"clusterName"`
+	Host              string      `json:"host"`
+	KubernetesVersion string      `json:"kubernetesVersion,omitempty"`
+	Size              int64       `json:"size"`
+	SHA256            string      `json:"sha256"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+}
+
+// SnapshotDir returns the local directory of the etcd snapshots of the cluster.
+func SnapshotDir(clusterName string) string {
+	return filepath.Join(constants.ClusterDir(clusterName), snapshotDirName)
+}
+
+// Path returns the local path of the snapshot file.
+func (s *Snapshot) Path() string {
+	return filepath.Join(SnapshotDir(s.ClusterName), s.Name+snapshotFileExt)
+}
+
+func (s *Snapshot) metadataPath() string {
+	return filepath.Join(SnapshotDir(s.ClusterName), s.Name+metadataFileExt)
+}
+
+// Verify checks the snapshot file against the recorded checksum.
+func (s *Snapshot) Verify() error {
+	sum, err := sha256File(s.Path())
+	if err != nil {
+		return err
+	}
+	if sum != s.SHA256 {
+		return fmt.Errorf("checksum of snapshot %s mismatch, expected %s but got %s", s.Name, s.SHA256, sum)
+	}
+	return nil
+}
+
+// Save takes an etcd snapshot of the cluster by the runtime and stores it locally,
+// a name is generated from the current time if name is empty.
+func Save(cluster *v2.Cluster, rt runtime.Interface, name string) (*Snapshot, error) {
+	if name == "" {
+		name = "snapshot-" + time.Now().Format("20060102-150405")
+	}
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	s := &Snapshot{
+		Name:              name,
+		ClusterName:       cluster.Name,
+		Host:              cluster.GetMaster0IPAndPort(),
+		KubernetesVersion: cluster.GetRootfsImage("").Labels[v2.ImageKubeVersionKey],
+		CreationTimestamp: metav1.Now(),
+	}
+	if file.IsExist(s.metadataPath()) || file.IsExist(s.Path()) {
+		return nil, fmt.Errorf("snapshot %s of cluster %s already exists", name, cluster.Name)
+	}
+	if err := file.MkDirs(SnapshotDir(cluster.Name)); err != nil {
+		return nil, err
+	}
+	if err := rt.SaveEtcdSnapshot(s.Path()); err != nil {
+		_ = os.Remove(s.Path())
+		return nil, err
+	}
+	var err error
+	if s.Size, err = file.GetFileSize(s.Path()); err != nil {
+		return nil, err
+	}
+	if s.SHA256, err = sha256File(s.Path()); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = file.WriteFile(s.metadataPath(), data); err != nil {
+		return nil, err
+	}
+	logger.Info("etcd snapshot %s of cluster %s is saved to %s", s.Name, s.ClusterName, s.Path())
+	return s, nil
+}
+
+// List returns the snapshots of the cluster sorted by creation time, the oldest first.
+func List(clusterName string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(SnapshotDir(clusterName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != metadataFileExt {
+			continue
+		}
+		s, err := Get(clusterName, strings.TrimSuffix(entry.Name(), metadataFileExt))
+		if err != nil {
+			logger.Warn("skip invalid snapshot metadata %s: %v", entry.Name(), err)
+			continue
+		}
+		snapshots = append(snapshots, *s)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].CreationTimestamp.Before(&snapshots[j].CreationTimestamp)
+	})
+	return snapshots, nil
+}
+
+// Get returns the snapshot of the cluster by name.
+func Get(clusterName, name string) (*Snapshot, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	s := &Snapshot{Name: name, ClusterName: clusterName}
+	data, err := os.ReadFile(s.metadataPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("snapshot %s of cluster %s not found", name, clusterName)
+		}
+		return nil, err
+	}
+	if err = json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata of snapshot %s: %v", name, err)
+	}
+	return s, nil
+}
+
+// Remove deletes the snapshot file and its metadata.
+func Remove(s *Snapshot) error {
+	return file.CleanFiles(s.Path(), s.metadataPath())
+}
+
+// Prune removes the oldest snapshots of the cluster and keeps the latest ones.
+func Prune(clusterName string, keep int) ([]Snapshot, error) {
+	snapshots, err := List(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if keep < 0 || len(snapshots) <= keep {
+		return nil, nil
+	}
+	pruned := snapshots[:len(snapshots)-keep]
+	for i := range pruned {
+		if err = Remove(&pruned[i]); err != nil {
+			return nil, err
+		}
+		logger.Info("etcd snapshot %s of cluster %s is pruned", pruned[i].Name, clusterName)
+	}
+	return pruned, nil
+}
+
+// Restore verifies the snapshot and restores the control plane of the cluster from it by the runtime.
+func Restore(s *Snapshot, rt runtime.Interface) error {
+	if err := s.Verify(); err != nil {
+		return err
+	}
+	logger.Info("start to restore cluster %s from etcd snapshot %s", s.ClusterName, s.Name)
+	return rt.RestoreEtcdSnapshot(s.Path())
+}
+
+// validateName rejects the snapshot names which would escape the snapshot directory.
+func validateName(name string) error {
+	if strings.ContainsRune(name, filepath.Separator) {
+		return fmt.Errorf("invalid snapshot name %s", name)
+	}
+	return nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
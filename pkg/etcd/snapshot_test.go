@@ -0,0 +1,104 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/utils/file"
+)
+
+func writeTestSnapshot(t *testing.T, name string, created time.Time) *Snapshot {
+	s := &Snapshot{Name: name, ClusterName: "default", CreationTimestamp: metav1.NewTime(created)}
+	if err := file.WriteFile(s.Path(), []byte(name)); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := sha256File(s.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SHA256 = sum
+	data, _ := json.Marshal(s)
+	if err = file.WriteFile(s.metadataPath(), data); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestListAndPrune(t *testing.T) {
+	old := constants.DefaultRuntimeRootDir
+	constants.DefaultRuntimeRootDir = t.TempDir()
+	defer func() { constants.DefaultRuntimeRootDir = old }()
+
+	now := time.Now()
+	writeTestSnapshot(t, "c", now)
+	writeTestSnapshot(t, "a", now.Add(-2*time.Hour))
+	writeTestSnapshot(t, "b", now.Add(-time.Hour))
+
+	snapshots, err := List("default")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(snapshots) != 3 || snapshots[0].Name != "a" || snapshots[2].Name != "c" {
+		t.Fatalf("List() = %+v, want sorted by creation time", snapshots)
+	}
+	if err = snapshots[0].Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	pruned, err := Prune("default", 1)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(pruned) != 2 {
+		t.Errorf("Prune() pruned %d snapshots, want 2", len(pruned))
+	}
+	if _, err = os.Stat(pruned[0].Path()); !os.IsNotExist(err) {
+		t.Errorf("Prune() should remove snapshot file %s", pruned[0].Path())
+	}
+	if _, err = Get("default", "c"); err != nil {
+		t.Errorf("Get() latest snapshot error = %v", err)
+	}
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	old := constants.DefaultRuntimeRootDir
+	constants.DefaultRuntimeRootDir = t.TempDir()
+	defer func() { constants.DefaultRuntimeRootDir = old }()
+
+	s := writeTestSnapshot(t, "a", time.Now())
+	if err := file.WriteFile(s.Path(), []byte("corrupted")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(); err == nil {
+		t.Errorf("Verify() expected checksum error")
+	}
+}
+
+func TestGetInvalidName(t *testing.T) {
+	old := constants.DefaultRuntimeRootDir
+	constants.DefaultRuntimeRootDir = t.TempDir()
+	defer func() { constants.DefaultRuntimeRootDir = old }()
+
+	if _, err := Get("default", "../../default/Clusterfile"); err == nil {
+		t.Errorf("Get() expected invalid name error")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	StepStarted  Type = "StepStarted"
+	StepFinished Type = "StepFinished"
+	BytesCopied  Type = "BytesCopied"
+)
+
+const (
+	SourceProcessor = "processor"
+	SourceRuntime   = "runtime"
+	SourceBootstrap = "bootstrap"
+	SourceRootfs    = "rootfs"
+	SourceHook      = "hook"
+)
+
+// Event is emitted by the long-running operations to track their progress.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// Source is the component which emits the event, one of processor, runtime, bootstrap, rootfs or hook.
+	Source string `json:"source"`
+	// Step is the name of step, e.g. CreateProcessor.Init or join-node.
+	Step string `json:"step"`
+	Host string `json:"host,omitempty"`
+	// Index and Total are the position of step in a pipeline, zero if the step is not a part of pipeline.
+	Index int `json:"index,omitempty"`
+	Total int `json:"total,omitempty"`
+	// Duration is set when the step is finished.
+	Duration time.Duration `json:"duration,omitempty"`
+	Bytes    int64         `json:"bytes,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Sink receives the events, it must be safe for concurrent use.
+type Sink interface {
+	Emit(Event)
+}
+
+var (
+	mu    sync.RWMutex
+	sinks []Sink
+)
+
+// Register adds the sink to receive the events emitted afterwards.
+func Register(sink Sink) {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = append(sinks, sink)
+}
+
+// Unregister removes the sink, the events emitted afterwards are not sent to it.
+func Unregister(sink Sink) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i := range sinks {
+		if sinks[i] == sink {
+			sinks = append(sinks[:i:i], sinks[i+1:]...)
+			return
+		}
+	}
+}
+
+// Reset removes all the registered sinks.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	sinks = nil
+}
+
+// Emit sends the event to all the registered sinks, the time of event is set if it is empty.
+func Emit(e Event) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if len(sinks) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	for _, s := range sinks {
+		s.Emit(e)
+	}
+}
+
+// Step is a step being tracked.
+type Step struct {
+	Event
+	start time.Time
+}
+
+// StartStep emits a StepStarted event, the returned step must be finished by Finish.
+func StartStep(source, step, host string) *Step {
+	return StartPipelineStep(source, step, host, 0, 0)
+}
+
+// StartPipelineStep emits a StepStarted event of the step at index of a pipeline with total steps.
+func StartPipelineStep(source, step, host string, index, total int) *Step {
+	s := &Step{
+		Event: Event{Source: source, Step: step, Host: host, Index: index, Total: total},
+		start: time.Now(),
+	}
+	e := s.Event
+	e.Type = StepStarted
+	Emit(e)
+	return s
+}
+
+// Finish emits a StepFinished event with the duration since the step started.
+func (s *Step) Finish(err error) {
+	e := s.Event
+	e.Type = StepFinished
+	e.Duration = time.Since(s.start)
+	if err != nil {
+		e.Error = err.Error()
+	}
+	Emit(e)
+}
+
+// Copied emits a BytesCopied event of the step.
+func (s *Step) Copied(bytes int64) {
+	e := s.Event
+	e.Type = BytesCopied
+	e.Bytes = bytes
+	Emit(e)
+}
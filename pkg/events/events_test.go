@@ -0,0 +1,85 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJSONLinesSink(t *testing.T) {
+	defer Reset()
+	var buf bytes.Buffer
+	Register(NewJSONLinesSink(&buf))
+
+	step := StartPipelineStep(SourceProcessor, "CreateProcessor.Init", "", 1, 2)
+	step.Finish(nil)
+	step = StartStep(SourceRootfs, "copy labring/kubernetes:v1.25.0", "192.168.0.2:22")
+	step.Copied(1024)
+	step.Finish(errors.New("connection refused"))
+
+	var got []Event
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid json line %s: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+	if len(got) != 5 {
+		t.Fatalf("got %d events, want 5", len(got))
+	}
+	wantTypes := []Type{StepStarted, StepFinished, StepStarted, BytesCopied, StepFinished}
+	for i, e := range got {
+		if e.Type != wantTypes[i] {
+			t.Errorf("event %d type = %s, want %s", i, e.Type, wantTypes[i])
+		}
+		if e.Time.IsZero() {
+			t.Errorf("event %d has no time", i)
+		}
+	}
+	if got[1].Index != 1 || got[1].Total != 2 {
+		t.Errorf("pipeline step = %d/%d, want 1/2", got[1].Index, got[1].Total)
+	}
+	if got[3].Bytes != 1024 || got[3].Host != "192.168.0.2:22" {
+		t.Errorf("bytes copied event = %+v", got[3])
+	}
+	if got[4].Error != "connection refused" {
+		t.Errorf("finished event error = %s", got[4].Error)
+	}
+}
+
+func TestProgressSink(t *testing.T) {
+	defer Reset()
+	var buf bytes.Buffer
+	Register(NewProgressSink(&buf))
+
+	StartPipelineStep(SourceProcessor, "CreateProcessor.Init", "", 1, 2).Finish(nil)
+	step := StartStep(SourceRootfs, "copy rootfs", "192.168.0.2:22")
+	step.Copied(2048)
+	step.Finish(nil)
+
+	out := buf.String()
+	for _, want := range []string{"[1/2] CreateProcessor.Init\n", "[1/2] ✓ CreateProcessor.Init", "✓ copy rootfs on 192.168.0.2:22", "2Ki copied"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("progress output %q does not contain %q", out, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+type jsonLinesSink struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLinesSink returns a sink which writes every event to w as a line of json.
+func NewJSONLinesSink(w io.Writer) Sink {
+	return &jsonLinesSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonLinesSink) Emit(e Event) {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.enc.Encode(e); err != nil {
+		logger.Debug("failed to write event: %v", err)
+	}
+}
+
+type progressSink struct {
+	sync.Mutex
+	w io.Writer
+	// copied are the bytes copied to each host by the running steps
+	copied map[string]int64
+}
+
+// NewProgressSink returns a sink which prints the progress of steps to the terminal.
+func NewProgressSink(w io.Writer) Sink {
+	return &progressSink{w: w, copied: make(map[string]int64)}
+}
+
+func (s *progressSink) Emit(e Event) {
+	s.Lock()
+	defer s.Unlock()
+	key := e.Source + "/" + e.Step + "/" + e.Host
+	switch e.Type {
+	case StepStarted:
+		if e.Total > 0 {
+			fmt.Fprintf(s.w, "[%d/%d] %s\n", e.Index, e.Total, e.Step)
+		}
+	case BytesCopied:
+		s.copied[key] += e.Bytes
+	case StepFinished:
+		prefix := "  "
+		if e.Total > 0 {
+			prefix = fmt.Sprintf("[%d/%d] ", e.Index, e.Total)
+		}
+		target := e.Step
+		if e.Host != "" {
+			target = fmt.Sprintf("%s on %s", e.Step, e.Host)
+		}
+		detail := e.Duration.Round(100 * time.Millisecond).String()
+		if copied := s.copied[key]; copied > 0 {
+			detail = fmt.Sprintf("%s, %s copied", detail, resource.NewQuantity(copied, resource.BinarySI).String())
+			delete(s.copied, key)
+		}
+		if e.Error != "" {
+			fmt.Fprintf(s.w, "%s✗ %s failed (%s): %s\n", prefix, target, detail, e.Error)
+			return
+		}
+		fmt.Fprintf(s.w, "%s✓ %s (%s)\n", prefix, target, detail)
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/system"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 )
 
@@ -45,16 +46,17 @@ func (s *scp) MirrorTo(ctx context.Context, hosts ...string) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	eg, _ := errgroup.WithContext(ctx)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(system.GetParallelism())
 	for i := range s.mounts {
 		m := s.mounts[i]
 		for j := range hosts {
 			host := hosts[j]
 			eg.Go(func() error {
-				if err := ssh.CopyDir(s.ssh, host, m.MountPoint, s.root, constants.IsRegistryDir); err != nil {
+				if err := ssh.CopyDir(ctx, s.ssh, host, m.MountPoint, s.root, constants.IsRegistryDir); err != nil {
 					return err
 				}
-				return s.ssh.CmdAsync(host, fmt.Sprintf(defaultUntarRegistry, s.root, constants.ScriptsDirName))
+				return s.ssh.CmdAsync(ctx, host, fmt.Sprintf(defaultUntarRegistry, s.root, constants.ScriptsDirName))
 			})
 		}
 	}
@@ -16,11 +16,15 @@ limitations under the License.
 
 package rootfs
 
-import v2 "github.com/labring/sealos/pkg/types/v1beta1"
+import (
+	"context"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
 
 type Interface interface {
-	// MountRootfs :send cloud rootfs to all hosts.
-	MountRootfs(cluster *v2.Cluster, hosts []string) error
+	// MountRootfs :send cloud rootfs to all hosts, the copies are interrupted once ctx is done.
+	MountRootfs(ctx context.Context, cluster *v2.Cluster, hosts []string) error
 	// UnMountRootfs :umount rootfs on all hosts.
-	UnMountRootfs(cluster *v2.Cluster, hosts []string) error
+	UnMountRootfs(ctx context.Context, cluster *v2.Cluster, hosts []string) error
 }
@@ -20,13 +20,17 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"os"
 	"path"
+	"path/filepath"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/labring/sealos/pkg/constants"
 	"github.com/labring/sealos/pkg/env"
+	"github.com/labring/sealos/pkg/events"
 	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/system"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/exec"
 	"github.com/labring/sealos/pkg/utils/file"
@@ -40,12 +44,12 @@ type defaultRootfs struct {
 	mounts []v2.MountImage
 }
 
-func (f *defaultRootfs) MountRootfs(cluster *v2.Cluster, hosts []string) error {
-	return f.mountRootfs(cluster, hosts)
+func (f *defaultRootfs) MountRootfs(ctx context.Context, cluster *v2.Cluster, hosts []string) error {
+	return f.mountRootfs(ctx, cluster, hosts)
 }
 
-func (f *defaultRootfs) UnMountRootfs(cluster *v2.Cluster, hosts []string) error {
-	return f.unmountRootfs(cluster, hosts)
+func (f *defaultRootfs) UnMountRootfs(ctx context.Context, cluster *v2.Cluster, hosts []string) error {
+	return f.unmountRootfs(ctx, cluster, hosts)
 }
 
 func (f *defaultRootfs) getClusterName(cluster *v2.Cluster) string {
@@ -53,16 +57,19 @@ func (f *defaultRootfs) getClusterName(cluster *v2.Cluster) string {
 }
 
 func (f *defaultRootfs) getSSH(cluster *v2.Cluster) ssh.Interface {
-	return ssh.NewSSHClient(&cluster.Spec.SSH, true)
+	return ssh.NewSSHClientWithCluster(cluster, true)
 }
 
-func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error {
+func (f *defaultRootfs) mountRootfs(ctx context.Context, cluster *v2.Cluster, ipList []string) error {
 	target := constants.NewData(f.getClusterName(cluster)).RootFSPath()
-	ctx := context.Background()
 	eg, _ := errgroup.WithContext(ctx)
 	envProcessor := env.NewEnvProcessor(cluster, f.mounts)
-	for _, mount := range f.mounts {
-		src := mount
+	notRegistryDirFilter := func(entry fs.DirEntry) bool { return !constants.IsRegistryDir(entry) }
+	// sizes of the mounts are computed once after rendering, and reported
+	// as the bytes copied to every host.
+	sizes := make([]int64, len(f.mounts))
+	for idx := range f.mounts {
+		i, src := idx, f.mounts[idx]
 		eg.Go(func() error {
 			if !file.IsExist(src.MountPoint) {
 				logger.Debug("Image %s not exist, render env continue", src.ImageName)
@@ -84,6 +91,7 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 					return fmt.Errorf("run chmod to rootfs failed: %w", err)
 				}
 			}
+			sizes[i] = dirSize(src.MountPoint, notRegistryDirFilter)
 			return nil
 		})
 	}
@@ -92,27 +100,29 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 	}
 
 	sshClient := f.getSSH(cluster)
-	notRegistryDirFilter := func(entry fs.DirEntry) bool { return !constants.IsRegistryDir(entry) }
 
+	// the mounts of a host are copied one after another, so that at most parallelism copies
+	// are running at the same time
+	eg.SetLimit(system.GetParallelism())
 	for idx := range ipList {
 		ip := ipList[idx]
 		eg.Go(func() error {
-			egg, _ := errgroup.WithContext(ctx)
-			for idj := range f.mounts {
-				mount := f.mounts[idj]
-				egg.Go(func() error {
-					switch mount.Type {
-					case v2.RootfsImage, v2.PatchImage:
-						logger.Debug("send mount image, ip: %s, image name: %s, image type: %s", ip, mount.ImageName, mount.Type)
-						err := ssh.CopyDir(sshClient, ip, mount.MountPoint, target, notRegistryDirFilter)
-						if err != nil {
-							return fmt.Errorf("failed to copy %s %s: %v", mount.Type, mount.Name, err)
-						}
+			for idj, mount := range f.mounts {
+				switch mount.Type {
+				case v2.RootfsImage, v2.PatchImage:
+					logger.Debug("send mount image, ip: %s, image name: %s, image type: %s", ip, mount.ImageName, mount.Type)
+					step := events.StartStep(events.SourceRootfs, "copy "+mount.ImageName, ip)
+					err := ssh.CopyDir(ctx, sshClient, ip, mount.MountPoint, target, notRegistryDirFilter)
+					if err == nil {
+						step.Copied(sizes[idj])
+					}
+					step.Finish(err)
+					if err != nil {
+						return fmt.Errorf("failed to copy %s %s: %v", mount.Type, mount.Name, err)
 					}
-					return nil
-				})
+				}
 			}
-			return egg.Wait()
+			return nil
 		})
 	}
 	err := eg.Wait()
@@ -123,11 +133,16 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 	endEg, _ := errgroup.WithContext(ctx)
 	master0 := cluster.GetMaster0IPAndPort()
 	for idx := range f.mounts {
-		mountInfo := f.mounts[idx]
+		mountInfo, size := f.mounts[idx], sizes[idx]
 		endEg.Go(func() error {
 			if mountInfo.Type == v2.AppImage {
 				logger.Debug("send app mount images, ip: %s, image name: %s, image type: %s", master0, mountInfo.ImageName, mountInfo.Type)
-				err = ssh.CopyDir(sshClient, master0, mountInfo.MountPoint, constants.GetAppWorkDir(cluster.Name, mountInfo.Name), notRegistryDirFilter)
+				step := events.StartStep(events.SourceRootfs, "copy "+mountInfo.ImageName, master0)
+				err := ssh.CopyDir(ctx, sshClient, master0, mountInfo.MountPoint, constants.GetAppWorkDir(cluster.Name, mountInfo.Name), notRegistryDirFilter)
+				if err == nil {
+					step.Copied(size)
+				}
+				step.Finish(err)
 				if err != nil {
 					return fmt.Errorf("failed to copy %s %s: %v", mountInfo.Type, mountInfo.Name, err)
 				}
@@ -138,21 +153,38 @@ func (f *defaultRootfs) mountRootfs(cluster *v2.Cluster, ipList []string) error
 	return endEg.Wait()
 }
 
-func (f *defaultRootfs) unmountRootfs(cluster *v2.Cluster, ipList []string) error {
+func (f *defaultRootfs) unmountRootfs(ctx context.Context, cluster *v2.Cluster, ipList []string) error {
 	clusterRootfsDir := constants.NewData(f.getClusterName(cluster)).Homedir()
 	rmRootfs := fmt.Sprintf("rm -rf %s", clusterRootfsDir)
 	deleteHomeDirCmd := fmt.Sprintf("rm -rf %s", constants.ClusterDir(cluster.Name))
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(system.GetParallelism())
 	for _, IP := range ipList {
 		ip := IP
 		eg.Go(func() error {
 			SSH := f.getSSH(cluster)
-			return SSH.CmdAsync(ip, rmRootfs, deleteHomeDirCmd)
+			return SSH.CmdAsync(ctx, ip, rmRootfs, deleteHomeDirCmd)
 		})
 	}
 	return eg.Wait()
 }
 
+// dirSize returns the total size of the entries of dir which pass the filter, it is only used to report progress.
+func dirSize(dir string, filter func(fs.DirEntry) bool) int64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var paths []string
+	for _, entry := range entries {
+		if filter == nil || filter(entry) {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	size, _ := file.GetFilesSize(paths)
+	return size
+}
+
 func renderTemplatesWithEnv(mountDir string, ipList []string, p env.Interface) error {
 	var (
 		renderEtc       = path.Join(mountDir, constants.EtcDirName)
@@ -15,6 +15,7 @@
 package guest
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -31,8 +32,8 @@ import (
 )
 
 type Interface interface {
-	Apply(cluster *v2.Cluster, mounts []v2.MountImage) error
-	Delete(cluster *v2.Cluster) error
+	Apply(ctx context.Context, cluster *v2.Cluster, mounts []v2.MountImage) error
+	Delete(ctx context.Context, cluster *v2.Cluster, mounts []v2.MountImage) error
 }
 
 type Default struct {
@@ -42,11 +43,14 @@ func NewGuestManager() (Interface, error) {
 	return &Default{}, nil
 }
 
-func (d *Default) Apply(cluster *v2.Cluster, mounts []v2.MountImage) error {
+func (d *Default) Apply(ctx context.Context, cluster *v2.Cluster, mounts []v2.MountImage) error {
 	envInterface := env.NewEnvProcessor(cluster, cluster.Status.Mounts)
 	envs := envInterface.WrapperEnv(cluster.GetMaster0IP()) //clusterfile
 	guestCMD := d.getGuestCmd(envs, cluster, mounts)
+	return d.runCmd(ctx, cluster, guestCMD)
+}
 
+func (d *Default) runCmd(ctx context.Context, cluster *v2.Cluster, cmds []string) error {
 	kubeConfig := filepath.Join(constants.GetHomeDir(), ".kube", "config")
 	if !fileutil.IsExist(kubeConfig) {
 		adminFile := runtime.GetConstantData(cluster.Name).AdminFile()
@@ -63,12 +67,12 @@ func (d *Default) Apply(cluster *v2.Cluster, mounts []v2.MountImage) error {
 			_ = fileutil.CleanFiles(kubeConfig)
 		}()
 	}
-	sshInterface := ssh.NewSSHClient(&cluster.Spec.SSH, true)
+	sshInterface := ssh.NewSSHClientWithCluster(cluster, true)
 	logger.Debug("start to exec guest commands")
-	if err := sshInterface.CmdAsync(cluster.GetMaster0IPAndPort(), guestCMD...); err != nil {
+	if err := sshInterface.CmdAsync(ctx, cluster.GetMaster0IPAndPort(), cmds...); err != nil {
 		return err
 	}
-	logger.Debug("finish to exec guest commands: %v", guestCMD)
+	logger.Debug("finish to exec guest commands: %v", cmds)
 	return nil
 }
 
@@ -107,6 +111,31 @@ func (d *Default) getGuestCmd(envs map[string]string, cluster *v2.Cluster, mount
 	return command
 }
 
-func (d Default) Delete(_ *v2.Cluster) error {
-	panic("not yet implemented")
+// Delete runs the uninstall commands declared by the label v2.ImageUninstallKey of the app images,
+// the images without the label are skipped.
+func (d *Default) Delete(ctx context.Context, cluster *v2.Cluster, mounts []v2.MountImage) error {
+	envInterface := env.NewEnvProcessor(cluster, cluster.Status.Mounts)
+	envs := envInterface.WrapperEnv(cluster.GetMaster0IP())
+	uninstallCMD := d.getUninstallCmd(envs, cluster, mounts)
+	if len(uninstallCMD) == 0 {
+		return nil
+	}
+	return d.runCmd(ctx, cluster, uninstallCMD)
+}
+
+func (d *Default) getUninstallCmd(envs map[string]string, cluster *v2.Cluster, mounts []v2.MountImage) []string {
+	command := make([]string, 0)
+	for _, i := range mounts {
+		if i.Type != v2.AppImage {
+			continue
+		}
+		cmd := i.Labels[v2.ImageUninstallKey]
+		if cmd == "" {
+			logger.Warn("app %s has no uninstall command declared by label %s, skip running it", i.ImageName, v2.ImageUninstallKey)
+			continue
+		}
+		mapping := expansion.MappingFuncFor(maps.MergeMap(i.Env, envs))
+		command = append(command, fmt.Sprintf(constants.CdAndExecCmd, constants.GetAppWorkDir(cluster.Name, i.Name), expansion.Expand(cmd, mapping)))
+	}
+	return command
 }
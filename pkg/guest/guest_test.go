@@ -134,3 +134,31 @@ func TestDefault_getGuestCmd(t *testing.T) {
 		})
 	}
 }
+
+func TestDefault_getUninstallCmd(t *testing.T) {
+	cluster := &v2.Cluster{}
+	cluster.Name = "default"
+	mounts := []v2.MountImage{
+		{
+			Name:   "helm",
+			Type:   v2.AppImage,
+			Env:    map[string]string{"NAMESPACE": "kube-system"},
+			Labels: map[string]string{v2.ImageUninstallKey: "helm uninstall app -n $(NAMESPACE)"},
+		},
+		{
+			Name:      "calico",
+			Type:      v2.AppImage,
+			ImageName: "labring/calico:v3.24.1",
+		},
+		{
+			Name:   "rootfs",
+			Type:   v2.RootfsImage,
+			Labels: map[string]string{v2.ImageUninstallKey: "kubeadm reset"},
+		},
+	}
+	want := []string{fmt.Sprintf(constants.CdAndExecCmd, constants.GetAppWorkDir("default", "helm"), "helm uninstall app -n kube-system")}
+	d := &Default{}
+	if got := d.getUninstallCmd(map[string]string{}, cluster, mounts); !reflect.DeepEqual(got, want) {
+		t.Errorf("getUninstallCmd() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/system"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	strutil "github.com/labring/sealos/pkg/utils/strings"
+)
+
+// DefaultTimeout is the timeout of a hook on each host if not set.
+const DefaultTimeout = 5 * time.Minute
+
+const (
+	envHookName    = "SEALOS_HOOK_NAME"
+	envHookPhase   = "SEALOS_HOOK_PHASE"
+	envHookHost    = "SEALOS_HOOK_HOST"
+	envClusterName = "SEALOS_CLUSTER_NAME"
+
+	remoteScriptDir = "/tmp"
+)
+
+var phases = []v2.HookPhase{v2.HookPreBootstrap, v2.HookPostBootstrap, v2.HookPostInit, v2.HookPostJoin, v2.HookPostGuest}
+
+// Validate checks the hooks declared in the Clusterfile.
+func Validate(hooks []v2.Hook) error {
+	names := make(map[string]struct{}, len(hooks))
+	for _, h := range hooks {
+		if h.Name == "" {
+			return fmt.Errorf("name of hook is required")
+		}
+		if _, ok := names[h.Name]; ok {
+			return fmt.Errorf("hook %s is declared more than once", h.Name)
+		}
+		names[h.Name] = struct{}{}
+		if !isValidPhase(h.Phase) {
+			return fmt.Errorf("unknown phase %s of hook %s, must be one of %v", h.Phase, h.Name, phases)
+		}
+		if (h.Command == "") == (h.Script == "") {
+			return fmt.Errorf("exactly one of command and script is required in hook %s", h.Name)
+		}
+		if h.Script != "" && !file.IsExist(h.Script) {
+			return fmt.Errorf("script %s of hook %s not found", h.Script, h.Name)
+		}
+		switch h.OnFailure {
+		case "", v2.HookFail, v2.HookIgnore:
+		default:
+			return fmt.Errorf("unknown onFailure %s of hook %s, must be %s or %s", h.OnFailure, h.Name, v2.HookFail, v2.HookIgnore)
+		}
+		if h.Timeout != nil && h.Timeout.Duration <= 0 {
+			return fmt.Errorf("timeout of hook %s must be positive", h.Name)
+		}
+	}
+	return nil
+}
+
+func isValidPhase(phase v2.HookPhase) bool {
+	for _, p := range phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// Hooks returns the hooks of the phase in the declared order.
+func Hooks(cluster *v2.Cluster, phase v2.HookPhase) []v2.Hook {
+	var hooks []v2.Hook
+	for _, h := range cluster.Spec.Hooks {
+		if h.Phase == phase {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
+
+// Targets returns the hosts which have any of the roles of the hook, all the hosts if no roles are set.
+func Targets(cluster *v2.Cluster, hook v2.Hook, hosts []string) []string {
+	if len(hook.Roles) == 0 {
+		return hosts
+	}
+	var targets []string
+	for _, host := range hosts {
+		for _, role := range rolesOf(cluster, host) {
+			if strutil.In(role, hook.Roles) {
+				targets = append(targets, host)
+				break
+			}
+		}
+	}
+	return targets
+}
+
+func rolesOf(cluster *v2.Cluster, host string) []string {
+	ip := iputils.GetHostIP(host)
+	for _, h := range cluster.Spec.Hosts {
+		for _, addr := range h.IPS {
+			if iputils.GetHostIP(addr) == ip {
+				return h.Roles
+			}
+		}
+	}
+	return nil
+}
+
+// Run runs the hooks of the phase on the hosts. Hooks are run one by one in the declared order,
+// and each of them runs on its target hosts in parallel.
+func Run(ctx context.Context, cluster *v2.Cluster, execer ssh.Interface, phase v2.HookPhase, hosts ...string) error {
+	return run(ctx, cluster, execer, phase, func(h v2.Hook) []string {
+		return Targets(cluster, h, hosts)
+	})
+}
+
+// RunOnCluster runs the hooks of the phase which are not bound to any hosts, such as the hooks run after
+// init or guest. A hook runs on master0 if no roles are set, otherwise on all the hosts with the roles.
+func RunOnCluster(ctx context.Context, cluster *v2.Cluster, execer ssh.Interface, phase v2.HookPhase) error {
+	return run(ctx, cluster, execer, phase, func(h v2.Hook) []string {
+		if len(h.Roles) == 0 {
+			return []string{cluster.GetMaster0IPAndPort()}
+		}
+		return Targets(cluster, h, append(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...), cluster.GetEtcdIPAndPortList()...))
+	})
+}
+
+func run(ctx context.Context, cluster *v2.Cluster, execer ssh.Interface, phase v2.HookPhase, targetsOf func(v2.Hook) []string) error {
+	for _, h := range Hooks(cluster, phase) {
+		targets := targetsOf(h)
+		if len(targets) == 0 {
+			continue
+		}
+		logger.Info("Executing hook %s of phase %s on %v", h.Name, phase, targets)
+		if err := runHook(ctx, cluster, execer, h, targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, cluster *v2.Cluster, execer ssh.Interface, hook v2.Hook, hosts []string) error {
+	eg, _ := errgroup.WithContext(ctx)
+	eg.SetLimit(system.GetParallelism())
+	for i := range hosts {
+		host := hosts[i]
+		eg.Go(func() error {
+			step := events.StartStep(events.SourceHook, hook.Name, host)
+			err := runOnHost(ctx, cluster, execer, hook, host)
+			step.Finish(err)
+			if err == nil {
+				return nil
+			}
+			if hook.OnFailure == v2.HookIgnore {
+				logger.Warn("ignore the failure of hook %s on host %s: %v", hook.Name, host, err)
+				return nil
+			}
+			return fmt.Errorf("failed to run hook %s on host %s: %v", hook.Name, host, err)
+		})
+	}
+	return eg.Wait()
+}
+
+func runOnHost(ctx context.Context, cluster *v2.Cluster, execer ssh.Interface, hook v2.Hook, host string) error {
+	timeout := DefaultTimeout
+	if hook.Timeout != nil {
+		timeout = hook.Timeout.Duration
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	envs := map[string]string{
+		envHookName:    hook.Name,
+		envHookPhase:   string(hook.Phase),
+		envHookHost:    iputils.GetHostIP(host),
+		envClusterName: cluster.Name,
+	}
+	var err error
+	if hook.Local {
+		err = runLocal(ctx, hook, envs)
+	} else {
+		err = runRemote(ctx, execer, hook, host, envs)
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s: %v", timeout, err)
+	}
+	return err
+}
+
+func runLocal(ctx context.Context, hook v2.Hook, envs map[string]string) error {
+	args := []string{"-c", hook.Command}
+	if hook.Script != "" {
+		args = []string{hook.Script}
+	}
+	// nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
+	cmd := exec.CommandContext(ctx, "bash", args...) // #nosec
+	cmd.Env = os.Environ()
+	for k, v := range envs {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func runRemote(ctx context.Context, execer ssh.Interface, hook v2.Hook, host string, envs map[string]string) error {
+	if hook.Command != "" {
+		return execer.CmdAsync(ctx, host, strutil.RenderShellFromEnv(hook.Command, envs))
+	}
+	dst := path.Join(remoteScriptDir, fmt.Sprintf("sealos-hook-%s-%s", hook.Name, path.Base(hook.Script)))
+	if err := execer.Copy(ctx, host, hook.Script, dst); err != nil {
+		return fmt.Errorf("failed to copy script %s: %v", hook.Script, err)
+	}
+	defer func() {
+		if err := execer.CmdAsync(context.Background(), host, fmt.Sprintf("rm -f %s", dst)); err != nil {
+			logger.Warn("failed to remove script %s on host %s: %v", dst, host, err)
+		}
+	}()
+	return execer.CmdAsync(ctx, host, strutil.RenderShellFromEnv(fmt.Sprintf("bash %s", dst), envs))
+}
@@ -0,0 +1,122 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hook
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func TestTargets(t *testing.T) {
+	cluster := &v2.Cluster{
+		Spec: v2.ClusterSpec{
+			Hosts: []v2.Host{
+				{IPS: []string{"192.168.0.2:22"}, Roles: []string{v2.MASTER}},
+				{IPS: []string{"192.168.0.3", "192.168.0.4:2222"}, Roles: []string{v2.NODE, "storage"}},
+			},
+		},
+	}
+	hosts := []string{"192.168.0.2:22", "192.168.0.3:22", "192.168.0.4:2222"}
+	tests := []struct {
+		name  string
+		roles []string
+		want  []string
+	}{
+		{
+			name: "all hosts",
+			want: hosts,
+		},
+		{
+			name:  "master",
+			roles: []string{v2.MASTER},
+			want:  []string{"192.168.0.2:22"},
+		},
+		{
+			name:  "custom role",
+			roles: []string{"storage"},
+			want:  []string{"192.168.0.3:22", "192.168.0.4:2222"},
+		},
+		{
+			name:  "no matched role",
+			roles: []string{"gpu"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Targets(cluster, v2.Hook{Roles: tt.roles}, hosts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Targets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   []v2.Hook
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			hooks: []v2.Hook{{Name: "notify", Phase: v2.HookPostInit, Command: "echo done", OnFailure: v2.HookIgnore}},
+		},
+		{
+			name:    "unknown phase",
+			hooks:   []v2.Hook{{Name: "notify", Phase: "PostDelete", Command: "echo done"}},
+			wantErr: true,
+		},
+		{
+			name:    "both command and script",
+			hooks:   []v2.Hook{{Name: "notify", Phase: v2.HookPostInit, Command: "echo done", Script: "notify.sh"}},
+			wantErr: true,
+		},
+		{
+			name: "duplicated name",
+			hooks: []v2.Hook{
+				{Name: "notify", Phase: v2.HookPostInit, Command: "echo done"},
+				{Name: "notify", Phase: v2.HookPostJoin, Command: "echo done"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.hooks); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunLocalIgnoreFailure(t *testing.T) {
+	cluster := &v2.Cluster{
+		Spec: v2.ClusterSpec{
+			Hooks: []v2.Hook{
+				{Name: "fail", Phase: v2.HookPostJoin, Command: "exit 1", Local: true, OnFailure: v2.HookIgnore},
+				{Name: "check-env", Phase: v2.HookPostJoin, Command: `test "$SEALOS_HOOK_HOST" = 192.168.0.2`, Local: true},
+			},
+		},
+	}
+	if err := Run(context.Background(), cluster, nil, v2.HookPostJoin, "192.168.0.2:22"); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	cluster.Spec.Hooks[0].OnFailure = v2.HookFail
+	if err := Run(context.Background(), cluster, nil, v2.HookPostJoin, "192.168.0.2:22"); err == nil {
+		t.Errorf("Run() expected error of hook fail")
+	}
+}
@@ -19,6 +19,7 @@ package remote
 // nosemgrep: go.lang.security.audit.xss.import-text-template.import-text-template
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"text/template"
 
@@ -39,7 +40,7 @@ func renderTemplate(tmpl *template.Template, data map[string]interface{}) (strin
 func bashToString(clusterName string, sshInterface ssh.Interface, host, cmd string) (string, error) {
 	data := constants.NewData(clusterName)
 	cmd = fmt.Sprintf("%s %s", data.RootFSSealctlPath(), cmd)
-	str, err := sshInterface.CmdToString(host, cmd, "")
+	str, err := sshInterface.CmdToString(context.Background(), host, cmd, "")
 	if err != nil {
 		logger.Debug("failed to exec remote %s shell: %s output: %s error: %+v", host, cmd, data, err)
 	}
@@ -48,5 +49,5 @@ func bashToString(clusterName string, sshInterface ssh.Interface, host, cmd stri
 func bashCTLSync(clusterName string, sshInterface ssh.Interface, host, cmd string) error {
 	data := constants.NewData(clusterName)
 	cmd = fmt.Sprintf("%s  %s", data.RootFSSealctlPath(), cmd)
-	return sshInterface.CmdAsync(host, cmd)
+	return sshInterface.CmdAsync(context.Background(), host, cmd)
 }
@@ -0,0 +1,306 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/system"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	kubeletClientCertFile = "/var/lib/kubelet/pki/kubelet-client-current.pem"
+	etcdComponent         = "etcd"
+
+	backupCertsCmd       = "mkdir -p %[1]s && cp -a %[2]s/pki %[1]s/ && (cp -a %[2]s/*.conf %[1]s/ 2>/dev/null || true)"
+	kubeConfigCertPrefix = "client cert of "
+)
+
+var (
+	// the certs relative to /etc/kubernetes, which are renewed as `kubeadm certs renew all` does
+	kubeadmManagedCerts = []string{
+		"pki/apiserver.crt",
+		"pki/apiserver-kubelet-client.crt",
+		"pki/apiserver-etcd-client.crt",
+		"pki/front-proxy-client.crt",
+	}
+	etcdManagedCerts = []string{
+		"pki/etcd/server.crt",
+		"pki/etcd/peer.crt",
+		"pki/etcd/healthcheck-client.crt",
+	}
+	kubeadmManagedKubeConfigs = []string{AdminConf, ControllerConf, SchedulerConf}
+	caCerts                   = []string{"pki/ca.crt", "pki/front-proxy-ca.crt", "pki/etcd/ca.crt"}
+)
+
+// CertExpiration is the expiration of a cert on a host.
+type CertExpiration struct {
+	Host string `json:"host"`
+	cert.Expiration
+}
+
+// CheckCerts returns the expiration of the certs and the client certs of kubeconfig on the masters and etcd hosts,
+// and the kubelet client certs on all the hosts.
+func (k *KubeadmRuntime) CheckCerts() ([]CertExpiration, error) {
+	cas, err := cert.LoadCAs(k.getContentData().PkiPath(), k.getContentData().PkiEtcdPath())
+	if err != nil {
+		return nil, err
+	}
+	hosts := append(append(k.getMasterIPAndPortList(), k.getNodeIPAndPortList()...), k.Cluster.GetEtcdIPAndPortList()...)
+	results := make([][]CertExpiration, len(hosts))
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for i := range hosts {
+		i, host := i, hosts[i]
+		eg.Go(func() error {
+			expirations, err := k.checkHostCerts(host, cas)
+			if err != nil {
+				return fmt.Errorf("failed to check certs of %s: %v", host, err)
+			}
+			results[i] = expirations
+			return nil
+		})
+	}
+	if err = eg.Wait(); err != nil {
+		return nil, err
+	}
+	var expirations []CertExpiration
+	for _, r := range results {
+		expirations = append(expirations, r...)
+	}
+	return expirations, nil
+}
+
+func (k *KubeadmRuntime) checkHostCerts(host string, cas []cert.CA) ([]CertExpiration, error) {
+	var files []string
+	switch ip := iputils.GetHostIP(host); {
+	case k.isMaster(ip):
+		files = append(append(append(append([]string{}, caCerts...), kubeadmManagedCerts...), etcdManagedCerts...), kubeadmManagedKubeConfigs...)
+	case k.isEtcdHost(ip):
+		files = append([]string{"pki/etcd/ca.crt"}, etcdManagedCerts...)
+	}
+	var expirations []CertExpiration
+	for _, name := range files {
+		data, err := k.fetchRemoteFile(host, path.Join(constants.KubernetesEtc, name))
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+		if strings.HasSuffix(name, ".conf") {
+			if data, err = cert.KubeConfigClientCert(data); err != nil {
+				return nil, fmt.Errorf("failed to load kubeconfig %s: %v", name, err)
+			}
+			if data == nil {
+				continue
+			}
+			name = kubeConfigCertPrefix + name
+		}
+		e, err := cert.ParseExpiration(strings.TrimPrefix(name, "pki/"), data, cas)
+		if err != nil {
+			return nil, err
+		}
+		expirations = append(expirations, CertExpiration{Host: host, Expiration: *e})
+	}
+	if !k.isEtcdHost(iputils.GetHostIP(host)) {
+		data, err := k.fetchRemoteFile(host, kubeletClientCertFile)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			e, err := cert.ParseExpiration("kubelet-client", data, nil)
+			if err != nil {
+				return nil, err
+			}
+			// the kubelet client cert is rotated by kubelet itself
+			expirations = append(expirations, CertExpiration{Host: host, Expiration: *e})
+		}
+	}
+	return expirations, nil
+}
+
+func (k *KubeadmRuntime) isMaster(ip string) bool {
+	for _, master := range k.getMasterIPList() {
+		if master == ip {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *KubeadmRuntime) isEtcdHost(ip string) bool {
+	for _, host := range k.Cluster.GetEtcdIPList() {
+		if iputils.GetHostIP(host) == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchRemoteFile returns the content of the remote file, nil if it doesn't exist.
+func (k *KubeadmRuntime) fetchRemoteFile(host, remotePath string) ([]byte, error) {
+	exist, err := k.isRemoteFileExist(host, remotePath)
+	if err != nil || !exist {
+		return nil, err
+	}
+	localPath := path.Join(k.getContentData().TmpPath(), "fetch", iputils.GetHostIP(host), remotePath)
+	if err = os.RemoveAll(localPath); err != nil {
+		return nil, err
+	}
+	if err = file.MkDirs(path.Dir(localPath)); err != nil {
+		return nil, err
+	}
+	if err = k.getSSHInterface().CopyR(k.ctx, host, localPath, remotePath); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", remotePath, err)
+	}
+	return os.ReadFile(localPath)
+}
+
+// RenewCerts renews the certs managed by kubeadm with the cas of the cluster, the etcd hosts and the masters are
+// renewed one by one and their static pods are restarted in order. The kubeconfig of admin is sent to the hosts at last.
+func (k *KubeadmRuntime) RenewCerts() error {
+	cas, err := cert.LoadCAs(k.getContentData().PkiPath(), k.getContentData().PkiEtcdPath())
+	if err != nil {
+		return err
+	}
+	for _, host := range k.Cluster.GetEtcdIPAndPortList() {
+		if err = k.renewHostCerts(host, cas, etcdManagedCerts, []string{etcdComponent}); err != nil {
+			return err
+		}
+	}
+	masterCerts := append(append([]string{}, kubeadmManagedCerts...), kubeadmManagedKubeConfigs...)
+	components := kubernetes.ControlPlaneComponents
+	if !k.Cluster.IsExternalEtcd() {
+		masterCerts = append(masterCerts, etcdManagedCerts...)
+		components = append([]string{etcdComponent}, components...)
+	}
+	for _, master := range k.getMasterIPAndPortList() {
+		if err = k.renewHostCerts(master, cas, masterCerts, components); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("start to update the kubeconfig of admin")
+	for _, name := range kubeadmManagedKubeConfigs {
+		if err = renewLocalKubeConfig(path.Join(k.getContentData().EtcPath(), name), cas); err != nil {
+			return err
+		}
+	}
+	for _, master := range k.getMasterIPAndPortList() {
+		if err = k.copyMasterKubeConfig(master); err != nil {
+			return err
+		}
+	}
+	return k.copyNodeKubeConfig(k.getNodeIPAndPortList())
+}
+
+func renewLocalKubeConfig(filename string, cas []cert.CA) error {
+	if !file.IsExist(filename) {
+		return nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if data, err = cert.RenewKubeConfig(data, cas); err != nil {
+		return fmt.Errorf("failed to renew %s: %v", filename, err)
+	}
+	return file.WriteFile(filename, data)
+}
+
+func (k *KubeadmRuntime) renewHostCerts(host string, cas []cert.CA, files, components []string) error {
+	step := events.StartStep(events.SourceRuntime, "RenewCerts", host)
+	err := k.renewCerts(host, cas, files, components)
+	step.Finish(err)
+	if err != nil {
+		return fmt.Errorf("failed to renew certs of %s, the hosts after it are untouched: %v", host, err)
+	}
+	logger.Info("succeeded in renewing certs of %s", host)
+	return nil
+}
+
+func (k *KubeadmRuntime) renewCerts(host string, cas []cert.CA, files, components []string) error {
+	logger.Info("start to renew certs of %s", host)
+	renewed := make(map[string][]byte)
+	for _, name := range files {
+		remotePath := path.Join(constants.KubernetesEtc, name)
+		data, err := k.fetchRemoteFile(host, remotePath)
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			logger.Debug("%s is not found on %s, skip renewing", remotePath, host)
+			continue
+		}
+		if strings.HasSuffix(name, ".conf") {
+			data, err = cert.RenewKubeConfig(data, cas)
+		} else {
+			data, err = cert.RenewCertPEM(data, cas)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to renew %s: %v", name, err)
+		}
+		renewed[remotePath] = data
+	}
+
+	backupDir := path.Join(k.getContentData().BackupPath(), fmt.Sprintf("certs-%d", time.Now().Unix()))
+	if err := k.sshCmdAsync(host, fmt.Sprintf(backupCertsCmd, backupDir, constants.KubernetesEtc)); err != nil {
+		return fmt.Errorf("failed to backup certs: %v", err)
+	}
+	logger.Info("the certs of %s are backed up in %s", host, backupDir)
+	for remotePath, data := range renewed {
+		localPath := path.Join(k.getContentData().TmpPath(), "renew", iputils.GetHostIP(host), remotePath)
+		if err := file.WriteFile(localPath, data); err != nil {
+			return err
+		}
+		if err := k.sshCopy(host, localPath, remotePath); err != nil {
+			return fmt.Errorf("failed to copy %s: %v", remotePath, err)
+		}
+	}
+
+	// the static pods load the certs only when starting
+	for _, component := range components {
+		id, err := k.sshCmdToString(host, fmt.Sprintf(runningContainerIDCmd, component))
+		if err != nil {
+			return err
+		}
+		if id = strings.TrimSpace(id); id == "" {
+			logger.Warn("%s is not running on %s, skip restarting", component, host)
+			continue
+		}
+		logger.Info("restart %s on %s", component, host)
+		if err = k.sshCmdAsync(host, fmt.Sprintf(stopContainerCmd, id)); err != nil {
+			return fmt.Errorf("failed to restart %s: %v", component, err)
+		}
+		if err = k.waitControlPlaneRestarted(host, map[string]string{component: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
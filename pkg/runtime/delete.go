@@ -0,0 +1,112 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/drain"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+var (
+	// DrainTimeout is the timeout of draining a node before it is deleted, zero means no timeout.
+	DrainTimeout = 5 * time.Minute
+	// IgnoreDrainErrors goes on deleting a node even if it fails to be drained.
+	IgnoreDrainErrors bool
+	// DeleteEmptyDirData deletes the pods using emptyDir volumes when draining a node before it is
+	// deleted or rebooted, draining fails on such pods if it is not set.
+	DeleteEmptyDirData bool
+)
+
+// getNodeNameByIP returns the name of the node whose internal ip is the host, empty if it is not registered.
+func getNodeNameByIP(ctx context.Context, cli kubernetes.Client, host string) (string, error) {
+	nodes, err := cli.Kubernetes().CoreV1().Nodes().List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("get node list failed: %v", err)
+	}
+	ip := iputils.GetHostIP(host)
+	for _, node := range nodes.Items {
+		for _, address := range node.Status.Addresses {
+			if address.Type == v1.NodeInternalIP && address.Address == ip {
+				return node.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// drainNodeBeforeDelete cordons the node and evicts its pods respecting PodDisruptionBudgets,
+// it gives up when DrainTimeout is reached.
+func (k *KubeadmRuntime) drainNodeBeforeDelete(host string) error {
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	nodeName, err := getNodeNameByIP(k.ctx, cli, host)
+	if err != nil {
+		return err
+	}
+	if nodeName == "" {
+		logger.Warn("node %s is not found in kubernetes, skip draining", host)
+		return nil
+	}
+	node, err := cli.Kubernetes().CoreV1().Nodes().Get(k.ctx, nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	helper := newDrainHelper(k.ctx, cli, DrainTimeout, false, DeleteEmptyDirData)
+	if err = drain.RunCordonOrUncordon(helper, node, true); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %v", nodeName, err)
+	}
+	logger.Info("start to drain node %s", nodeName)
+	if err = drain.RunNodeDrain(helper, nodeName); err != nil {
+		return fmt.Errorf("failed to drain node %s: %v", nodeName, err)
+	}
+	return nil
+}
+
+// checkEtcdMemberRemovable returns the etcd member on the master and makes sure that
+// etcd keeps the quorum after it is removed, the member is nil if the master is not an etcd member.
+func (k *KubeadmRuntime) checkEtcdMemberRemovable(executor, master string) (*etcdMember, error) {
+	members, err := k.listEtcdMembers(executor)
+	if err != nil {
+		return nil, err
+	}
+	member := findEtcdMember(members, master)
+	if member == nil {
+		return nil, nil
+	}
+	health, err := k.getEtcdEndpointsHealth(executor)
+	if err != nil {
+		return nil, err
+	}
+	return member, checkEtcdQuorum(members, health, *member)
+}
+
+func (k *KubeadmRuntime) removeEtcdMember(executor string, member *etcdMember) error {
+	logger.Info("start to remove etcd member %s(%x)", member.Name, member.ID)
+	if err := k.sshCmdAsync(executor, etcdctl(fmt.Sprintf("member remove %x", member.ID))); err != nil {
+		return fmt.Errorf("failed to remove etcd member %s: %v", member.Name, err)
+	}
+	return nil
+}
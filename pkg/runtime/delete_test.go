@@ -0,0 +1,73 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTestEtcdMembers(n int) []etcdMember {
+	var members []etcdMember
+	for i := 1; i <= n; i++ {
+		members = append(members, etcdMember{
+			ID:         uint64(i),
+			Name:       fmt.Sprintf("master%d", i),
+			PeerURLs:   []string{fmt.Sprintf("https://192.168.0.%d:2380", i)},
+			ClientURLs: []string{fmt.Sprintf("https://192.168.0.%d:2379", i)},
+		})
+	}
+	return members
+}
+
+func TestCheckEtcdQuorum(t *testing.T) {
+	tests := []struct {
+		name     string
+		members  int
+		healthy  []int
+		removing int
+		wantErr  bool
+	}{
+		{"last member", 1, []int{1}, 1, true},
+		{"all healthy", 3, []int{1, 2, 3}, 3, false},
+		{"remove unhealthy member", 3, []int{1, 2}, 3, false},
+		{"remove healthy member with one down", 3, []int{1, 2}, 2, true},
+		{"quorum lost", 3, []int{1}, 3, true},
+		{"two members", 2, []int{1, 2}, 2, false},
+		{"five members with two down", 5, []int{1, 2, 3}, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := newTestEtcdMembers(tt.members)
+			health := map[string]bool{}
+			for _, i := range tt.healthy {
+				health[members[i-1].ClientURLs[0]] = true
+			}
+			if err := checkEtcdQuorum(members, health, members[tt.removing-1]); (err != nil) != tt.wantErr {
+				t.Errorf("checkEtcdQuorum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindEtcdMember(t *testing.T) {
+	members := newTestEtcdMembers(3)
+	if m := findEtcdMember(members, "192.168.0.2:22"); m == nil || m.Name != "master2" {
+		t.Errorf("findEtcdMember() = %v, want master2", m)
+	}
+	if m := findEtcdMember(members, "192.168.0.4:22"); m != nil {
+		t.Errorf("findEtcdMember() = %v, want nil", m)
+	}
+}
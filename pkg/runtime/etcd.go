@@ -0,0 +1,313 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	etcdContainerIDCmd = "crictl ps -q --label io.kubernetes.container.name=etcd | head -n 1"
+	// etcdctl runs inside the etcd static pod, so the snapshot is written into the data dir which is mounted from host
+	etcdctlCmd         = `ETCD=$(%s) && [ -n "$ETCD" ] && crictl exec $ETCD etcdctl --endpoints=https://127.0.0.1:2379 --cacert=%[2]s/ca.crt --cert=%[2]s/healthcheck-client.crt --key=%[2]s/healthcheck-client.key %[3]s`
+	waitEtcdStoppedCmd = `(for i in $(seq 1 60); do [ -z "$(%s)" ] && exit 0; sleep 2; done; exit 1)`
+	// the etcd image of the static pod is reused to restore the snapshot, so that etcdctl is not required on host
+	etcdSnapshotRestoreCmd = `ctr -n k8s.io run --rm --mount type=bind,src=%[1]s,dst=%[1]s,options=rbind:rw --mount type=bind,src=%[2]s,dst=%[2]s,options=rbind:rw ` +
+		`$(sed -n 's/^ *image: *//p' %[3]s | head -n 1) sealos-etcd-restore etcdctl snapshot restore %[4]s --data-dir %[5]s ` +
+		`--name %[6]s --initial-cluster %[7]s --initial-cluster-token sealos-etcd-restore --initial-advertise-peer-urls %[8]s`
+	// controlPlanePhasesCmd recreates the kubeconfigs and static pods of a control-plane whose manifests are lost
+	controlPlanePhasesCmd = "kubeadm init phase kubeconfig all --config=%[1]s && kubeadm init phase kubelet-start --config=%[1]s && " +
+		"kubeadm init phase control-plane all --config=%[1]s && kubeadm init phase etcd local --config=%[1]s"
+	etcdPeerURL = "https://%s:2380"
+)
+
+// etcdMember is a member in the output of `etcdctl member list -w json`.
+type etcdMember struct {
+	ID         uint64   `json:"ID"`
+	Name       string   `json:"name"`
+	PeerURLs   []string `json:"peerURLs"`
+	ClientURLs []string `json:"clientURLs"`
+}
+
+// etcdEndpointHealth is an endpoint in the output of `etcdctl endpoint health -w json`.
+type etcdEndpointHealth struct {
+	Endpoint string `json:"endpoint"`
+	Health   bool   `json:"health"`
+}
+
+// etcdctl returns the command which runs etcdctl with the args against the local etcd member.
+func etcdctl(args string) string {
+	return fmt.Sprintf(etcdctlCmd, etcdContainerIDCmd, path.Join(constants.KubernetesEtcPKI, constants.PkiEtcdDirName), args)
+}
+
+func (k *KubeadmRuntime) listEtcdMembers(host string) ([]etcdMember, error) {
+	out, err := k.sshCmdToString(host, etcdctl("member list -w json 2>/dev/null"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd members on %s: %v", host, err)
+	}
+	var list struct {
+		Members []etcdMember `json:"members"`
+	}
+	if err = json.Unmarshal([]byte(out), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse etcd members: %v", err)
+	}
+	return list.Members, nil
+}
+
+// getEtcdEndpointsHealth returns the health of the client urls of all etcd members.
+func (k *KubeadmRuntime) getEtcdEndpointsHealth(host string) (map[string]bool, error) {
+	// etcdctl exits with non-zero code if any endpoint is unhealthy
+	out, err := k.sshCmdToString(host, etcdctl("endpoint health --cluster -w json 2>/dev/null || true"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check etcd health on %s: %v", host, err)
+	}
+	var endpoints []etcdEndpointHealth
+	if err = json.Unmarshal([]byte(out), &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse etcd endpoints health: %v", err)
+	}
+	health := make(map[string]bool, len(endpoints))
+	for _, e := range endpoints {
+		health[e.Endpoint] = e.Health
+	}
+	return health, nil
+}
+
+// findEtcdMember returns the member whose peer url is on the host, nil if it is not found.
+func findEtcdMember(members []etcdMember, host string) *etcdMember {
+	ip := iputils.GetHostIP(host)
+	for i := range members {
+		for _, peerURL := range members[i].PeerURLs {
+			if u, err := url.Parse(peerURL); err == nil && u.Hostname() == ip {
+				return &members[i]
+			}
+		}
+	}
+	return nil
+}
+
+func isEtcdMemberHealthy(member etcdMember, health map[string]bool) bool {
+	for _, clientURL := range member.ClientURLs {
+		if health[clientURL] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEtcdQuorum makes sure that etcd keeps the quorum after the member is removed,
+// the removal itself needs the quorum of the current members as well.
+func checkEtcdQuorum(members []etcdMember, health map[string]bool, removing etcdMember) error {
+	if len(members) <= 1 {
+		return fmt.Errorf("etcd member %s is the last member of the cluster", removing.Name)
+	}
+	healthy := 0
+	for _, m := range members {
+		if isEtcdMemberHealthy(m, health) {
+			healthy++
+		}
+	}
+	if quorum := len(members)/2 + 1; healthy < quorum {
+		return fmt.Errorf("only %d of %d etcd members are healthy, etcd has lost the quorum of %d", healthy, len(members), quorum)
+	}
+	remaining := healthy
+	if isEtcdMemberHealthy(removing, health) {
+		remaining--
+	}
+	if quorum := (len(members)-1)/2 + 1; remaining < quorum {
+		return fmt.Errorf("only %d healthy etcd members are left after removing %s, less than the quorum of %d", remaining, removing.Name, quorum)
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) getStaticPodManifest(name string) string {
+	return path.Join(constants.KubernetesEtc, constants.ManifestsDirName, name+".yaml")
+}
+
+func (k *KubeadmRuntime) isRemoteFileExist(host, file string) (bool, error) {
+	out, err := k.sshCmdToString(host, fmt.Sprintf("test -f %s && echo yes || true", file))
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "yes", nil
+}
+
+// checkEtcdCA makes sure that the etcd CA on the master is the one of the local cluster PKI.
+func (k *KubeadmRuntime) checkEtcdCA(master string) error {
+	ca := path.Join(k.getContentData().PkiEtcdPath(), "ca.crt")
+	data, err := os.ReadFile(ca)
+	if err != nil {
+		return fmt.Errorf("failed to read etcd ca of cluster %s: %v", k.getClusterName(), err)
+	}
+	sum := sha256.Sum256(data)
+	out, err := k.sshCmdToString(master, fmt.Sprintf("sha256sum %s | cut -d' ' -f1", path.Join(constants.KubernetesEtcPKI, constants.PkiEtcdDirName, "ca.crt")))
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(out) != hex.EncodeToString(sum[:]) {
+		return fmt.Errorf("etcd ca on %s is different from %s, the host might not belong to cluster %s", master, ca, k.getClusterName())
+	}
+	return nil
+}
+
+// saveEtcdSnapshot takes a snapshot of the local etcd member on the master and moves it to dst.
+func (k *KubeadmRuntime) saveEtcdSnapshot(master, dst string) error {
+	tmp := path.Join(k.getEtcdDataDir(), fmt.Sprintf("sealos-snapshot-%d.db", time.Now().Unix()))
+	logger.Info("start to save etcd snapshot on %s", master)
+	return k.sshCmdAsync(master,
+		etcdctl("snapshot save "+tmp),
+		fmt.Sprintf("mkdir -p %s && mv -f %s %s", path.Dir(dst), tmp, dst),
+	)
+}
+
+// SaveEtcdSnapshot takes a snapshot of etcd on master0, or the first etcd host if etcd is external,
+// and fetches it to the local file dst.
+func (k *KubeadmRuntime) SaveEtcdSnapshot(dst string) error {
+	executor := k.getEtcdExecutor()
+	if err := k.checkEtcdCA(executor); err != nil {
+		return err
+	}
+	snapshot := path.Join(k.getContentData().BackupPath(), "etcd", path.Base(dst))
+	if err := k.saveEtcdSnapshot(executor, snapshot); err != nil {
+		return fmt.Errorf("failed to save etcd snapshot: %v", err)
+	}
+	defer func() {
+		if err := k.sshCmdAsync(executor, fmt.Sprintf("rm -f %s", snapshot)); err != nil {
+			logger.Warn("failed to remove etcd snapshot %s on %s: %v", snapshot, executor, err)
+		}
+	}()
+	return k.getSSHInterface().CopyR(k.ctx, executor, dst, snapshot)
+}
+
+// RestoreEtcdSnapshot restores the whole control plane from the local snapshot file src:
+// the cluster PKI is sent to all masters, the static pods of master0 are recreated by kubeadm
+// if they are lost, then every etcd member is restored from the snapshot with the same membership.
+func (k *KubeadmRuntime) RestoreEtcdSnapshot(src string) error {
+	if k.Cluster.IsExternalEtcd() {
+		return fmt.Errorf("restoring external etcd is not supported, restore the members on %v with etcdutl instead", k.getEtcdIPAndPortList())
+	}
+	masters := k.getMasterIPAndPortList()
+	master0 := k.getMaster0IPAndPort()
+	if err := k.sendNewCertAndKey(masters); err != nil {
+		return err
+	}
+	for _, master := range masters {
+		exist, err := k.isRemoteFileExist(master, k.getStaticPodManifest("etcd"))
+		if err != nil {
+			return err
+		}
+		if exist {
+			continue
+		}
+		if master != master0 {
+			return fmt.Errorf("control plane of %s is lost, delete it from the cluster before restoring and add it back afterwards", master)
+		}
+		logger.Info("control plane of master0 is lost, recreate it by kubeadm")
+		if err = k.ConfigInitKubeadmToMaster0(); err != nil {
+			return err
+		}
+		initConfig := path.Join(k.getContentData().EtcPath(), constants.DefaultInitKubeadmFileName)
+		if err = k.sshCmdAsync(master0, fmt.Sprintf(controlPlanePhasesCmd, initConfig)); err != nil {
+			return fmt.Errorf("failed to recreate control plane of master0: %v", err)
+		}
+	}
+	stagingDir := path.Join(k.getContentData().BackupPath(), "etcd-restore")
+	snapshot := path.Join(stagingDir, "snapshot.db")
+	for _, master := range masters {
+		if err := k.sshCmdAsync(master, fmt.Sprintf("mkdir -p %s", stagingDir)); err != nil {
+			return err
+		}
+		if err := k.sshCopy(master, src, snapshot); err != nil {
+			return fmt.Errorf("failed to send etcd snapshot to %s: %v", master, err)
+		}
+	}
+	if err := k.restoreEtcdSnapshot(masters, snapshot, stagingDir); err != nil {
+		return err
+	}
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	if err = kubernetes.NewKubeHealthy(cli.Kubernetes(), 5*time.Minute).ForAPI(); err != nil {
+		return fmt.Errorf("api-server is not healthy after restoring etcd: %v", err)
+	}
+	for _, master := range masters {
+		if err = k.sshCmdAsync(master, fmt.Sprintf("rm -rf %s", stagingDir)); err != nil {
+			logger.Warn("failed to clean %s on %s: %v", stagingDir, master, err)
+		}
+	}
+	return nil
+}
+
+// restoreEtcdSnapshot replaces the data of every etcd member with the snapshot which has been
+// placed at the same path on all masters. The api-server and etcd are stopped during restoring,
+// the previous data dir is renamed instead of being removed.
+func (k *KubeadmRuntime) restoreEtcdSnapshot(masters []string, snapshot, stagingDir string) error {
+	names := make(map[string]string, len(masters))
+	var initialCluster []string
+	for _, master := range masters {
+		name, err := k.getRemoteInterface().Hostname(master)
+		if err != nil {
+			return err
+		}
+		//default nodeName in k8s is the lower case of their hostname because of DNS protocol.
+		names[master] = strings.ToLower(name)
+		initialCluster = append(initialCluster, fmt.Sprintf("%s="+etcdPeerURL, names[master], iputils.GetHostIP(master)))
+	}
+	etcdManifest, apiServerManifest := k.getStaticPodManifest("etcd"), k.getStaticPodManifest("kube-apiserver")
+	stoppedEtcdManifest := path.Join(stagingDir, "etcd.yaml")
+	for _, master := range masters {
+		logger.Info("stop api-server and etcd on %s", master)
+		// kubelet stops the static pods once their manifests are removed
+		if err := k.sshCmdAsync(master,
+			fmt.Sprintf("mkdir -p %[1]s && mv -f %[2]s %[1]s/ && (test ! -f %[3]s || mv -f %[3]s %[1]s/)", stagingDir, etcdManifest, apiServerManifest),
+			fmt.Sprintf(waitEtcdStoppedCmd, etcdContainerIDCmd),
+		); err != nil {
+			return fmt.Errorf("failed to stop etcd on %s: %v", master, err)
+		}
+	}
+	dataDir := k.getEtcdDataDir()
+	for _, master := range masters {
+		logger.Info("start to restore etcd snapshot %s on %s", snapshot, master)
+		if err := k.sshCmdAsync(master,
+			fmt.Sprintf("mv %s %s.bak-%d", dataDir, dataDir, time.Now().Unix()),
+			fmt.Sprintf(etcdSnapshotRestoreCmd, path.Dir(snapshot), path.Dir(dataDir), stoppedEtcdManifest, snapshot, dataDir,
+				names[master], strings.Join(initialCluster, ","), fmt.Sprintf(etcdPeerURL, iputils.GetHostIP(master))),
+		); err != nil {
+			return fmt.Errorf("failed to restore etcd snapshot on %s: %v", master, err)
+		}
+	}
+	for _, master := range masters {
+		if err := k.sshCmdAsync(master,
+			fmt.Sprintf("mv -f %s %s", stoppedEtcdManifest, etcdManifest),
+			fmt.Sprintf("test ! -f %[1]s/kube-apiserver.yaml || mv -f %[1]s/kube-apiserver.yaml %[2]s", stagingDir, apiServerManifest),
+		); err != nil {
+			return fmt.Errorf("failed to start etcd on %s: %v", master, err)
+		}
+	}
+	return nil
+}
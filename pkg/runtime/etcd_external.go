@@ -0,0 +1,372 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kubernetes/cmd/kubeadm/app/apis/kubeadm"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/system"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	stringsutil "github.com/labring/sealos/pkg/utils/strings"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+const (
+	etcdClientURL = "https://%s:2379"
+	// the kubelet on etcd hosts runs standalone, it only manages the static pod of etcd
+	etcdKubeletDropInDir  = "/etc/systemd/system/kubelet.service.d"
+	etcdKubeletConfigName = "etcd-kubelet.yaml"
+	etcdKubeletDropInName = "20-etcd-service-manager.conf"
+	etcdKubeletDropIn     = `[Service]
+ExecStart=
+ExecStart=/usr/bin/kubelet --config=%s --container-runtime-endpoint=%s
+Restart=always
+`
+	restartKubeletCmd   = "systemctl daemon-reload && systemctl restart kubelet"
+	cleanEtcdKubeletCmd = "rm -f %s %s && systemctl daemon-reload"
+	etcdMemberPhaseCmd  = "kubeadm init phase etcd local --config=%s"
+	waitEtcdHealthyCmd  = `(for i in $(seq 1 60); do %s >/dev/null 2>&1 && exit 0; sleep 2; done; exit 1)`
+
+	etcdInitialClusterStateNew      = "new"
+	etcdInitialClusterStateExisting = "existing"
+)
+
+func (k *KubeadmRuntime) getEtcdIPAndPortList() []string {
+	return k.Cluster.GetEtcdIPAndPortList()
+}
+
+func (k *KubeadmRuntime) getEtcdEndpoints() []string {
+	var endpoints []string
+	for _, ip := range k.Cluster.GetEtcdIPList() {
+		endpoints = append(endpoints, fmt.Sprintf(etcdClientURL, ip))
+	}
+	return endpoints
+}
+
+// getEtcdExecutor returns the host on which etcdctl is able to run against the local etcd member.
+func (k *KubeadmRuntime) getEtcdExecutor() string {
+	if k.Cluster.IsExternalEtcd() {
+		return k.getEtcdIPAndPortList()[0]
+	}
+	return k.getMaster0IPAndPort()
+}
+
+// setExternalEtcd points the control plane to the etcd members on the etcd hosts,
+// nothing is changed if etcd is stacked on the masters.
+func (k *KubeadmRuntime) setExternalEtcd() {
+	if !k.Cluster.IsExternalEtcd() {
+		return
+	}
+	k.ClusterConfiguration.Etcd.Local = nil
+	k.ClusterConfiguration.Etcd.External = &kubeadm.ExternalEtcd{
+		Endpoints: k.getEtcdEndpoints(),
+		CAFile:    path.Join(constants.KubernetesEtcPKI, constants.PkiEtcdDirName, "ca.crt"),
+		CertFile:  path.Join(constants.KubernetesEtcPKI, "apiserver-etcd-client.crt"),
+		KeyFile:   path.Join(constants.KubernetesEtcPKI, "apiserver-etcd-client.key"),
+	}
+}
+
+func (k *KubeadmRuntime) getEtcdMemberName(host string) (string, error) {
+	name, err := k.getRemoteInterface().Hostname(host)
+	if err != nil {
+		return "", err
+	}
+	//default nodeName in k8s is the lower case of their hostname because of DNS protocol.
+	return strings.ToLower(name), nil
+}
+
+// InitExternalEtcd bootstraps a new etcd cluster on the etcd hosts before master0 is initialized,
+// it does nothing if etcd is stacked on the masters.
+func (k *KubeadmRuntime) InitExternalEtcd() error {
+	hosts := k.getEtcdIPAndPortList()
+	if len(hosts) == 0 {
+		return nil
+	}
+	logger.Info("start to init external etcd on %v", hosts)
+	names := make(map[string]string, len(hosts))
+	var initialCluster []string
+	for _, host := range hosts {
+		name, err := k.getEtcdMemberName(host)
+		if err != nil {
+			return err
+		}
+		names[host] = name
+		initialCluster = append(initialCluster, fmt.Sprintf("%s="+etcdPeerURL, name, iputils.GetHostIP(host)))
+	}
+	// all the members have to be started before etcd gets the quorum
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, host := range hosts {
+		host := host
+		eg.Go(func() error {
+			step := events.StartStep(events.SourceRuntime, "InitEtcdMember", host)
+			err := k.setupEtcdMember(host, names[host], initialCluster, etcdInitialClusterStateNew)
+			step.Finish(err)
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	return k.waitEtcdHealthy(hosts[0])
+}
+
+// JoinEtcdMembers adds the etcd hosts into the external etcd cluster one by one,
+// then the api-servers are pointed to all the members.
+func (k *KubeadmRuntime) JoinEtcdMembers(newEtcdIPList []string) error {
+	if len(newEtcdIPList) == 0 {
+		return nil
+	}
+	logger.Info("%s will be added as etcd member", newEtcdIPList)
+	existing := stringsutil.RemoveStrSlice(k.getEtcdIPAndPortList(), newEtcdIPList)
+	if len(existing) == 0 {
+		return fmt.Errorf("no etcd host is found in cluster %s, etcd members can only be added when etcd is external", k.getClusterName())
+	}
+	if err := ssh.WaitSSHReady(k.ctx, k.getSSHInterface(), 6, newEtcdIPList...); err != nil {
+		return fmt.Errorf("join etcd members wait for ssh ready time out: %w", err)
+	}
+	if err := k.MergeKubeadmConfig(); err != nil {
+		return err
+	}
+	executor := existing[0]
+	for _, host := range newEtcdIPList {
+		step := events.StartStep(events.SourceRuntime, "JoinEtcdMember", host)
+		err := k.joinEtcdMember(executor, host)
+		step.Finish(err)
+		if err != nil {
+			return fmt.Errorf("join etcd member %s failed %v", host, err)
+		}
+	}
+	return k.updateExternalEtcdEndpoints()
+}
+
+func (k *KubeadmRuntime) joinEtcdMember(executor, host string) error {
+	name, err := k.getEtcdMemberName(host)
+	if err != nil {
+		return err
+	}
+	members, err := k.listEtcdMembers(executor)
+	if err != nil {
+		return err
+	}
+	// the member might have been added by the last failed attempt
+	if findEtcdMember(members, host) == nil {
+		logger.Info("start to add etcd member %s", name)
+		if err = k.sshCmdAsync(executor, etcdctl(fmt.Sprintf("member add %s --peer-urls=%s", name, fmt.Sprintf(etcdPeerURL, iputils.GetHostIP(host))))); err != nil {
+			return fmt.Errorf("failed to add etcd member %s: %v", name, err)
+		}
+		if members, err = k.listEtcdMembers(executor); err != nil {
+			return err
+		}
+	}
+	if err = k.setupEtcdMember(host, name, getEtcdInitialCluster(members, name), etcdInitialClusterStateExisting); err != nil {
+		return err
+	}
+	return k.waitEtcdHealthy(host)
+}
+
+// getEtcdInitialCluster returns the initial cluster of the member which has been added but not started yet,
+// such a member has no name in the member list.
+func getEtcdInitialCluster(members []etcdMember, name string) []string {
+	var initialCluster []string
+	for _, m := range members {
+		memberName := m.Name
+		if memberName == "" {
+			memberName = name
+		}
+		for _, peerURL := range m.PeerURLs {
+			initialCluster = append(initialCluster, fmt.Sprintf("%s=%s", memberName, peerURL))
+		}
+	}
+	return initialCluster
+}
+
+// setupEtcdMember sends the certs signed by the etcd ca of the cluster to the etcd host, and
+// runs the etcd static pod by a standalone kubelet, which is the same as kubeadm does for stacked etcd.
+func (k *KubeadmRuntime) setupEtcdMember(host, name string, initialCluster []string, state string) error {
+	ip := iputils.GetHostIP(host)
+	localDir := path.Join(k.getContentData().TmpPath(), "etcd", ip)
+	logger.Info("start to generate etcd certs of %s", host)
+	if err := cert.GenerateEtcdMember(k.getContentData().PkiEtcdPath(), localDir, name, ip); err != nil {
+		return fmt.Errorf("failed to generate etcd certs of %s: %v", host, err)
+	}
+	criSocket, err := k.getCRISocket(host)
+	if err != nil {
+		return err
+	}
+	cgroupDriver, err := k.getCGroupDriver(host)
+	if err != nil {
+		return err
+	}
+	remotePKI := path.Join(constants.KubernetesEtcPKI, constants.PkiEtcdDirName)
+	remoteKubeletConfig := path.Join(etcdKubeletDropInDir, etcdKubeletConfigName)
+	remoteKubeadmConfig := path.Join(k.getContentData().EtcPath(), constants.DefaultEtcdKubeadmFileName)
+
+	kubeletConfig := map[string]interface{}{
+		"apiVersion":     "kubelet.config.k8s.io/v1beta1",
+		"kind":           "KubeletConfiguration",
+		"authentication": map[string]interface{}{"anonymous": map[string]bool{"enabled": false}, "webhook": map[string]bool{"enabled": false}},
+		"authorization":  map[string]string{"mode": "AlwaysAllow"},
+		"cgroupDriver":   cgroupDriver,
+		"address":        "127.0.0.1",
+		"staticPodPath":  constants.KubernetesEtcStaticPod,
+	}
+	if err = yaml.MarshalYamlToFile(path.Join(localDir, etcdKubeletConfigName), kubeletConfig); err != nil {
+		return err
+	}
+	if err = file.WriteFile(path.Join(localDir, etcdKubeletDropInName), []byte(fmt.Sprintf(etcdKubeletDropIn, remoteKubeletConfig, criSocket))); err != nil {
+		return err
+	}
+	if err = yaml.MarshalYamlToFile(path.Join(localDir, constants.DefaultEtcdKubeadmFileName), k.getEtcdMemberKubeadmConfigs(name, ip, criSocket, initialCluster, state)...); err != nil {
+		return err
+	}
+
+	copies := map[string]string{
+		path.Join(k.getContentData().PkiEtcdPath(), "ca.crt"):     path.Join(remotePKI, "ca.crt"),
+		path.Join(localDir, etcdKubeletConfigName):                remoteKubeletConfig,
+		path.Join(localDir, etcdKubeletDropInName):                path.Join(etcdKubeletDropInDir, etcdKubeletDropInName),
+		path.Join(localDir, constants.DefaultEtcdKubeadmFileName): remoteKubeadmConfig,
+	}
+	for _, base := range []string{"server", "peer", "healthcheck-client"} {
+		for _, ext := range []string{".crt", ".key"} {
+			copies[path.Join(localDir, base+ext)] = path.Join(remotePKI, base+ext)
+		}
+	}
+	for src, dst := range copies {
+		if err = k.sshCopy(host, src, dst); err != nil {
+			return fmt.Errorf("failed to copy %s to %s: %v", src, host, err)
+		}
+	}
+	logger.Info("start to run etcd member %s on %s", name, host)
+	if err = k.sshCmdAsync(host, restartKubeletCmd, fmt.Sprintf(etcdMemberPhaseCmd, remoteKubeadmConfig)); err != nil {
+		return fmt.Errorf("failed to run etcd member on %s: %v", host, err)
+	}
+	return nil
+}
+
+// getEtcdMemberKubeadmConfigs returns the kubeadm configs which are used to generate the manifest of the etcd member.
+func (k *KubeadmRuntime) getEtcdMemberKubeadmConfigs(name, ip, criSocket string, initialCluster []string, state string) []interface{} {
+	initConfig := map[string]interface{}{
+		"apiVersion":       k.getKubeadmAPIVersion(),
+		"kind":             "InitConfiguration",
+		"nodeRegistration": map[string]interface{}{"name": name, "criSocket": criSocket},
+		"localAPIEndpoint": map[string]interface{}{"advertiseAddress": ip},
+	}
+	clusterConfig := map[string]interface{}{
+		"apiVersion":        k.getKubeadmAPIVersion(),
+		"kind":              "ClusterConfiguration",
+		"kubernetesVersion": k.getKubeVersion(),
+		"etcd": map[string]interface{}{
+			"local": map[string]interface{}{
+				"dataDir":        k.getEtcdDataDir(),
+				"serverCertSANs": []string{ip},
+				"peerCertSANs":   []string{ip},
+				"extraArgs": map[string]string{
+					"name":                        name,
+					"initial-cluster":             strings.Join(initialCluster, ","),
+					"initial-cluster-state":       state,
+					"listen-peer-urls":            fmt.Sprintf(etcdPeerURL, ip),
+					"listen-client-urls":          fmt.Sprintf(etcdClientURL, "127.0.0.1") + "," + fmt.Sprintf(etcdClientURL, ip),
+					"advertise-client-urls":       fmt.Sprintf(etcdClientURL, ip),
+					"initial-advertise-peer-urls": fmt.Sprintf(etcdPeerURL, ip),
+				},
+			},
+		},
+	}
+	if repo := k.ClusterConfiguration.ImageRepository; repo != "" {
+		clusterConfig["imageRepository"] = repo
+	}
+	return []interface{}{initConfig, clusterConfig}
+}
+
+func (k *KubeadmRuntime) waitEtcdHealthy(host string) error {
+	logger.Info("wait for etcd member on %s to be healthy", host)
+	if err := k.sshCmdAsync(host, fmt.Sprintf(waitEtcdHealthyCmd, etcdctl("endpoint health"))); err != nil {
+		return fmt.Errorf("etcd member on %s is not healthy: %v", host, err)
+	}
+	return nil
+}
+
+// updateExternalEtcdEndpoints updates the etcd endpoints in the kubeadm config of the cluster
+// and in the api-server on every master.
+func (k *KubeadmRuntime) updateExternalEtcdEndpoints() error {
+	endpoints := k.getEtcdEndpoints()
+	logger.Info("start to update etcd endpoints to %v", endpoints)
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	data, err := kubernetes.GetKubeadmConfig(cli.Kubernetes())
+	if err != nil {
+		return err
+	}
+	obj, err := yaml.UnmarshalData([]byte(data.Data[ClusterConfiguration]))
+	if err != nil {
+		return err
+	}
+	if err = unstructured.SetNestedStringSlice(obj, endpoints, "etcd", "external", "endpoints"); err != nil {
+		return err
+	}
+	if err = yaml.MarshalYamlToFile(path.Join(k.getContentData().EtcPath(), constants.DefaultUpdateKubeadmFileName), obj); err != nil {
+		return err
+	}
+	if err = k.uploadConfigFromKubeadm(); err != nil {
+		return err
+	}
+	for _, master := range k.getMasterIPAndPortList() {
+		if err = k.sshCmdAsync(master, fmt.Sprintf(`sed -i "s#--etcd-servers=.*#--etcd-servers=%s#" %s`,
+			strings.Join(endpoints, ","), k.getStaticPodManifest("kube-apiserver"))); err != nil {
+			return fmt.Errorf("failed to update etcd endpoints of api-server on %s: %v", master, err)
+		}
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) resetEtcdMembers(hosts []string) {
+	if len(hosts) == 0 {
+		return
+	}
+	logger.Info("start to reset etcd members: %v", hosts)
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, host := range hosts {
+		host := host
+		eg.Go(func() error {
+			if err := k.resetNode(host, func() {
+				cmd := fmt.Sprintf(cleanEtcdKubeletCmd, path.Join(etcdKubeletDropInDir, etcdKubeletDropInName), path.Join(etcdKubeletDropInDir, etcdKubeletConfigName))
+				if err := k.sshCmdAsync(host, cmd); err != nil {
+					logger.Error("failed to clean kubelet of etcd on %s: %v", host, err)
+				}
+			}); err != nil {
+				logger.Error("delete etcd member %s failed %v", host, err)
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+}
@@ -0,0 +1,31 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetEtcdInitialCluster(t *testing.T) {
+	members := []etcdMember{
+		{ID: 1, Name: "etcd-0", PeerURLs: []string{"https://192.168.0.8:2380"}},
+		{ID: 2, PeerURLs: []string{"https://192.168.0.9:2380"}},
+	}
+	want := []string{"etcd-0=https://192.168.0.8:2380", "etcd-1=https://192.168.0.9:2380"}
+	if got := getEtcdInitialCluster(members, "etcd-1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("getEtcdInitialCluster() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,454 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/system"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	strings2 "github.com/labring/sealos/pkg/utils/strings"
+	yaml2 "github.com/labring/sealos/pkg/utils/yaml"
+)
+
+const (
+	k3sServerService = "k3s"
+	k3sAgentService  = "k3s-agent"
+
+	k3sConfigFile     = "/etc/rancher/k3s/config.yaml"
+	k3sKubeConfigFile = "/etc/rancher/k3s/k3s.yaml"
+	k3sDataDir        = "/var/lib/rancher/k3s"
+	k3sTokenFile      = "/var/lib/rancher/k3s/server/token"
+	k3sUnitDir        = "/etc/systemd/system"
+
+	k3sUnit = `[Unit]
+Description=Lightweight Kubernetes
+Documentation=https://k3s.io
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=%s
+ExecStart=/usr/bin/k3s %s
+KillMode=process
+Delegate=yes
+LimitNOFILE=1048576
+LimitNPROC=infinity
+LimitCORE=infinity
+TasksMax=infinity
+TimeoutStartSec=0
+Restart=always
+RestartSec=5s
+
+[Install]
+WantedBy=multi-user.target
+`
+	enableK3sCmd      = "systemctl daemon-reload && systemctl enable --now %s"
+	restartK3sCmd     = "systemctl restart %s"
+	copyK3sKubeConfig = `rm -rf $HOME/.kube/config && mkdir -p $HOME/.kube && cp /etc/rancher/k3s/k3s.yaml $HOME/.kube/config`
+	remoteCleanK3sCmd = `systemctl disable --now k3s k3s-agent >/dev/null 2>&1 || true && \
+rm -f /etc/systemd/system/k3s.service /etc/systemd/system/k3s-agent.service && systemctl daemon-reload && \
+(ps -e -o pid= -o args= | awk '$2 ~ "^/var/lib/rancher/k3s/data/.*/containerd-shim" {print $1}' | xargs -r kill -9) && \
+(awk '$2 ~ "^(/var/lib/kubelet|/run/k3s|/var/lib/rancher/k3s)/" {print $2}' /proc/self/mounts | sort -r | xargs -r umount) && \
+rm -rf /etc/rancher/k3s /var/lib/rancher/k3s /var/lib/kubelet /run/k3s /run/flannel && \
+(ip link delete cni0 >/dev/null 2>&1 || true) && (ip link delete flannel.1 >/dev/null 2>&1 || true)
+`
+)
+
+// K3sRuntime installs kubernetes by the k3s binary shipped in the rootfs image, which is expected to be
+// installed into /usr/bin by the init script of the image. The servers run embedded etcd, and the agents
+// balance the servers by themselves, so neither external etcd nor lvscare is used.
+// The ssh, hosts and node helpers are shared with KubeadmRuntime, and only the networking and certSANs
+// of the kubeadm config in Clusterfile are used.
+type K3sRuntime struct {
+	*KubeadmRuntime
+}
+
+var _ Interface = &K3sRuntime{}
+
+// k3sConfig is the config file of k3s, see https://docs.k3s.io/installation/configuration#configuration-file.
+type k3sConfig struct {
+	Server        string   `json:"server,omitempty"`
+	Token         string   `json:"token,omitempty"`
+	ClusterInit   bool     `json:"cluster-init,omitempty"`
+	TLSSan        []string `json:"tls-san,omitempty"`
+	ClusterCIDR   string   `json:"cluster-cidr,omitempty"`
+	ServiceCIDR   string   `json:"service-cidr,omitempty"`
+	ClusterDomain string   `json:"cluster-domain,omitempty"`
+	NodeIP        string   `json:"node-ip,omitempty"`
+	NodeLabel     []string `json:"node-label,omitempty"`
+	NodeTaint     []string `json:"node-taint,omitempty"`
+}
+
+func newK3sRuntime(ctx context.Context, cluster *v2.Cluster, kubeadm *KubeadmConfig) (Interface, error) {
+	k := &K3sRuntime{
+		KubeadmRuntime: &KubeadmRuntime{
+			Mutex:   &sync.Mutex{},
+			ctx:     ctx,
+			Cluster: cluster,
+			Config: &Config{
+				ClusterFileKubeConfig: kubeadm,
+				APIServerDomain:       DefaultAPIServerDomain,
+			},
+			KubeadmConfig: &KubeadmConfig{},
+		},
+	}
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// newK3sConfig returns the config of the host, a server joins the cluster by server if it is not empty,
+// otherwise it initializes the cluster.
+func (k *K3sRuntime) newK3sConfig(host string, isServer bool, server, token string) *k3sConfig {
+	c := &k3sConfig{
+		Server: server,
+		Token:  token,
+		NodeIP: iputils.GetHostIP(host),
+	}
+	if h := k.Cluster.GetHostByIP(host); h != nil {
+		for _, key := range sets.StringKeySet(h.Labels).List() {
+			// the others are set by SyncNodeConfigs
+			if isKubeletLabel(key) {
+				c.NodeLabel = append(c.NodeLabel, fmt.Sprintf("%s=%s", key, h.Labels[key]))
+			}
+		}
+		for _, t := range h.Taints {
+			c.NodeTaint = append(c.NodeTaint, t.ToString())
+		}
+	}
+	if !isServer {
+		return c
+	}
+	c.ClusterInit = server == ""
+	c.TLSSan = append([]string{k.getAPIServerDomain()}, k.getMasterIPList()...)
+	if kubeadm := k.ClusterFileKubeConfig; kubeadm != nil {
+		networking := kubeadm.ClusterConfiguration.Networking
+		c.ClusterCIDR = networking.PodSubnet
+		c.ServiceCIDR = networking.ServiceSubnet
+		c.ClusterDomain = networking.DNSDomain
+		c.TLSSan = append(c.TLSSan, kubeadm.ClusterConfiguration.APIServer.CertSANs...)
+	}
+	c.TLSSan = strings2.RemoveDuplicate(c.TLSSan)
+	return c
+}
+
+func (k *K3sRuntime) getK3sServer() string {
+	return fmt.Sprintf("https://%s:%d", k.getMaster0IP(), k.getAPIServerPort())
+}
+
+// getK3sToken returns the token generated by master0 when the cluster is initialized.
+func (k *K3sRuntime) getK3sToken() (string, error) {
+	token, err := k.sshCmdToString(k.getMaster0IPAndPort(), "cat "+k3sTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to get k3s token from master0: %v", err)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("k3s token of master0 is empty")
+	}
+	return token, nil
+}
+
+// writeRemoteFile writes data into the file dst on the host.
+func (k *K3sRuntime) writeRemoteFile(host, dst string, data []byte) error {
+	localPath := path.Join(k.getContentData().TmpPath(), "k3s", iputils.GetHostIP(host), path.Base(dst))
+	if err := file.WriteFile(localPath, data); err != nil {
+		return err
+	}
+	return k.sshCopy(host, localPath, dst)
+}
+
+// installK3s writes the config and systemd unit of k3s on the host and starts it, a server is started
+// only after it is ready.
+func (k *K3sRuntime) installK3s(host string, isServer bool, c *k3sConfig) error {
+	service, unitType, args := k3sAgentService, "exec", "agent"
+	if isServer {
+		service, unitType, args = k3sServerService, "notify", "server"
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err = k.writeRemoteFile(host, k3sConfigFile, data); err != nil {
+		return fmt.Errorf("failed to write k3s config: %v", err)
+	}
+	if err = k.writeRemoteFile(host, path.Join(k3sUnitDir, service+".service"), []byte(fmt.Sprintf(k3sUnit, unitType, args))); err != nil {
+		return fmt.Errorf("failed to write k3s unit: %v", err)
+	}
+	logger.Info("start to run %s on %s", service, host)
+	return k.sshCmdAsync(host, fmt.Sprintf(enableK3sCmd, service))
+}
+
+// fetchK3sKubeConfig fetches the kubeconfig of admin from master0, the server in it is replaced
+// by the api-server domain like the one generated by kubeadm.
+func (k *K3sRuntime) fetchK3sKubeConfig() error {
+	data, err := k.fetchRemoteFile(k.getMaster0IPAndPort(), k3sKubeConfigFile)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("%s not found on master0", k3sKubeConfigFile)
+	}
+	config := strings.ReplaceAll(string(data), fmt.Sprintf("https://127.0.0.1:%d", k.getAPIServerPort()), k.getClusterAPIServer())
+	return file.WriteFile(k.getContentData().AdminFile(), []byte(config))
+}
+
+func (k *K3sRuntime) Init() error {
+	master0 := k.getMaster0IPAndPort()
+	pipeline := []func() error{
+		func() error {
+			return k.execHostsAppend(master0, k.getMaster0IP(), k.getAPIServerDomain())
+		},
+		func() error {
+			return k.installK3s(master0, true, k.newK3sConfig(master0, true, "", ""))
+		},
+		k.fetchK3sKubeConfig,
+		func() error {
+			return k.sshCmdAsync(master0, copyK3sKubeConfig)
+		},
+	}
+	return k.pipeline("init", pipeline)
+}
+
+// JoinMasters joins the servers one by one, since the etcd members are added in turn.
+func (k *K3sRuntime) JoinMasters(newMastersIPList []string) error {
+	if len(newMastersIPList) == 0 {
+		return nil
+	}
+	logger.Info("%s will be added as master", newMastersIPList)
+	token, err := k.getK3sToken()
+	if err != nil {
+		return err
+	}
+	for _, master := range newMastersIPList {
+		step := events.StartStep(events.SourceRuntime, "JoinMaster", master)
+		err = k.joinK3sServer(master, token)
+		step.Finish(err)
+		if err != nil {
+			return fmt.Errorf("failed to join master %s: %v", master, err)
+		}
+		logger.Info("succeeded in joining %s as master", master)
+	}
+	return nil
+}
+
+func (k *K3sRuntime) joinK3sServer(master, token string) error {
+	if err := k.execHostsAppend(master, master, k.getAPIServerDomain()); err != nil {
+		return err
+	}
+	if err := k.installK3s(master, true, k.newK3sConfig(master, true, k.getK3sServer(), token)); err != nil {
+		return err
+	}
+	return k.sshCmdAsync(master, copyK3sKubeConfig)
+}
+
+func (k *K3sRuntime) JoinNodes(newNodesIPList []string) error {
+	if len(newNodesIPList) == 0 {
+		return nil
+	}
+	logger.Info("%s will be added as worker", newNodesIPList)
+	token, err := k.getK3sToken()
+	if err != nil {
+		return err
+	}
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, node := range newNodesIPList {
+		node := node
+		eg.Go(func() error {
+			step := events.StartStep(events.SourceRuntime, "JoinNode", node)
+			err := k.installK3s(node, false, k.newK3sConfig(node, false, k.getK3sServer(), token))
+			step.Finish(err)
+			if err != nil {
+				return fmt.Errorf("failed to join node %s: %v", node, err)
+			}
+			logger.Info("succeeded in joining %s as worker", node)
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (k *K3sRuntime) DeleteNodes(nodeIPList []string) error {
+	if len(nodeIPList) == 0 {
+		return nil
+	}
+	logger.Info("worker %s will be deleted", nodeIPList)
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, node := range nodeIPList {
+		node := node
+		eg.Go(func() error {
+			step := events.StartStep(events.SourceRuntime, "DeleteNode", node)
+			err := k.deleteK3sNode(node)
+			step.Finish(err)
+			if err != nil {
+				return fmt.Errorf("delete node %s failed %v", node, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// DeleteMasters deletes the servers one by one, k3s removes the etcd member of a server once its node is deleted.
+func (k *K3sRuntime) DeleteMasters(mastersIPList []string) error {
+	if len(mastersIPList) == 0 {
+		return nil
+	}
+	logger.Info("master %s will be deleted", mastersIPList)
+	for _, master := range mastersIPList {
+		step := events.StartStep(events.SourceRuntime, "DeleteMaster", master)
+		err := k.deleteK3sNode(master)
+		step.Finish(err)
+		if err != nil {
+			return fmt.Errorf("delete master %s failed %v", master, err)
+		}
+	}
+	return nil
+}
+
+// deleteK3sNode drains the node, stops k3s on the host before deleting the node, otherwise it registers itself again.
+func (k *K3sRuntime) deleteK3sNode(host string) error {
+	if err := k.drainNodeBeforeDelete(host); err != nil {
+		if !IgnoreDrainErrors {
+			return fmt.Errorf("%v, use --ignore-drain-errors to delete it anyway", err)
+		}
+		logger.Warn("failed to drain node %s, delete it by force: %v", host, err)
+	}
+	k.resetK3sHost(host)
+	if err := k.RemoveNodeFromK8sClient(host); err != nil {
+		logger.Warn(fmt.Errorf("delete node %s failed %v", host, err))
+	}
+	return nil
+}
+
+func (k *K3sRuntime) Reset() error {
+	logger.Info("start to delete Cluster: master %s, node %s", k.getMasterIPList(), k.getNodeIPList())
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, node := range k.getNodeIPAndPortList() {
+		node := node
+		eg.Go(func() error {
+			k.resetK3sHost(node)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	for _, master := range k.getMasterIPAndPortList() {
+		k.resetK3sHost(master)
+	}
+	return nil
+}
+
+// resetK3sHost stops k3s and removes its data on the host, the failures are logged only.
+func (k *K3sRuntime) resetK3sHost(host string) {
+	logger.Info("start to reset node: %s", host)
+	if err := k.sshCmdAsync(host, removeKubeConfig, remoteCleanK3sCmd); err != nil {
+		logger.Error("failed to clean node %s: %v", host, err)
+	}
+	if err := k.execHostsDelete(host, k.getAPIServerDomain()); err != nil {
+		logger.Error("delete apiserver hosts failed %v", err)
+	}
+}
+
+func (k *K3sRuntime) JoinEtcdMembers(newEtcdIPList []string) error {
+	if len(newEtcdIPList) == 0 {
+		return nil
+	}
+	return fmt.Errorf("external etcd is not supported by distribution %s, remove the etcd role of %v", v2.K3sDistribution, newEtcdIPList)
+}
+
+// SyncNodeIPVS does nothing, the agents of k3s balance the servers by themselves.
+func (k *K3sRuntime) SyncNodeIPVS(_, _ []string) error {
+	return nil
+}
+
+// SyncNodeConfigs applies the labels and taints declared in the host groups to the nodes of the hosts,
+// the kubelet config is not supported by k3s.
+func (k *K3sRuntime) SyncNodeConfigs(hosts []string) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, host := range hosts {
+		host := host
+		eg.Go(func() error {
+			step := events.StartStep(events.SourceRuntime, "SyncNodeConfig", host)
+			err := k.syncK3sNodeConfig(cli, host)
+			step.Finish(err)
+			if err != nil {
+				return fmt.Errorf("failed to sync config of node %s: %v", host, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (k *K3sRuntime) syncK3sNodeConfig(cli kubernetes.Client, host string) error {
+	h := k.Cluster.GetHostByIP(host)
+	if h == nil {
+		return nil
+	}
+	if h.KubeletConfig != nil && len(h.KubeletConfig.Raw) > 0 {
+		logger.Warn("kubelet config of %s is ignored, it is not supported by distribution %s", host, v2.K3sDistribution)
+	}
+	name, err := getNodeNameByIP(k.ctx, cli, host)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("node of %s is not found", host)
+	}
+	return kubernetes.NewKubeIdempotency(cli.Kubernetes()).PatchNode(name, func(n *v1.Node) {
+		setNodeLabelsAndTaints(n, h.Labels, h.Taints)
+	})
+}
+
+// GetAdminKubeconfig returns the Clusterfile of the cluster.
+func (k *K3sRuntime) GetAdminKubeconfig() ([]byte, error) {
+	k.Cluster.Status = v2.ClusterStatus{}
+	objects := []interface{}{k.Cluster}
+	if k.ClusterFileKubeConfig != nil && k.ClusterFileKubeConfig.ClusterConfiguration.Kind != "" {
+		objects = append(objects, k.ClusterFileKubeConfig.ClusterConfiguration)
+	}
+	return yaml2.MarshalYamlConfigs(objects...)
+}
+
+func errK3sNotSupported(operation string) error {
+	return fmt.Errorf("%s is not supported by distribution %s", operation, v2.K3sDistribution)
+}
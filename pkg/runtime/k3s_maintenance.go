@@ -0,0 +1,316 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"sigs.k8s.io/yaml"
+
+	"github.com/labring/sealos/pkg/cert"
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/system"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	strings2 "github.com/labring/sealos/pkg/utils/strings"
+	"github.com/labring/sealos/pkg/utils/versionutil"
+)
+
+const (
+	k3sServerTLSDir = "/var/lib/rancher/k3s/server/tls"
+	k3sAgentDir     = "/var/lib/rancher/k3s/agent"
+	k3sDBDir        = "/var/lib/rancher/k3s/server/db"
+
+	listK3sCertsCmd     = "ls -1 %s/*.crt 2>/dev/null || true"
+	rotateK3sCertsCmd   = "systemctl stop k3s && k3s certificate rotate && systemctl start k3s"
+	installK3sBinaryCmd = "cp -f %s/k3s /usr/bin/k3s.new && mv -f /usr/bin/k3s.new /usr/bin/k3s"
+	saveK3sSnapshotCmd  = "rm -rf %[1]s && mkdir -p %[1]s && k3s etcd-snapshot save --dir %[1]s --name sealos >/dev/null && ls -1 %[1]s | head -n 1"
+	stopK3sCmd          = "systemctl stop k3s"
+	startK3sCmd         = "systemctl start k3s"
+	resetK3sClusterCmd  = "k3s server --cluster-reset --cluster-reset-restore-path=%s"
+	removeK3sDBCmd      = "rm -rf " + k3sDBDir
+
+	k3sAPIHealthyTimeout = 5 * time.Minute
+)
+
+// UpgradeCluster replaces the k3s binary by the one of the new rootfs image and restarts k3s, the servers are
+// upgraded one by one and then the agents in batches, each node is drained before restarting.
+func (k *K3sRuntime) UpgradeCluster(version string) error {
+	curVersion := k.getKubeVersionFromImage()
+	if curVersion == version {
+		logger.Info("The cluster version has not changed")
+		return nil
+	}
+	if !versionutil.Compare(version, curVersion) {
+		logger.Info("new cluster version %s behind the current version %s", version, curVersion)
+		return nil
+	}
+	if err := versionutil.UpgradeVersionLimit(curVersion, version); err != nil {
+		return err
+	}
+	logger.Info("cluster version: %s will be upgraded into %s.", curVersion, version)
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	masters, nodes := k.getMasterIPAndPortList(), k.getNodeIPAndPortList()
+	nodeNames, unschedulable, err := k.upgradePreflight(cli, append(append([]string{}, masters...), nodes...))
+	if err != nil {
+		return fmt.Errorf("upgrade pre-flight checks failed: %v", err)
+	}
+	strategy := k.getUpgradeStrategy()
+	u := &nodeUpgrader{KubeadmRuntime: k.KubeadmRuntime, client: cli, strategy: strategy, version: version, nodeNames: nodeNames, unschedulable: unschedulable}
+	logger.Info("start to upgrade servers")
+	for _, ip := range masters {
+		if err = k.upgradeK3sNode(u, ip, k3sServerService); err != nil {
+			return err
+		}
+	}
+	logger.Info("start to upgrade agents, %d at a time", strategy.MaxUnavailable)
+	for _, batch := range splitBatches(nodes, strategy.MaxUnavailable) {
+		eg, _ := errgroup.WithContext(k.ctx)
+		for _, ip := range batch {
+			ip := ip
+			eg.Go(func() error {
+				return k.upgradeK3sNode(u, ip, k3sAgentService)
+			})
+		}
+		if err = eg.Wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *K3sRuntime) upgradeK3sNode(u *nodeUpgrader, ip, service string) error {
+	nodeName := u.nodeNames[ip]
+	logger.Info("upgrade node %s", nodeName)
+	if err := u.drainNode(nodeName); err != nil {
+		return err
+	}
+	if err := k.sshCmdAsync(ip,
+		fmt.Sprintf(installK3sBinaryCmd, k.getContentData().RootFSBinPath()),
+		fmt.Sprintf(restartK3sCmd, service),
+	); err != nil {
+		return err
+	}
+	healthy := kubernetes.NewKubeHealthy(u.client.Kubernetes(), u.strategy.NodeReadyTimeout.Duration)
+	if err := healthy.ForNodeReady(nodeName); err != nil {
+		return fmt.Errorf("node %s is not ready after upgrading: %v", nodeName, err)
+	}
+	return u.uncordonNode(ip)
+}
+
+// RollbackUpgrade is not supported, since the binaries are not backed up before upgrading.
+func (k *K3sRuntime) RollbackUpgrade(_ bool) error {
+	return errK3sNotSupported("rolling back an upgrade")
+}
+
+// RebootNodes is not supported yet.
+func (k *K3sRuntime) RebootNodes(_ []string, _ int) error {
+	return errK3sNotSupported("rebooting nodes")
+}
+
+// DiffClusterConfig is not supported, the control plane of k3s is configured by its config file.
+func (k *K3sRuntime) DiffClusterConfig() ([]ConfigChange, error) {
+	return nil, errK3sNotSupported("reconfiguring control plane")
+}
+
+func (k *K3sRuntime) Reconfigure(_ string) error {
+	return errK3sNotSupported("reconfiguring control plane")
+}
+
+// UpdateCert adds the certSANs into the config of the servers and restarts them one by one,
+// k3s regenerates the serving cert of api-server on starting.
+func (k *K3sRuntime) UpdateCert(certs []string) error {
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	for _, master := range k.getMasterIPAndPortList() {
+		data, err := k.fetchRemoteFile(master, k3sConfigFile)
+		if err != nil {
+			return err
+		}
+		c := &k3sConfig{}
+		if err = yaml.Unmarshal(data, c); err != nil {
+			return fmt.Errorf("failed to parse k3s config of %s: %v", master, err)
+		}
+		sans := strings2.RemoveDuplicate(append(append([]string{}, c.TLSSan...), certs...))
+		if len(sans) == len(c.TLSSan) {
+			logger.Info("certSANs of %s are up to date", master)
+			continue
+		}
+		c.TLSSan = sans
+		if data, err = yaml.Marshal(c); err != nil {
+			return err
+		}
+		logger.Info("start to update certSANs of %s", master)
+		if err = k.writeRemoteFile(master, k3sConfigFile, data); err != nil {
+			return err
+		}
+		if err = k.sshCmdAsync(master, fmt.Sprintf(restartK3sCmd, k3sServerService)); err != nil {
+			return err
+		}
+		if err = kubernetes.NewKubeHealthy(cli.Kubernetes(), k3sAPIHealthyTimeout).ForAPI(); err != nil {
+			return fmt.Errorf("api-server is not healthy after restarting %s: %v", master, err)
+		}
+	}
+	return nil
+}
+
+// CheckCerts returns the expirations of the certs generated by k3s on the servers and agents.
+func (k *K3sRuntime) CheckCerts() ([]CertExpiration, error) {
+	hosts := append(k.getMasterIPAndPortList(), k.getNodeIPAndPortList()...)
+	results := make([][]CertExpiration, len(hosts))
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for i := range hosts {
+		i, host := i, hosts[i]
+		eg.Go(func() error {
+			dirs := []string{k3sAgentDir}
+			if k.isMaster(iputils.GetHostIP(host)) {
+				dirs = append([]string{k3sServerTLSDir}, dirs...)
+			}
+			for _, dir := range dirs {
+				expirations, err := k.checkK3sCerts(host, dir)
+				if err != nil {
+					return fmt.Errorf("failed to check certs of %s: %v", host, err)
+				}
+				results[i] = append(results[i], expirations...)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	var expirations []CertExpiration
+	for _, r := range results {
+		expirations = append(expirations, r...)
+	}
+	return expirations, nil
+}
+
+func (k *K3sRuntime) checkK3sCerts(host, dir string) ([]CertExpiration, error) {
+	out, err := k.getSSHInterface().Cmd(k.ctx, host, fmt.Sprintf(listK3sCertsCmd, dir))
+	if err != nil {
+		return nil, err
+	}
+	var expirations []CertExpiration
+	for _, f := range strings.Fields(string(out)) {
+		data, err := k.fetchRemoteFile(host, f)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+		e, err := cert.ParseExpiration(strings.TrimPrefix(f, k3sDataDir+"/"), data, nil)
+		if err != nil {
+			return nil, err
+		}
+		// k3s renews all the certs except the cas
+		e.Renewable = !e.IsCA
+		expirations = append(expirations, CertExpiration{Host: host, Expiration: *e})
+	}
+	return expirations, nil
+}
+
+// RenewCerts rotates the certs of the servers one by one by k3s, and the agents renew their certs by restarting.
+// The kubeconfig of admin is fetched again at last.
+func (k *K3sRuntime) RenewCerts() error {
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	for _, master := range k.getMasterIPAndPortList() {
+		logger.Info("start to rotate certs of %s", master)
+		if err = k.sshCmdAsync(master, rotateK3sCertsCmd, copyK3sKubeConfig); err != nil {
+			return fmt.Errorf("failed to rotate certs of %s: %v", master, err)
+		}
+		if err = kubernetes.NewKubeHealthy(cli.Kubernetes(), k3sAPIHealthyTimeout).ForAPI(); err != nil {
+			return fmt.Errorf("api-server is not healthy after rotating certs of %s: %v", master, err)
+		}
+	}
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, node := range k.getNodeIPAndPortList() {
+		node := node
+		eg.Go(func() error {
+			return k.sshCmdAsync(node, fmt.Sprintf(restartK3sCmd, k3sAgentService))
+		})
+	}
+	if err = eg.Wait(); err != nil {
+		return err
+	}
+	return k.fetchK3sKubeConfig()
+}
+
+// SaveEtcdSnapshot takes a snapshot of the embedded etcd on master0 and fetches it to the local file dst.
+func (k *K3sRuntime) SaveEtcdSnapshot(dst string) error {
+	master0 := k.getMaster0IPAndPort()
+	dir := path.Join(k.getContentData().BackupPath(), "etcd", fmt.Sprintf("k3s-%d", time.Now().Unix()))
+	logger.Info("start to save etcd snapshot on %s", master0)
+	name, err := k.sshCmdToString(master0, fmt.Sprintf(saveK3sSnapshotCmd, dir))
+	if err != nil {
+		return fmt.Errorf("failed to save etcd snapshot: %v", err)
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		return fmt.Errorf("etcd snapshot is not found in %s", dir)
+	}
+	defer func() {
+		if err := k.sshCmdAsync(master0, fmt.Sprintf("rm -rf %s", dir)); err != nil {
+			logger.Warn("failed to remove etcd snapshot %s on %s: %v", dir, master0, err)
+		}
+	}()
+	_ = os.Remove(dst)
+	return k.getSSHInterface().CopyR(k.ctx, master0, dst, path.Join(dir, name))
+}
+
+// RestoreEtcdSnapshot resets the embedded etcd of master0 from the local snapshot file src, the other servers
+// remove their etcd data and join master0 again.
+func (k *K3sRuntime) RestoreEtcdSnapshot(src string) error {
+	master0 := k.getMaster0IPAndPort()
+	masters := k.getMasterIPAndPortList()
+	snapshot := path.Join(k.getContentData().BackupPath(), "etcd-restore", path.Base(src))
+	if err := k.sshCopy(master0, src, snapshot); err != nil {
+		return fmt.Errorf("failed to send etcd snapshot: %v", err)
+	}
+	for _, master := range masters {
+		if err := k.sshCmdAsync(master, stopK3sCmd); err != nil {
+			return fmt.Errorf("failed to stop k3s on %s: %v", master, err)
+		}
+	}
+	logger.Info("start to restore etcd of master0 from snapshot")
+	if err := k.sshCmdAsync(master0, fmt.Sprintf(resetK3sClusterCmd, snapshot), startK3sCmd); err != nil {
+		return fmt.Errorf("failed to restore etcd snapshot: %v", err)
+	}
+	for _, master := range masters {
+		if master == master0 {
+			continue
+		}
+		logger.Info("start to rejoin %s", master)
+		if err := k.sshCmdAsync(master, removeK3sDBCmd, startK3sCmd); err != nil {
+			return fmt.Errorf("failed to rejoin %s: %v", master, err)
+		}
+	}
+	return k.pingAPIServer()
+}
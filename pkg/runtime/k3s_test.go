@@ -0,0 +1,120 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func newTestK3sCluster(distribution v2.Distribution, label string) *v2.Cluster {
+	cluster := &v2.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: v2.ClusterSpec{
+			Image: []string{"labring/k3s:v1.27.4-k3s1"},
+			Hosts: []v2.Host{
+				{IPS: []string{"192.168.0.2:22", "192.168.0.3:22"}, Roles: []string{v2.MASTER}},
+				{
+					IPS:    []string{"192.168.0.4:22"},
+					Roles:  []string{v2.NODE},
+					Labels: map[string]string{"pool": "edge", "node-role.kubernetes.io/edge": ""},
+					Taints: []v1.Taint{{Key: "edge", Value: "true", Effect: v1.TaintEffectNoSchedule}},
+				},
+			},
+			Distribution: distribution,
+		},
+	}
+	labels := map[string]string{v2.ImageKubeVersionKey: "v1.27.4"}
+	if label != "" {
+		labels[v2.ImageDistributionKey] = label
+	}
+	cluster.Status.Mounts = []v2.MountImage{{Name: "rootfs", Type: v2.RootfsImage, ImageName: cluster.Spec.Image[0], Labels: labels}}
+	return cluster
+}
+
+func TestNewDefaultRuntimeDistribution(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution v2.Distribution
+		label        string
+		wantK3s      bool
+		wantErr      bool
+	}{
+		{name: "default", wantK3s: false},
+		{name: "image label", label: "k3s", wantK3s: true},
+		{name: "clusterfile takes precedence", distribution: v2.KubeadmDistribution, label: "k3s", wantK3s: false},
+		{name: "clusterfile", distribution: v2.K3sDistribution, wantK3s: true},
+		{name: "unknown", distribution: "rke2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewDefaultRuntime(context.Background(), newTestK3sCluster(tt.distribution, tt.label), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDefaultRuntime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, ok := r.(*K3sRuntime); ok != tt.wantK3s {
+				t.Errorf("NewDefaultRuntime() = %T, want k3s %v", r, tt.wantK3s)
+			}
+		})
+	}
+}
+
+func TestNewK3sConfig(t *testing.T) {
+	kubeadm := &KubeadmConfig{}
+	kubeadm.ClusterConfiguration.Networking.PodSubnet = "100.64.0.0/10"
+	kubeadm.ClusterConfiguration.APIServer.CertSANs = []string{"k3s.example.com", "192.168.0.2"}
+	r, err := newK3sRuntime(context.Background(), newTestK3sCluster(v2.K3sDistribution, ""), kubeadm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := r.(*K3sRuntime)
+
+	got := k.newK3sConfig("192.168.0.2:22", true, "", "")
+	want := &k3sConfig{
+		ClusterInit: true,
+		TLSSan:      []string{DefaultAPIServerDomain, "192.168.0.2", "192.168.0.3", "k3s.example.com"},
+		ClusterCIDR: "100.64.0.0/10",
+		NodeIP:      "192.168.0.2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newK3sConfig() of master0 = %+v, want %+v", got, want)
+	}
+
+	got = k.newK3sConfig("192.168.0.3:22", true, k.getK3sServer(), "token")
+	if got.ClusterInit || got.Server != "https://192.168.0.2:6443" || got.Token != "token" {
+		t.Errorf("newK3sConfig() of joining master = %+v", got)
+	}
+
+	got = k.newK3sConfig("192.168.0.4:22", false, k.getK3sServer(), "token")
+	want = &k3sConfig{
+		Server:    "https://192.168.0.2:6443",
+		Token:     "token",
+		NodeIP:    "192.168.0.4",
+		NodeLabel: []string{"pool=edge"},
+		NodeTaint: []string{"edge=true:NoSchedule"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("newK3sConfig() of agent = %+v, want %+v", got, want)
+	}
+}
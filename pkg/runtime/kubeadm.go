@@ -481,6 +481,7 @@ func (k *KubeadmRuntime) ConvertInitConfigConversion(fns ...func(*KubeadmRuntime
 	if err := k.MergeKubeadmConfig(); err != nil {
 		return err
 	}
+	k.setExternalEtcd()
 	for _, fn := range fns {
 		if err := fn(k); err != nil {
 			return err
@@ -600,6 +601,7 @@ func (k *KubeadmRuntime) generateJoinNodeConfigs(node string) ([]byte, error) {
 	if err := k.setCGroupDriverAndSocket(node); err != nil {
 		return nil, err
 	}
+	k.setJoinNodeRegistration(node)
 	k.cleanJoinLocalAPIEndPoint()
 	k.setAPIServerEndpoint(k.getVipAndPort())
 	if err := k.convertKubeadmVersion(); err != nil {
@@ -15,11 +15,11 @@
 package runtime
 
 import (
-	"context"
 	"fmt"
 	"path"
 
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
 	"github.com/labring/sealos/pkg/ssh"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
@@ -49,7 +49,7 @@ func (k *KubeadmRuntime) InitMaster0() error {
 
 // sendJoinCPConfig send join CP masters configuration
 func (k *KubeadmRuntime) sendJoinCPConfig(joinMaster []string) error {
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, _ := errgroup.WithContext(k.ctx)
 	for _, master := range joinMaster {
 		master := master
 		eg.Go(func() error {
@@ -86,7 +86,7 @@ func (k *KubeadmRuntime) joinMasters(masters []string) error {
 	}
 	logger.Info("start to init filesystem join masters...")
 	var err error
-	if err = ssh.WaitSSHReady(k.getSSHInterface(), 6, masters...); err != nil {
+	if err = ssh.WaitSSHReady(k.ctx, k.getSSHInterface(), 6, masters...); err != nil {
 		return fmt.Errorf("join masters wait for ssh ready time out: %w", err)
 	}
 
@@ -115,34 +115,44 @@ func (k *KubeadmRuntime) joinMasters(masters []string) error {
 		return fmt.Errorf("get join master command failed, kubernetes version is %s", k.getKubeVersion())
 	}
 	for _, master := range masters {
-		logger.Info("start to join %s as master", master)
-		logger.Info("start to generator cert %s as master", master)
-		err = k.execCert(master)
+		step := events.StartStep(events.SourceRuntime, "JoinMaster", master)
+		err = k.joinMaster(master, cmd)
+		step.Finish(err)
 		if err != nil {
-			return fmt.Errorf("generator master %s cert failed %v", master, err)
+			return err
 		}
+	}
+	return nil
+}
 
-		err = k.execHostsAppend(master, k.getMaster0IP(), k.getAPIServerDomain())
-		if err != nil {
-			return fmt.Errorf("add master0 apiserver domain hosts to %s failed %v", master, err)
-		}
+func (k *KubeadmRuntime) joinMaster(master, cmd string) error {
+	logger.Info("start to join %s as master", master)
+	logger.Info("start to generator cert %s as master", master)
+	err := k.execCert(master)
+	if err != nil {
+		return fmt.Errorf("generator master %s cert failed %v", master, err)
+	}
 
-		err = k.sshCmdAsync(master, cmd)
-		if err != nil {
-			return fmt.Errorf("exec kubeadm join in %s failed %v", master, err)
-		}
+	err = k.execHostsAppend(master, k.getMaster0IP(), k.getAPIServerDomain())
+	if err != nil {
+		return fmt.Errorf("add master0 apiserver domain hosts to %s failed %v", master, err)
+	}
 
-		err = k.execHostsAppend(master, master, k.getAPIServerDomain())
-		if err != nil {
-			return fmt.Errorf("add master0 apiserver domain hosts in %s failed %v", master, err)
-		}
+	err = k.sshCmdAsync(master, cmd)
+	if err != nil {
+		return fmt.Errorf("exec kubeadm join in %s failed %v", master, err)
+	}
 
-		err = k.copyMasterKubeConfig(master)
-		if err != nil {
-			return err
-		}
-		logger.Info("succeeded in joining %s as master", master)
+	err = k.execHostsAppend(master, master, k.getAPIServerDomain())
+	if err != nil {
+		return fmt.Errorf("add master0 apiserver domain hosts in %s failed %v", master, err)
 	}
+
+	err = k.copyMasterKubeConfig(master)
+	if err != nil {
+		return err
+	}
+	logger.Info("succeeded in joining %s as master", master)
 	return nil
 }
 
@@ -154,23 +164,45 @@ func (k *KubeadmRuntime) deleteMasters(masters []string) error {
 	if len(masters) == 0 {
 		return nil
 	}
-	eg, _ := errgroup.WithContext(context.Background())
+	// masters are deleted one by one, so that the etcd quorum is checked against the latest members
 	for _, master := range masters {
-		master := master
-		eg.Go(func() error {
-			logger.Info("start to delete master %s", master)
-			if err := k.deleteMaster(master); err != nil {
-				logger.Error("delete master %s failed %v", master, err)
-			} else {
-				logger.Info("succeeded in deleting master %s", master)
-			}
-			return nil
-		})
+		logger.Info("start to delete master %s", master)
+		step := events.StartStep(events.SourceRuntime, "DeleteMaster", master)
+		err := k.deleteMaster(master)
+		step.Finish(err)
+		if err != nil {
+			return fmt.Errorf("delete master %s failed %v", master, err)
+		}
+		logger.Info("succeeded in deleting master %s", master)
 	}
-	return eg.Wait()
+	return nil
 }
 
 func (k *KubeadmRuntime) deleteMaster(master string) error {
+	executor := k.getMaster0IPAndPort()
+	if master == executor {
+		return fmt.Errorf("master0 can not be deleted")
+	}
+	var member *etcdMember
+	// there is no etcd member on the masters if etcd is external
+	if !k.Cluster.IsExternalEtcd() {
+		m, err := k.checkEtcdMemberRemovable(executor, master)
+		if err != nil {
+			return err
+		}
+		member = m
+	}
+	if err := k.drainNodeBeforeDelete(master); err != nil {
+		if !IgnoreDrainErrors {
+			return fmt.Errorf("%v, use --ignore-drain-errors to delete it anyway", err)
+		}
+		logger.Warn("failed to drain master %s, delete it by force: %v", master, err)
+	}
+	if member != nil {
+		if err := k.removeEtcdMember(executor, member); err != nil {
+			return err
+		}
+	}
 	return k.resetNode(master, func() {
 		//remove master
 		masterIPs := strings.SliceRemoveStr(k.getMasterIPList(), master)
@@ -15,21 +15,28 @@
 package runtime
 
 import (
-	"context"
 	"fmt"
 	"path"
+	"time"
 
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
 	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/system"
 	"github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/logger"
 
 	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// JoinWaveTimeout is the timeout of waiting for the nodes of a wave to register before joining the next wave.
+var JoinWaveTimeout = 5 * time.Minute
+
 func (k *KubeadmRuntime) joinNodes(newNodesIPList []string) error {
 	var err error
-	if err = ssh.WaitSSHReady(k.getSSHInterface(), 6, newNodesIPList...); err != nil {
+	if err = ssh.WaitSSHReady(k.ctx, k.getSSHInterface(), 6, newNodesIPList...); err != nil {
 		return fmt.Errorf("join nodes wait for ssh ready time out: %w", err)
 	}
 
@@ -40,48 +47,99 @@ func (k *KubeadmRuntime) joinNodes(newNodesIPList []string) error {
 	if err = k.fetchKubeadmConfig(); err != nil {
 		return err
 	}
-	eg, _ := errgroup.WithContext(context.Background())
-	for _, node := range newNodesIPList {
-		node := node
-		eg.Go(func() error {
-			logger.Info("start to join %s as worker", node)
-			k.Lock()
-			err = k.ConfigJoinNodeKubeadmToNode(node)
-			if err != nil {
-				return fmt.Errorf("failed to copy join node kubeadm config %s %v", node, err)
-			}
-			k.Unlock()
-			err = k.execHostsAppend(node, k.getVip(), k.getAPIServerDomain())
-			if err != nil {
-				return fmt.Errorf("add apiserver domain hosts failed %v", err)
-			}
-			err = k.execHostsAppend(node, node, constants.DefaultLvscareDomain)
-			if err != nil {
-				return fmt.Errorf("add lvscare domain hosts failed %v", err)
+	// join the nodes in waves so that a large scale-out does not overwhelm the control plane
+	waves := splitBatches(newNodesIPList, system.GetParallelism())
+	for i, wave := range waves {
+		if len(waves) > 1 {
+			logger.Info("start to join wave %d/%d of nodes: %v", i+1, len(waves), wave)
+		}
+		eg, _ := errgroup.WithContext(k.ctx)
+		for _, node := range wave {
+			node := node
+			eg.Go(func() error {
+				step := events.StartStep(events.SourceRuntime, "JoinNode", node)
+				err := k.joinNode(node, masters)
+				step.Finish(err)
+				return err
+			})
+		}
+		if err = eg.Wait(); err != nil {
+			return err
+		}
+		if i < len(waves)-1 {
+			if err = k.waitNodesRegistered(wave); err != nil {
+				return fmt.Errorf("nodes of wave %d are not healthy, stop joining the rest: %v", i+1, err)
 			}
-			logger.Info("run ipvs once module: %s", node)
-			err = k.execIPVS(node, masters)
+		}
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) joinNode(node string, masters []string) error {
+	logger.Info("start to join %s as worker", node)
+	k.Lock()
+	err := k.ConfigJoinNodeKubeadmToNode(node)
+	k.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to copy join node kubeadm config %s %v", node, err)
+	}
+	err = k.execHostsAppend(node, k.getVip(), k.getAPIServerDomain())
+	if err != nil {
+		return fmt.Errorf("add apiserver domain hosts failed %v", err)
+	}
+	err = k.execHostsAppend(node, node, constants.DefaultLvscareDomain)
+	if err != nil {
+		return fmt.Errorf("add lvscare domain hosts failed %v", err)
+	}
+	logger.Info("run ipvs once module: %s", node)
+	err = k.execIPVS(node, masters)
+	if err != nil {
+		return fmt.Errorf("run ipvs once failed %v", err)
+	}
+	logger.Info("start join node: %s", node)
+	cmd := k.Command(k.getKubeVersion(), JoinNode)
+	if cmd == "" {
+		return fmt.Errorf("get join node command failed, kubernetes version is %s", k.getKubeVersion())
+	}
+	if err = k.sshCmdAsync(node, cmd); err != nil {
+		return fmt.Errorf("failed to join node %s %v", node, err)
+	}
+	//logger.Info("sync ipvs yaml in node: %s", node)
+	//err = k.execIPVSPod(node, masters)
+	//if err != nil {
+	//	return fmt.Errorf("generator ipvs static pod failed %v", err)
+	//}
+	if err = k.copyNodeKubeConfig([]string{node}); err != nil {
+		return fmt.Errorf("failed to copy kubeconfig to node %s %v", node, err)
+	}
+	logger.Info("succeeded in joining %s as worker", node)
+	return nil
+}
+
+// waitNodesRegistered waits for the apiserver to be healthy and the kubelet of the nodes to register themselves.
+// The readiness of nodes is not checked, since the CNI might not be installed yet.
+func (k *KubeadmRuntime) waitNodesRegistered(nodes []string) error {
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	if err = kubernetes.NewKubeHealthy(cli.Kubernetes(), JoinWaveTimeout).ForAPI(); err != nil {
+		return fmt.Errorf("apiserver is not healthy: %v", err)
+	}
+	for _, node := range nodes {
+		err = wait.PollImmediate(kubernetes.APICallRetryInterval, JoinWaveTimeout, func() (bool, error) {
+			name, err := getNodeNameByIP(k.ctx, cli, node)
 			if err != nil {
-				return fmt.Errorf("run ipvs once failed %v", err)
-			}
-			logger.Info("start join node: %s", node)
-			cmd := k.Command(k.getKubeVersion(), JoinNode)
-			if cmd == "" {
-				return fmt.Errorf("get join node command failed, kubernetes version is %s", k.getKubeVersion())
+				logger.Debug("failed to get node %s: %v", node, err)
+				return false, nil
 			}
-			if err = k.sshCmdAsync(node, cmd); err != nil {
-				return fmt.Errorf("failed to join node %s %v", node, err)
-			}
-			//logger.Info("sync ipvs yaml in node: %s", node)
-			//err = k.execIPVSPod(node, masters)
-			//if err != nil {
-			//	return fmt.Errorf("generator ipvs static pod failed %v", err)
-			//}
-			logger.Info("succeeded in joining %s as worker", node)
-			return nil
+			return name != "", nil
 		})
+		if err != nil {
+			return fmt.Errorf("node %s is not registered in %s", node, JoinWaveTimeout)
+		}
 	}
-	return eg.Wait()
+	return nil
 }
 
 func (k *KubeadmRuntime) ConfigJoinNodeKubeadmToNode(node string) error {
@@ -106,12 +164,16 @@ func (k *KubeadmRuntime) deleteNodes(nodes []string) error {
 	if len(nodes) == 0 {
 		return nil
 	}
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
 	for _, node := range nodes {
 		node := node
 		eg.Go(func() error {
 			logger.Info("start to delete worker %s", node)
-			if err := k.deleteNode(node); err != nil {
+			step := events.StartStep(events.SourceRuntime, "DeleteNode", node)
+			err := k.deleteNode(node)
+			step.Finish(err)
+			if err != nil {
 				return fmt.Errorf("delete node %s failed %v", node, err)
 			}
 			logger.Info("succeeded in deleting worker %s", node)
@@ -122,6 +184,12 @@ func (k *KubeadmRuntime) deleteNodes(nodes []string) error {
 }
 
 func (k *KubeadmRuntime) deleteNode(node string) error {
+	if err := k.drainNodeBeforeDelete(node); err != nil {
+		if !IgnoreDrainErrors {
+			return fmt.Errorf("%v, use --ignore-drain-errors to delete it anyway", err)
+		}
+		logger.Warn("failed to drain node %s, delete it by force: %v", node, err)
+	}
 	return k.resetNode(node, func() {
 		//remove node
 		if len(k.getMasterIPList()) > 0 {
@@ -0,0 +1,265 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	v1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/system"
+	"github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	// the labels and taints set by the last sync, so that the ones no longer declared can be removed
+	annotationManagedLabels = "node.sealos.io/managed-labels"
+	annotationManagedTaints = "node.sealos.io/managed-taints"
+
+	kubeletNodeLabelsArg = "node-labels"
+	kubeletConfigFile    = "/var/lib/kubelet/config.yaml"
+)
+
+// setJoinNodeRegistration sets the labels and taints of the host group into the join configuration of the node,
+// the labels which kubelet is not allowed to set on itself are left to SyncNodeConfigs.
+func (k *KubeadmRuntime) setJoinNodeRegistration(node string) {
+	var (
+		taints     []v1.Taint
+		nodeLabels []string
+	)
+	if c := k.Config.ClusterFileKubeConfig; c != nil {
+		taints = c.JoinConfiguration.NodeRegistration.Taints
+		if labels := c.JoinConfiguration.NodeRegistration.KubeletExtraArgs[kubeletNodeLabelsArg]; labels != "" {
+			nodeLabels = append(nodeLabels, labels)
+		}
+	}
+	if host := k.Cluster.GetHostByIP(node); host != nil {
+		if len(host.Taints) > 0 {
+			taints = append(append([]v1.Taint{}, taints...), host.Taints...)
+		}
+		for _, key := range sets.StringKeySet(host.Labels).List() {
+			if isKubeletLabel(key) {
+				nodeLabels = append(nodeLabels, fmt.Sprintf("%s=%s", key, host.Labels[key]))
+			}
+		}
+	}
+
+	registration := &k.JoinConfiguration.NodeRegistration
+	registration.Taints = taints
+	// never modify the map in place, it might be shared with the kubeadm config of Clusterfile
+	args := make(map[string]string, len(registration.KubeletExtraArgs)+1)
+	for key, value := range registration.KubeletExtraArgs {
+		args[key] = value
+	}
+	if len(nodeLabels) > 0 {
+		args[kubeletNodeLabelsArg] = strings.Join(nodeLabels, ",")
+	} else {
+		delete(args, kubeletNodeLabelsArg)
+	}
+	registration.KubeletExtraArgs = args
+}
+
+// isKubeletLabel returns whether kubelet is allowed to set the label on its node, the labels in the namespaces
+// of kubernetes.io and k8s.io are restricted except for kubelet.kubernetes.io and node.kubernetes.io.
+func isKubeletLabel(key string) bool {
+	idx := strings.Index(key, "/")
+	if idx < 0 {
+		return true
+	}
+	namespace := key[:idx]
+	inNamespace := func(ns string) bool {
+		return namespace == ns || strings.HasSuffix(namespace, "."+ns)
+	}
+	if inNamespace("kubelet.kubernetes.io") || inNamespace("node.kubernetes.io") {
+		return true
+	}
+	return !inNamespace("kubernetes.io") && !inNamespace("k8s.io")
+}
+
+// SyncNodeConfigs applies the labels, taints and kubelet config declared in the host groups to the nodes of the hosts.
+func (k *KubeadmRuntime) SyncNodeConfigs(hosts []string) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	eg, _ := errgroup.WithContext(k.ctx)
+	eg.SetLimit(system.GetParallelism())
+	for _, host := range hosts {
+		host := host
+		eg.Go(func() error {
+			step := events.StartStep(events.SourceRuntime, "SyncNodeConfig", host)
+			err := k.syncNodeConfig(cli, host)
+			step.Finish(err)
+			if err != nil {
+				return fmt.Errorf("failed to sync config of node %s: %v", host, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (k *KubeadmRuntime) syncNodeConfig(cli kubernetes.Client, host string) error {
+	h := k.Cluster.GetHostByIP(host)
+	if h == nil {
+		return nil
+	}
+	if err := k.patchKubeletConfig(host, h.KubeletConfig); err != nil {
+		return err
+	}
+	name, err := getNodeNameByIP(k.ctx, cli, host)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("node of %s is not found", host)
+	}
+	logger.Debug("sync labels %v and taints %v of node %s", h.Labels, h.Taints, name)
+	return kubernetes.NewKubeIdempotency(cli.Kubernetes()).PatchNode(name, func(n *v1.Node) {
+		setNodeLabelsAndTaints(n, h.Labels, h.Taints)
+	})
+}
+
+// setNodeLabelsAndTaints sets the labels and taints on the node, the ones set by the last sync but
+// no longer declared are removed, others which are not managed by sealos are kept.
+func setNodeLabelsAndTaints(node *v1.Node, labels map[string]string, taints []v1.Taint) {
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+
+	for _, key := range strings.Split(node.Annotations[annotationManagedLabels], ",") {
+		if _, ok := labels[key]; key != "" && !ok {
+			delete(node.Labels, key)
+		}
+	}
+	for key, value := range labels {
+		node.Labels[key] = value
+	}
+	setManagedAnnotation(node, annotationManagedLabels, sets.StringKeySet(labels))
+
+	managed := sets.NewString(strings.Split(node.Annotations[annotationManagedTaints], ",")...)
+	declared := sets.NewString()
+	for _, t := range taints {
+		declared.Insert(taintKey(t))
+	}
+	var result []v1.Taint
+	for _, t := range node.Spec.Taints {
+		// the declared taints are appended below, which might have a different value
+		if key := taintKey(t); !declared.Has(key) && !managed.Has(key) {
+			result = append(result, t)
+		}
+	}
+	node.Spec.Taints = append(result, taints...)
+	setManagedAnnotation(node, annotationManagedTaints, declared)
+}
+
+// taintKey identifies a taint on a node, which is unique by key and effect.
+func taintKey(t v1.Taint) string {
+	return fmt.Sprintf("%s:%s", t.Key, t.Effect)
+}
+
+func setManagedAnnotation(node *v1.Node, annotation string, keys sets.String) {
+	if keys.Len() == 0 {
+		delete(node.Annotations, annotation)
+		return
+	}
+	node.Annotations[annotation] = strings.Join(keys.List(), ",")
+}
+
+// patchKubeletConfig merges the patch into the kubelet config of the host, kubelet is restarted if anything changed.
+func (k *KubeadmRuntime) patchKubeletConfig(host string, patch *k8sruntime.RawExtension) error {
+	if patch == nil || len(patch.Raw) == 0 {
+		return nil
+	}
+	localPath := path.Join(k.getContentData().TmpPath(), "kubelet", iputils.GetHostIP(host), path.Base(kubeletConfigFile))
+	if err := file.MkDirs(path.Dir(localPath)); err != nil {
+		return err
+	}
+	// the config fetched by the last sync is overwritten
+	if err := os.RemoveAll(localPath); err != nil {
+		return err
+	}
+	if err := k.getSSHInterface().CopyR(k.ctx, host, localPath, kubeletConfigFile); err != nil {
+		return fmt.Errorf("failed to fetch kubelet config: %v", err)
+	}
+	original, err := os.ReadFile(localPath)
+	if err != nil {
+		return err
+	}
+	patched, changed, err := mergeKubeletConfig(original, patch.Raw)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		logger.Debug("kubelet config of %s is up to date", host)
+		return nil
+	}
+	if err = file.WriteFile(localPath, patched); err != nil {
+		return err
+	}
+	logger.Info("start to update kubelet config of %s", host)
+	if err = k.sshCopy(host, localPath, kubeletConfigFile); err != nil {
+		return fmt.Errorf("failed to copy kubelet config: %v", err)
+	}
+	return k.sshCmdAsync(host, restartKubeletCmd)
+}
+
+// mergeKubeletConfig merges the json patch into the kubelet config in yaml, and returns whether anything changed.
+func mergeKubeletConfig(original, patch []byte) ([]byte, bool, error) {
+	originalJSON, err := yaml.YAMLToJSON(original)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse kubelet config: %v", err)
+	}
+	patchedJSON, err := strategicpatch.StrategicMergePatch(originalJSON, patch, kubeletconfigv1beta1.KubeletConfiguration{})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to patch kubelet config: %v", err)
+	}
+	var before, after interface{}
+	if err = json.Unmarshal(originalJSON, &before); err != nil {
+		return nil, false, err
+	}
+	if err = json.Unmarshal(patchedJSON, &after); err != nil {
+		return nil, false, err
+	}
+	if reflect.DeepEqual(before, after) {
+		return original, false, nil
+	}
+	patched, err := yaml.JSONToYAML(patchedJSON)
+	if err != nil {
+		return nil, false, err
+	}
+	return patched, true, nil
+}
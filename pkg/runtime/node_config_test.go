@@ -0,0 +1,96 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsKubeletLabel(t *testing.T) {
+	tests := map[string]bool{
+		"gpu":                                true,
+		"example.com/pool":                   true,
+		"node.kubernetes.io/instance-type":   true,
+		"foo.kubelet.kubernetes.io/bar":      true,
+		"node-role.kubernetes.io/worker":     false,
+		"kubernetes.io/role":                 false,
+		"topology.k8s.io/zone":               false,
+		"my-kubernetes.io/not-in-the-domain": true,
+	}
+	for key, want := range tests {
+		if got := isKubeletLabel(key); got != want {
+			t.Errorf("isKubeletLabel(%s) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestSetNodeLabelsAndTaints(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"kubernetes.io/hostname": "node1", "pool": "cpu", "zone": "a"},
+			Annotations: map[string]string{
+				annotationManagedLabels: "pool,zone",
+				annotationManagedTaints: "dedicated:NoSchedule",
+			},
+		},
+		Spec: v1.NodeSpec{Taints: []v1.Taint{
+			{Key: "dedicated", Value: "cpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "node.kubernetes.io/not-ready", Effect: v1.TaintEffectNoSchedule},
+		}},
+	}
+	setNodeLabelsAndTaints(node, map[string]string{"pool": "gpu"}, []v1.Taint{{Key: "nvidia.com/gpu", Effect: v1.TaintEffectNoSchedule}})
+
+	wantLabels := map[string]string{"kubernetes.io/hostname": "node1", "pool": "gpu"}
+	if !reflect.DeepEqual(node.Labels, wantLabels) {
+		t.Errorf("labels = %v, want %v", node.Labels, wantLabels)
+	}
+	wantTaints := []v1.Taint{
+		{Key: "node.kubernetes.io/not-ready", Effect: v1.TaintEffectNoSchedule},
+		{Key: "nvidia.com/gpu", Effect: v1.TaintEffectNoSchedule},
+	}
+	if !reflect.DeepEqual(node.Spec.Taints, wantTaints) {
+		t.Errorf("taints = %v, want %v", node.Spec.Taints, wantTaints)
+	}
+	if got := node.Annotations[annotationManagedTaints]; got != "nvidia.com/gpu:NoSchedule" {
+		t.Errorf("managed taints = %s", got)
+	}
+
+	setNodeLabelsAndTaints(node, nil, nil)
+	if _, ok := node.Labels["pool"]; ok {
+		t.Errorf("label pool is not removed")
+	}
+	if len(node.Spec.Taints) != 1 || len(node.Annotations) != 0 {
+		t.Errorf("taints = %v, annotations = %v", node.Spec.Taints, node.Annotations)
+	}
+}
+
+func TestMergeKubeletConfig(t *testing.T) {
+	original := []byte("apiVersion: kubelet.config.k8s.io/v1beta1\nkind: KubeletConfiguration\nmaxPods: 110\n")
+	patched, changed, err := mergeKubeletConfig(original, []byte(`{"maxPods":250,"systemReserved":{"cpu":"500m"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || !strings.Contains(string(patched), "maxPods: 250") || !strings.Contains(string(patched), "cpu: 500m") {
+		t.Errorf("mergeKubeletConfig() = %s, %v", patched, changed)
+	}
+	if _, changed, err = mergeKubeletConfig(patched, []byte(`{"maxPods":250}`)); err != nil || changed {
+		t.Errorf("mergeKubeletConfig() changed = %v, err = %v, want unchanged", changed, err)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kubectl/pkg/drain"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/ssh"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	strutil "github.com/labring/sealos/pkg/utils/strings"
+)
+
+var (
+	// RebootTimeout is the timeout of waiting for a host to come back and its node to be ready after rebooting.
+	RebootTimeout = 10 * time.Minute
+	// SkipDrain only cordons the nodes before rebooting, the pods are not evicted.
+	SkipDrain bool
+)
+
+const (
+	bootIDCmd = "cat /proc/sys/kernel/random/boot_id"
+	// reboot in background, so that the ssh session is able to exit before the host goes down
+	rebootCmd = "nohup sh -c 'sleep 2 && systemctl reboot' >/dev/null 2>&1 &"
+
+	rebootPollingInterval = 5 * time.Second
+)
+
+// CordonNodes marks the nodes of the hosts as unschedulable.
+func (k *KubeadmRuntime) CordonNodes(hosts []string) error {
+	return k.cordonNodes(hosts, true)
+}
+
+// UncordonNodes marks the nodes of the hosts as schedulable.
+func (k *KubeadmRuntime) UncordonNodes(hosts []string) error {
+	return k.cordonNodes(hosts, false)
+}
+
+func (k *KubeadmRuntime) cordonNodes(hosts []string, unschedulable bool) error {
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		nodeName, err := getNodeNameByIP(k.ctx, cli, host)
+		if err != nil {
+			return err
+		}
+		if nodeName == "" {
+			return fmt.Errorf("node of %s is not found", host)
+		}
+		if err = k.setNodeUnschedulable(cli, nodeName, unschedulable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KubeadmRuntime) setNodeUnschedulable(cli kubernetes.Client, nodeName string, unschedulable bool) error {
+	node, err := cli.Kubernetes().CoreV1().Nodes().Get(k.ctx, nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	action := "uncordon"
+	if unschedulable {
+		action = "cordon"
+	}
+	if err = drain.RunCordonOrUncordon(newDrainHelper(k.ctx, cli, DrainTimeout, false, DeleteEmptyDirData), node, unschedulable); err != nil {
+		return fmt.Errorf("failed to %s node %s: %v", action, nodeName, err)
+	}
+	logger.Info("succeeded in %sing node %s", action, nodeName)
+	return nil
+}
+
+// RebootNodes reboots the hosts in batches: the masters are rebooted one by one to keep the etcd quorum,
+// and then the other nodes at most maxUnavailable at a time. A node is drained before rebooting and
+// uncordoned after it is ready again unless it was cordoned before, the rollout stops at the first batch which fails.
+func (k *KubeadmRuntime) RebootNodes(hosts []string, maxUnavailable int) error {
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+	addrs, err := iputils.ListLocalHostAddrs()
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		if ip := iputils.GetHostIP(host); iputils.IsLocalIP(ip, addrs) {
+			return fmt.Errorf("%s is the host running sealos, reboot it manually", ip)
+		}
+	}
+	batches := rebootBatches(hosts, k.getMasterIPList(), maxUnavailable)
+	for i, batch := range batches {
+		logger.Info("start to reboot batch %d/%d: %v", i+1, len(batches), batch)
+		if err = k.rebootBatch(batch); err != nil {
+			return fmt.Errorf("failed to reboot %v, the batches after it are untouched: %v", batch, err)
+		}
+	}
+	return nil
+}
+
+// rebootBatches splits the hosts into batches, each master is in a batch of its own and comes first.
+func rebootBatches(hosts, masterIPs []string, maxUnavailable int) [][]string {
+	var masters, nodes []string
+	for _, host := range hosts {
+		if strutil.In(iputils.GetHostIP(host), masterIPs) {
+			masters = append(masters, host)
+		} else {
+			nodes = append(nodes, host)
+		}
+	}
+	return append(splitBatches(masters, 1), splitBatches(nodes, maxUnavailable)...)
+}
+
+func (k *KubeadmRuntime) rebootBatch(hosts []string) error {
+	cli, err := k.newClientExcluding(hosts)
+	if err != nil {
+		return err
+	}
+	eg, _ := errgroup.WithContext(k.ctx)
+	for _, host := range hosts {
+		host := host
+		eg.Go(func() error {
+			step := events.StartStep(events.SourceRuntime, "RebootNode", host)
+			err := k.rebootNode(cli, host)
+			step.Finish(err)
+			if err != nil {
+				return fmt.Errorf("host %s: %v", host, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// newClientExcluding returns the client of the api-server on the first master which is not one of the hosts,
+// so that the api-server keeps available while the hosts are rebooting.
+func (k *KubeadmRuntime) newClientExcluding(hosts []string) (kubernetes.Client, error) {
+	apiServer := k.getMaster0IPAPIServer()
+	excluded := iputils.GetHostIPs(hosts)
+	for _, master := range k.getMasterIPList() {
+		if !strutil.In(master, excluded) {
+			apiServer = fmt.Sprintf("https://%s:%d", master, k.getAPIServerPort())
+			break
+		}
+	}
+	return kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), apiServer)
+}
+
+func (k *KubeadmRuntime) rebootNode(cli kubernetes.Client, host string) error {
+	nodeName, err := getNodeNameByIP(k.ctx, cli, host)
+	if err != nil {
+		return err
+	}
+	if nodeName == "" {
+		return fmt.Errorf("node is not found")
+	}
+	node, err := cli.Kubernetes().CoreV1().Nodes().Get(k.ctx, nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	// a node cordoned before rebooting, e.g. for investigation, is kept cordoned
+	wasUnschedulable := node.Spec.Unschedulable
+	if err = k.setNodeUnschedulable(cli, nodeName, true); err != nil {
+		return err
+	}
+	if !SkipDrain {
+		logger.Info("drain node %s", nodeName)
+		if err = drain.RunNodeDrain(newDrainHelper(k.ctx, cli, DrainTimeout, false, DeleteEmptyDirData), nodeName); err != nil {
+			return fmt.Errorf("failed to drain node %s: %v", nodeName, err)
+		}
+	}
+	bootID, err := k.sshCmdToString(host, bootIDCmd)
+	if err != nil {
+		return fmt.Errorf("failed to get boot id: %v", err)
+	}
+	logger.Info("reboot host %s", host)
+	if err = k.sshCmdAsync(host, rebootCmd); err != nil {
+		return err
+	}
+	newBootID, err := k.waitHostRebooted(host, strings.TrimSpace(bootID))
+	if err != nil {
+		return err
+	}
+	if err = k.waitNodeRebooted(cli, nodeName, newBootID); err != nil {
+		return err
+	}
+	if !strutil.In(iputils.GetHostIP(host), k.getMasterIPList()) {
+		if err = k.syncNodeIPVSYaml(k.getMasterIPAndPortList(), []string{host}); err != nil {
+			return err
+		}
+	}
+	if wasUnschedulable {
+		logger.Info("node %s was cordoned before rebooting, keep it cordoned", nodeName)
+		return nil
+	}
+	// the node is left cordoned if anything above failed, so that it can be checked before serving again
+	return k.setNodeUnschedulable(cli, nodeName, false)
+}
+
+// waitHostRebooted waits for the host to be reachable by ssh with a new boot id, and returns the new boot id.
+func (k *KubeadmRuntime) waitHostRebooted(host, bootID string) (string, error) {
+	var current string
+	// the host is polled until timeout, so the ssh operations are not retried by themselves
+	execer := ssh.WithRetryPolicy(k.getSSHInterface(), ssh.NoRetry)
+	err := wait.PollImmediate(rebootPollingInterval, RebootTimeout, func() (bool, error) {
+		if err := ssh.WaitSSHReady(k.ctx, execer, 1, host); err != nil {
+			logger.Debug("host %s is not reachable: %v", host, err)
+			return false, nil
+		}
+		id, err := execer.CmdToString(k.ctx, host, bootIDCmd, "")
+		if err != nil {
+			logger.Debug("failed to get boot id of %s: %v", host, err)
+			return false, nil
+		}
+		current = strings.TrimSpace(id)
+		return current != "" && current != bootID, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("host is not rebooted in %s", RebootTimeout)
+	}
+	return current, nil
+}
+
+// waitNodeRebooted waits for the node to be ready, the boot id reported by kubelet makes sure that
+// the ready condition is not the stale one before rebooting.
+func (k *KubeadmRuntime) waitNodeRebooted(cli kubernetes.Client, nodeName, bootID string) error {
+	logger.Info("wait for node %s to be ready", nodeName)
+	err := wait.PollImmediate(rebootPollingInterval, RebootTimeout, func() (bool, error) {
+		node, err := cli.Kubernetes().CoreV1().Nodes().Get(k.ctx, nodeName, metaV1.GetOptions{})
+		if err != nil {
+			logger.Debug("failed to get node %s: %v", nodeName, err)
+			return false, nil
+		}
+		return node.Status.NodeInfo.BootID == bootID && isNodeReady(node), nil
+	})
+	if err != nil {
+		return fmt.Errorf("node %s is not ready in %s after rebooting", nodeName, RebootTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRebootBatches(t *testing.T) {
+	masterIPs := []string{"192.168.0.2", "192.168.0.3"}
+	tests := []struct {
+		name           string
+		hosts          []string
+		maxUnavailable int
+		want           [][]string
+	}{
+		{
+			name:           "masters one by one before nodes",
+			hosts:          []string{"192.168.0.4:22", "192.168.0.2:22", "192.168.0.5:22", "192.168.0.3:22", "192.168.0.6:22"},
+			maxUnavailable: 2,
+			want: [][]string{
+				{"192.168.0.2:22"}, {"192.168.0.3:22"},
+				{"192.168.0.4:22", "192.168.0.5:22"}, {"192.168.0.6:22"},
+			},
+		},
+		{
+			name:           "nodes only",
+			hosts:          []string{"192.168.0.4:22", "192.168.0.5:22", "192.168.0.6:22"},
+			maxUnavailable: 3,
+			want:           [][]string{{"192.168.0.4:22", "192.168.0.5:22", "192.168.0.6:22"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rebootBatches(tt.hosts, masterIPs, tt.maxUnavailable); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rebootBatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
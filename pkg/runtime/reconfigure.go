@@ -0,0 +1,404 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+	"github.com/labring/sealos/pkg/utils/yaml"
+)
+
+// ReconfigureTimeout is the timeout of waiting for the control plane of a master to be restarted and healthy.
+var ReconfigureTimeout = 5 * time.Minute
+
+const (
+	reconfigureKubeadmFileName = "kubeadm-reconfigure.yaml"
+	auditPolicyFileArg         = "audit-policy-file"
+
+	controlPlanePhaseCmd        = "kubeadm init phase control-plane all --config=%s"
+	backupManifestsCmd          = "mkdir -p %[1]s && cp -f %[2]s/kube-*.yaml %[1]s/"
+	restoreManifestsCmd         = "cp -f %s/kube-*.yaml %s/"
+	manifestChecksumCmd         = "md5sum %s | cut -d' ' -f1"
+	runningContainerIDCmd       = "crictl ps --name '^%s$' --state running -q | head -n 1"
+	stopContainerCmd            = "crictl --timeout=10s stop %s"
+	controlPlanePollingInterval = 2 * time.Second
+)
+
+var (
+	// the fields of ClusterConfiguration which are able to be changed on a running cluster
+	reconfigurableFields = [][]string{
+		{"featureGates"},
+		{"apiServer", "certSANs"},
+		{"apiServer", "extraArgs"},
+		{"apiServer", "extraVolumes"},
+		{"controllerManager", "extraArgs"},
+		{"controllerManager", "extraVolumes"},
+		{"scheduler", "extraArgs"},
+		{"scheduler", "extraVolumes"},
+	}
+	// the fields which can't be changed without recreating the cluster
+	immutableFields = [][]string{
+		{"networking", "podSubnet"},
+		{"networking", "serviceSubnet"},
+		{"networking", "dnsDomain"},
+	}
+)
+
+// ConfigChange is a change of the ClusterConfiguration made by reconfiguring the control plane.
+type ConfigChange struct {
+	Path    string      `json:"path"`
+	Current interface{} `json:"current,omitempty"`
+	Desired interface{} `json:"desired,omitempty"`
+}
+
+// DiffClusterConfig returns the changes between the ClusterConfiguration in the kubeadm-config ConfigMap
+// and the one merged from the kubeadm config of the runtime.
+func (k *KubeadmRuntime) DiffClusterConfig() ([]ConfigChange, error) {
+	_, changes, err := k.getClusterConfigChanges()
+	return changes, err
+}
+
+func (k *KubeadmRuntime) getClusterConfigChanges() (map[string]interface{}, []ConfigChange, error) {
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := kubernetes.GetKubeadmConfig(cli.Kubernetes())
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := yaml.UnmarshalData([]byte(data.Data[ClusterConfiguration]))
+	if err != nil {
+		return nil, nil, err
+	}
+	if current, err = toJSONObject(current); err != nil {
+		return nil, nil, err
+	}
+	if err = k.ConvertInitConfigConversion(setCGroupDriverAndSocket); err != nil {
+		return nil, nil, err
+	}
+	desired, err := toJSONObject(k.conversion.ClusterConfiguration)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := diffClusterConfig(current, desired)
+	return current, changes, err
+}
+
+// toJSONObject converts the object into a map with the same value types as decoding it from json.
+func toJSONObject(obj interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]interface{})
+	if err = json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func diffClusterConfig(current, desired map[string]interface{}) ([]ConfigChange, error) {
+	for _, fields := range immutableFields {
+		c, _, _ := unstructured.NestedFieldNoCopy(current, fields...)
+		d, _, _ := unstructured.NestedFieldNoCopy(desired, fields...)
+		if !isEmptyValue(d) && !reflect.DeepEqual(c, d) {
+			return nil, fmt.Errorf("%s can't be changed from %v to %v in place, the cluster has to be recreated", strings.Join(fields, "."), c, d)
+		}
+	}
+	var changes []ConfigChange
+	for _, fields := range reconfigurableFields {
+		c, _, _ := unstructured.NestedFieldNoCopy(current, fields...)
+		d, _, _ := unstructured.NestedFieldNoCopy(desired, fields...)
+		if fields[len(fields)-1] == "certSANs" {
+			// the sans added by `sealos cert` are kept
+			d = mergeCertSANs(c, d)
+		}
+		if (isEmptyValue(c) && isEmptyValue(d)) || reflect.DeepEqual(c, d) {
+			continue
+		}
+		changes = append(changes, ConfigChange{Path: strings.Join(fields, "."), Current: c, Desired: d})
+	}
+	return changes, nil
+}
+
+func mergeCertSANs(current, desired interface{}) interface{} {
+	c, _ := current.([]interface{})
+	d, _ := desired.([]interface{})
+	merged := append([]interface{}{}, c...)
+	for _, san := range d {
+		found := false
+		for _, existing := range c {
+			if existing == san {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, san)
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+func isEmptyValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch val := reflect.ValueOf(v); val.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return val.Len() == 0
+	}
+	return false
+}
+
+// applyConfigChanges applies the changes into the ClusterConfiguration.
+func applyConfigChanges(obj map[string]interface{}, changes []ConfigChange) error {
+	for _, change := range changes {
+		fields := strings.Split(change.Path, ".")
+		if isEmptyValue(change.Desired) {
+			unstructured.RemoveNestedField(obj, fields...)
+			continue
+		}
+		if err := unstructured.SetNestedField(obj, deepCopyJSON(change.Desired), fields...); err != nil {
+			return fmt.Errorf("failed to set %s: %v", change.Path, err)
+		}
+	}
+	return nil
+}
+
+func deepCopyJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			out[k] = deepCopyJSON(v)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i := range val {
+			out[i] = deepCopyJSON(val[i])
+		}
+		return out
+	default:
+		return val
+	}
+}
+
+// Reconfigure applies the changes of ClusterConfiguration to the control plane, the static pods are regenerated
+// on the masters one by one, and the kubeadm-config ConfigMap is updated after all the masters are healthy.
+// auditPolicy is the local audit policy file sent to the masters, empty if unchanged.
+func (k *KubeadmRuntime) Reconfigure(auditPolicy string) error {
+	clusterConfig, changes, err := k.getClusterConfigChanges()
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && auditPolicy == "" {
+		logger.Info("control plane is up to date, nothing to reconfigure")
+		return nil
+	}
+	if err = applyConfigChanges(clusterConfig, changes); err != nil {
+		return err
+	}
+	var certChanged bool
+	for _, change := range changes {
+		if change.Path == "apiServer.certSANs" {
+			certChanged = true
+		}
+	}
+	if certChanged {
+		sans, _, _ := unstructured.NestedStringSlice(clusterConfig, "apiServer", "certSANs")
+		k.setCertSANS(sans)
+		if err = k.initCert(); err != nil {
+			return err
+		}
+	}
+	var policyFile string
+	if auditPolicy != "" {
+		policyFile, _, _ = unstructured.NestedString(clusterConfig, "apiServer", "extraArgs", auditPolicyFileArg)
+		if policyFile == "" {
+			return fmt.Errorf("%s is not set in apiServer extraArgs, unable to send the audit policy", auditPolicyFileArg)
+		}
+	}
+
+	for _, master := range k.getMasterIPAndPortList() {
+		step := events.StartStep(events.SourceRuntime, "ReconfigureMaster", master)
+		err = k.reconfigureMaster(master, clusterConfig, auditPolicy, policyFile, certChanged || auditPolicy != "")
+		step.Finish(err)
+		if err != nil {
+			return fmt.Errorf("failed to reconfigure master %s, the masters after it are untouched: %v", master, err)
+		}
+	}
+
+	// upload at last, so that a failed reconfiguration is able to be retried with the same changes
+	if err = yaml.MarshalYamlToFile(path.Join(k.getContentData().EtcPath(), constants.DefaultUpdateKubeadmFileName), clusterConfig); err != nil {
+		return err
+	}
+	return k.uploadConfigFromKubeadm()
+}
+
+// reconfigureMaster regenerates the static pods of control plane on the master, and restores the manifests
+// if the control plane is not healthy in ReconfigureTimeout.
+func (k *KubeadmRuntime) reconfigureMaster(master string, clusterConfig map[string]interface{}, auditPolicy, policyFile string, restartAPIServer bool) error {
+	logger.Info("start to reconfigure control plane of master %s", master)
+	ip := iputils.GetHostIP(master)
+	criSocket, err := k.getCRISocket(master)
+	if err != nil {
+		return err
+	}
+	initConfig := map[string]interface{}{
+		"apiVersion":       k.getKubeadmAPIVersion(),
+		"kind":             InitConfiguration,
+		"localAPIEndpoint": map[string]interface{}{"advertiseAddress": ip, "bindPort": k.getAPIServerPort()},
+		"nodeRegistration": map[string]interface{}{"criSocket": criSocket},
+	}
+	localConfig := path.Join(k.getContentData().TmpPath(), "reconfigure", ip, reconfigureKubeadmFileName)
+	if err = yaml.MarshalYamlToFile(localConfig, initConfig, clusterConfig); err != nil {
+		return err
+	}
+	remoteConfig := path.Join(k.getContentData().EtcPath(), reconfigureKubeadmFileName)
+	if err = k.sshCopy(master, localConfig, remoteConfig); err != nil {
+		return fmt.Errorf("failed to copy kubeadm config: %v", err)
+	}
+	if auditPolicy != "" {
+		if err = k.sshCopy(master, auditPolicy, policyFile); err != nil {
+			return fmt.Errorf("failed to copy audit policy: %v", err)
+		}
+	}
+
+	checksums, containers, err := k.getControlPlaneState(master)
+	if err != nil {
+		return err
+	}
+	manifestsDir := path.Join(constants.KubernetesEtc, constants.ManifestsDirName)
+	backupDir := path.Join(k.getContentData().BackupPath(), fmt.Sprintf("reconfigure-%d", time.Now().Unix()))
+	if err = k.sshCmdAsync(master, fmt.Sprintf(backupManifestsCmd, backupDir, manifestsDir)); err != nil {
+		return fmt.Errorf("failed to backup manifests: %v", err)
+	}
+	restarting, err := k.regenerateControlPlane(master, remoteConfig, checksums, containers, restartAPIServer)
+	if err == nil {
+		err = k.waitControlPlaneRestarted(master, restarting)
+	}
+	if err != nil {
+		logger.Warn("restore the manifests of %s from %s", master, backupDir)
+		if restoreErr := k.sshCmdAsync(master, fmt.Sprintf(restoreManifestsCmd, backupDir, manifestsDir)); restoreErr != nil {
+			logger.Error("failed to restore manifests of %s: %v", master, restoreErr)
+		}
+		return err
+	}
+	logger.Info("succeeded in reconfiguring control plane of master %s", master)
+	return nil
+}
+
+// regenerateControlPlane rewrites the manifests, and returns the containers of the components which are restarting.
+func (k *KubeadmRuntime) regenerateControlPlane(master, config string, checksums, containers map[string]string, restartAPIServer bool) (map[string]string, error) {
+	if err := k.sshCmdAsync(master, fmt.Sprintf(controlPlanePhaseCmd, config)); err != nil {
+		return nil, fmt.Errorf("failed to regenerate control plane: %v", err)
+	}
+	newChecksums, _, err := k.getControlPlaneState(master)
+	if err != nil {
+		return nil, err
+	}
+	restarting := make(map[string]string)
+	for _, component := range kubernetes.ControlPlaneComponents {
+		if checksums[component] != newChecksums[component] {
+			restarting[component] = containers[component]
+		}
+	}
+	// a new cert or audit policy only takes effect after the api-server is restarted
+	if _, ok := restarting[kubernetes.KubeAPIServer]; !ok && restartAPIServer {
+		id := containers[kubernetes.KubeAPIServer]
+		if id != "" {
+			if err = k.sshCmdAsync(master, fmt.Sprintf(stopContainerCmd, id)); err != nil {
+				return nil, fmt.Errorf("failed to restart api-server: %v", err)
+			}
+		}
+		restarting[kubernetes.KubeAPIServer] = id
+	}
+	logger.Debug("restarting components of %s: %v", master, restarting)
+	return restarting, nil
+}
+
+// getControlPlaneState returns the checksums of the manifests and the running containers of the control plane.
+func (k *KubeadmRuntime) getControlPlaneState(master string) (map[string]string, map[string]string, error) {
+	checksums := make(map[string]string)
+	containers := make(map[string]string)
+	for _, component := range kubernetes.ControlPlaneComponents {
+		checksum, err := k.sshCmdToString(master, fmt.Sprintf(manifestChecksumCmd, k.getStaticPodManifest(component)))
+		if err != nil {
+			return nil, nil, err
+		}
+		checksums[component] = strings.TrimSpace(checksum)
+		id, err := k.sshCmdToString(master, fmt.Sprintf(runningContainerIDCmd, component))
+		if err != nil {
+			return nil, nil, err
+		}
+		containers[component] = strings.TrimSpace(id)
+	}
+	return checksums, containers, nil
+}
+
+// waitControlPlaneRestarted waits for the components to be running in new containers and the api-server to be healthy.
+func (k *KubeadmRuntime) waitControlPlaneRestarted(master string, previous map[string]string) error {
+	if len(previous) == 0 {
+		logger.Info("manifests of %s are not changed", master)
+		return nil
+	}
+	logger.Info("wait for control plane of %s to be restarted", master)
+	err := wait.PollImmediate(controlPlanePollingInterval, ReconfigureTimeout, func() (bool, error) {
+		for component, id := range previous {
+			current, err := k.sshCmdToString(master, fmt.Sprintf(runningContainerIDCmd, component))
+			if err != nil {
+				logger.Debug("failed to get container of %s on %s: %v", component, master, err)
+				return false, nil
+			}
+			if current = strings.TrimSpace(current); current == "" || current == id {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("control plane is not restarted in %s", ReconfigureTimeout)
+	}
+	if _, ok := previous[kubernetes.KubeAPIServer]; !ok {
+		return nil
+	}
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), fmt.Sprintf("https://%s:%d", iputils.GetHostIP(master), k.getAPIServerPort()))
+	if err != nil {
+		return err
+	}
+	if err = kubernetes.NewKubeHealthy(cli.Kubernetes(), ReconfigureTimeout).ForAPI(); err != nil {
+		return fmt.Errorf("api-server of %s is not healthy: %v", master, err)
+	}
+	return nil
+}
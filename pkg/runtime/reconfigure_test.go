@@ -0,0 +1,115 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffClusterConfig(t *testing.T) {
+	current := map[string]interface{}{
+		"networking": map[string]interface{}{"podSubnet": "100.64.0.0/10", "serviceSubnet": "10.96.0.0/22"},
+		"apiServer": map[string]interface{}{
+			"certSANs":  []interface{}{"127.0.0.1", "apiserver.cluster.local", "10.0.0.1"},
+			"extraArgs": map[string]interface{}{"feature-gates": "TTLAfterFinished=true"},
+		},
+		"scheduler": map[string]interface{}{"extraArgs": map[string]interface{}{"v": "2"}},
+	}
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		want    []ConfigChange
+		wantErr bool
+	}{
+		{
+			name: "nothing changed",
+			desired: map[string]interface{}{
+				"networking": map[string]interface{}{"podSubnet": "100.64.0.0/10"},
+				"apiServer": map[string]interface{}{
+					"certSANs":  []interface{}{"10.0.0.1"},
+					"extraArgs": map[string]interface{}{"feature-gates": "TTLAfterFinished=true"},
+				},
+				"scheduler": map[string]interface{}{"extraArgs": map[string]interface{}{"v": "2"}},
+			},
+		},
+		{
+			name: "extra args and sans changed",
+			desired: map[string]interface{}{
+				"apiServer": map[string]interface{}{
+					"certSANs":  []interface{}{"sealos.io"},
+					"extraArgs": map[string]interface{}{"feature-gates": "TTLAfterFinished=true"},
+				},
+				"controllerManager": map[string]interface{}{"extraArgs": map[string]interface{}{"bind-address": "0.0.0.0"}},
+				"featureGates":      map[string]interface{}{"PublicKeysECDSA": true},
+			},
+			want: []ConfigChange{
+				{Path: "featureGates", Desired: map[string]interface{}{"PublicKeysECDSA": true}},
+				{
+					Path:    "apiServer.certSANs",
+					Current: []interface{}{"127.0.0.1", "apiserver.cluster.local", "10.0.0.1"},
+					Desired: []interface{}{"127.0.0.1", "apiserver.cluster.local", "10.0.0.1", "sealos.io"},
+				},
+				{Path: "controllerManager.extraArgs", Desired: map[string]interface{}{"bind-address": "0.0.0.0"}},
+				{Path: "scheduler.extraArgs", Current: map[string]interface{}{"v": "2"}},
+			},
+		},
+		{
+			name: "pod subnet changed",
+			desired: map[string]interface{}{
+				"networking": map[string]interface{}{"podSubnet": "10.244.0.0/16"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffClusterConfig(current, tt.desired)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("diffClusterConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffClusterConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyConfigChanges(t *testing.T) {
+	obj := map[string]interface{}{
+		"kubernetesVersion": "v1.25.6",
+		"scheduler":         map[string]interface{}{"extraArgs": map[string]interface{}{"v": "2"}},
+	}
+	desired := map[string]interface{}{"bind-address": "0.0.0.0"}
+	changes := []ConfigChange{
+		{Path: "scheduler.extraArgs", Current: map[string]interface{}{"v": "2"}},
+		{Path: "controllerManager.extraArgs", Desired: desired},
+	}
+	if err := applyConfigChanges(obj, changes); err != nil {
+		t.Fatalf("applyConfigChanges() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"kubernetesVersion": "v1.25.6",
+		"scheduler":         map[string]interface{}{},
+		"controllerManager": map[string]interface{}{"extraArgs": map[string]interface{}{"bind-address": "0.0.0.0"}},
+	}
+	if !reflect.DeepEqual(obj, want) {
+		t.Errorf("applyConfigChanges() = %v, want %v", obj, want)
+	}
+	desired["bind-address"] = "127.0.0.1"
+	if v := obj["controllerManager"].(map[string]interface{})["extraArgs"].(map[string]interface{})["bind-address"]; v != "0.0.0.0" {
+		t.Errorf("applyConfigChanges() shares the desired value with changes, got %v", v)
+	}
+}
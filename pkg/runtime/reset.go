@@ -15,7 +15,6 @@
 package runtime
 
 import (
-	"context"
 	"fmt"
 
 	"golang.org/x/sync/errgroup"
@@ -36,12 +35,13 @@ rm -rf %s && (ip link delete kube-ipvs0 >/dev/null 2>&1 || true)
 func (k *KubeadmRuntime) reset() error {
 	k.resetNodes(k.getNodeIPAndPortList())
 	k.resetMasters(k.getMasterIPAndPortList())
+	k.resetEtcdMembers(k.getEtcdIPAndPortList())
 	return nil
 }
 
 func (k *KubeadmRuntime) resetNodes(nodes []string) {
 	logger.Info("start to reset nodes: %v", nodes)
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, _ := errgroup.WithContext(k.ctx)
 	for _, node := range nodes {
 		node := node
 		eg.Go(func() error {
@@ -15,6 +15,7 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -28,6 +29,8 @@ import (
 
 type KubeadmRuntime struct {
 	*sync.Mutex
+	// ctx interrupts the remote commands once cancelled
+	ctx      context.Context
 	Cluster  *v2.Cluster
 	Token    *Token
 	Registry *v2.RegistryConfig
@@ -48,6 +51,7 @@ func (k *KubeadmRuntime) Init() error {
 		k.ConfigInitKubeadmToMaster0,
 		k.UpdateCertByInit,
 		k.CopyStaticFilesToMasters,
+		k.InitExternalEtcd,
 		k.InitMaster0,
 	}
 
@@ -81,9 +85,21 @@ type Interface interface {
 	DeleteNodes(nodeIPList []string) error
 	JoinMasters(newMastersIPList []string) error
 	DeleteMasters(mastersIPList []string) error
+	JoinEtcdMembers(newEtcdIPList []string) error
 	SyncNodeIPVS(mastersIPList, nodeIPList []string) error
+	SyncNodeConfigs(hosts []string) error
+	CordonNodes(hosts []string) error
+	UncordonNodes(hosts []string) error
+	RebootNodes(hosts []string, maxUnavailable int) error
 	UpdateCert(certs []string) error
+	CheckCerts() ([]CertExpiration, error)
+	RenewCerts() error
+	DiffClusterConfig() ([]ConfigChange, error)
+	Reconfigure(auditPolicy string) error
 	UpgradeCluster(version string) error
+	RollbackUpgrade(restoreEtcd bool) error
+	SaveEtcdSnapshot(dst string) error
+	RestoreEtcdSnapshot(src string) error
 	GetAdminKubeconfig() ([]byte, error)
 }
 
@@ -96,10 +112,7 @@ func (k *KubeadmRuntime) JoinNodes(newNodesIPList []string) error {
 	if len(newNodesIPList) != 0 {
 		logger.Info("%s will be added as worker", newNodesIPList)
 	}
-	if err := k.joinNodes(newNodesIPList); err != nil {
-		return err
-	}
-	return k.copyNodeKubeConfig(newNodesIPList)
+	return k.joinNodes(newNodesIPList)
 }
 func (k *KubeadmRuntime) DeleteNodes(nodesIPList []string) error {
 	if len(nodesIPList) != 0 {
@@ -122,9 +135,10 @@ func (k *KubeadmRuntime) DeleteMasters(mastersIPList []string) error {
 	return k.deleteMasters(mastersIPList)
 }
 
-func newKubeadmRuntime(cluster *v2.Cluster, kubeadm *KubeadmConfig) (Interface, error) {
+func newKubeadmRuntime(ctx context.Context, cluster *v2.Cluster, kubeadm *KubeadmConfig) (Interface, error) {
 	k := &KubeadmRuntime{
 		Mutex:   &sync.Mutex{},
+		ctx:     ctx,
 		Cluster: cluster,
 		Config: &Config{
 			ClusterFileKubeConfig: kubeadm,
@@ -141,9 +155,17 @@ func newKubeadmRuntime(cluster *v2.Cluster, kubeadm *KubeadmConfig) (Interface,
 	return k, nil
 }
 
-// NewDefaultRuntime arg "clusterName" is the Cluster name
-func NewDefaultRuntime(cluster *v2.Cluster, kubeadm *KubeadmConfig) (Interface, error) {
-	return newKubeadmRuntime(cluster, kubeadm)
+// NewDefaultRuntime returns the runtime of the kubernetes distribution of the cluster,
+// remote commands are interrupted once ctx is done.
+func NewDefaultRuntime(ctx context.Context, cluster *v2.Cluster, kubeadm *KubeadmConfig) (Interface, error) {
+	switch d := cluster.GetDistribution(); d {
+	case v2.KubeadmDistribution:
+		return newKubeadmRuntime(ctx, cluster, kubeadm)
+	case v2.K3sDistribution:
+		return newK3sRuntime(ctx, cluster, kubeadm)
+	default:
+		return nil, fmt.Errorf("unsupported distribution %s, must be %s or %s", d, v2.KubeadmDistribution, v2.K3sDistribution)
+	}
 }
 
 func (k *KubeadmRuntime) Validate() error {
@@ -17,7 +17,6 @@ limitations under the License.
 package runtime
 
 import (
-	"context"
 	"fmt"
 	"strings"
 
@@ -120,7 +119,7 @@ func (k *KubeadmRuntime) syncNodeIPVSYaml(masterIPs, nodesIPs []string) error {
 		masters = append(masters, fmt.Sprintf("%s:%d", iputils.GetHostIP(master), k.getAPIServerPort()))
 	}
 
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, _ := errgroup.WithContext(k.ctx)
 	for _, node := range nodesIPs {
 		node := node
 		eg.Go(func() error {
@@ -168,11 +167,11 @@ func (k *KubeadmRuntime) execHostsDelete(ip, domain string) error {
 }
 
 func (k *KubeadmRuntime) sshCmdAsync(host string, cmd ...string) error {
-	return k.getSSHInterface().CmdAsync(host, cmd...)
+	return k.getSSHInterface().CmdAsync(k.ctx, host, cmd...)
 }
 
 func (k *KubeadmRuntime) sshCmdToString(host string, cmd string) (string, error) {
-	return k.getSSHInterface().CmdToString(host, cmd, "")
+	return k.getSSHInterface().CmdToString(k.ctx, host, cmd, "")
 }
 
 func (k *KubeadmRuntime) sshCopy(host, srcFilePath, dstFilePath string) error {
@@ -180,7 +179,7 @@ func (k *KubeadmRuntime) sshCopy(host, srcFilePath, dstFilePath string) error {
 		logger.Info("src and dst is same path , skip copy %s", srcFilePath)
 		return nil
 	}
-	return k.getSSHInterface().Copy(host, srcFilePath, dstFilePath)
+	return k.getSSHInterface().Copy(k.ctx, host, srcFilePath, dstFilePath)
 }
 
 func (k *KubeadmRuntime) getImageLabels() map[string]string {
@@ -188,7 +187,7 @@ func (k *KubeadmRuntime) getImageLabels() map[string]string {
 }
 
 func (k *KubeadmRuntime) getSSHInterface() ssh.Interface {
-	return ssh.NewSSHClient(&k.Cluster.Spec.SSH, true)
+	return ssh.NewSSHClientWithCluster(k.Cluster, true)
 }
 
 func (k *KubeadmRuntime) getENVInterface() env.Interface {
@@ -15,7 +15,6 @@
 package runtime
 
 import (
-	"context"
 	"fmt"
 	"path/filepath"
 
@@ -52,7 +51,7 @@ func (k *KubeadmRuntime) CopyStaticFiles(nodes []string) error {
 	for _, file := range MasterStaticFiles {
 		staticFilePath := filepath.Join(k.getContentData().RootFSStaticsPath(), file.Name)
 		cmdLinkStatic := fmt.Sprintf(RemoteCmdCopyStatic, file.DestinationDir, staticFilePath, filepath.Join(file.DestinationDir, file.Name))
-		eg, _ := errgroup.WithContext(context.Background())
+		eg, _ := errgroup.WithContext(k.ctx)
 		for _, host := range nodes {
 			host := host
 			eg.Go(func() error {
@@ -15,7 +15,6 @@
 package runtime
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"path"
@@ -158,7 +157,7 @@ func (k *KubeadmRuntime) deleteAPIServer() error {
 		} `json:"containers"`
 	}
 	logger.Info("delete pod apiserver from crictl")
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, _ := errgroup.WithContext(k.ctx)
 	for _, master := range k.getMasterIPAndPortList() {
 		m := master
 		eg.Go(func() error {
@@ -18,12 +18,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path"
 	str "strings"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/drain"
 
 	"github.com/labring/sealos/pkg/client-go/kubernetes"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/confirm"
 	"github.com/labring/sealos/pkg/utils/logger"
 	"github.com/labring/sealos/pkg/utils/versionutil"
@@ -32,15 +38,25 @@ import (
 const (
 	upgradeApplyCmd = "kubeadm upgrade apply %s"
 	upradeNodeCmd   = "kubeadm upgrade node"
-	//drainNodeCmd    = "kubectl drain %s --ignore-daemonsets"
-	cordonNodeCmd   = "kubectl cordon %s"
-	uncordonNodeCmd = "kubectl uncordon %s"
 	daemonReload    = "systemctl daemon-reload"
 	restartKubelet  = "systemctl restart kubelet"
 
 	installKubeadmCmd = "cp -rf %s/kubeadm /usr/bin"
 	installKubeletCmd = "cp -rf %s/kubelet /usr/bin"
 	installKubectlCmd = "cp -rf %s/kubectl /usr/bin"
+
+	// backupUpgradeCmd saves the binaries, static pod manifests, certs and kubelet configs replaced by upgrading
+	backupUpgradeCmd = `rm -rf %[1]s && mkdir -p %[1]s/bin && cp -af /usr/bin/kubeadm /usr/bin/kubelet /usr/bin/kubectl %[1]s/bin/ && ` +
+		`cp -a /etc/kubernetes %[1]s/ && (cp -af /var/lib/kubelet/config.yaml /var/lib/kubelet/kubeadm-flags.env /etc/image-cri-shim.yaml %[1]s/ 2>/dev/null || true)`
+	restoreUpgradeCmd = `test -d %[1]s/kubernetes && cp -af %[1]s/bin/. /usr/bin/ && cp -af %[1]s/kubernetes/. /etc/kubernetes/ && ` +
+		`(test ! -f %[1]s/config.yaml || cp -af %[1]s/config.yaml /var/lib/kubelet/config.yaml) && ` +
+		`(test ! -f %[1]s/kubeadm-flags.env || cp -af %[1]s/kubeadm-flags.env /var/lib/kubelet/kubeadm-flags.env)`
+	restoreCRIShimCmd = `test ! -f %[1]s/image-cri-shim.yaml || (cp -af %[1]s/image-cri-shim.yaml /etc/image-cri-shim.yaml && systemctl restart image-cri-shim)`
+
+	kubeadmConfigMap = "kubeadm-config"
+
+	defaultUpgradeDrainTimeout     = 5 * time.Minute
+	defaultUpgradeNodeReadyTimeout = 5 * time.Minute
 )
 
 func (k *KubeadmRuntime) upgradeCluster(version string) error {
@@ -57,50 +73,343 @@ func (k *KubeadmRuntime) upgradeCluster(version string) error {
 			return err
 		}
 	}
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	master0 := k.getMaster0IPAndPort()
+	var masters, nodes []string
+	for _, ip := range k.getMasterIPAndPortList() {
+		if ip != master0 {
+			masters = append(masters, ip)
+		}
+	}
+	nodes = k.getNodeIPAndPortList()
+	allHosts := append(append([]string{master0}, masters...), nodes...)
+
+	logger.Info("start to run pre-flight checks of upgrading")
+	nodeNames, unschedulable, err := k.upgradePreflight(cli, allHosts)
+	if err != nil {
+		return fmt.Errorf("upgrade pre-flight checks failed: %v", err)
+	}
+	if err = k.backupBeforeUpgrade(cli, version, allHosts); err != nil {
+		return fmt.Errorf("failed to backup before upgrading: %v", err)
+	}
+	strategy := k.getUpgradeStrategy()
+	u := &nodeUpgrader{KubeadmRuntime: k, client: cli, strategy: strategy, version: version, nodeNames: nodeNames, unschedulable: unschedulable}
+
 	//upgrade master0
 	logger.Info("start to upgrade master0")
-	err := k.upgradeMaster0(version)
+	if err = u.upgrade(master0, true); err != nil {
+		return err
+	}
+	//upgrade other control-planes one by one
+	logger.Info("start to upgrade other control-planes")
+	for _, ip := range masters {
+		if err = u.upgrade(ip, false); err != nil {
+			return err
+		}
+	}
+	//upgrade worker nodes in batches
+	logger.Info("start to upgrade worker nodes, %d at a time", strategy.MaxUnavailable)
+	for _, batch := range splitBatches(nodes, strategy.MaxUnavailable) {
+		eg, _ := errgroup.WithContext(k.ctx)
+		for _, ip := range batch {
+			ip := ip
+			eg.Go(func() error {
+				return u.upgrade(ip, false)
+			})
+		}
+		if err = eg.Wait(); err != nil {
+			return err
+		}
+	}
+	k.cleanUpgradeBackup()
+	return nil
+}
+
+func (k *KubeadmRuntime) getUpgradeStrategy() v2.UpgradeStrategy {
+	strategy := v2.UpgradeStrategy{}
+	if k.Cluster.Spec.Upgrade != nil {
+		strategy = *k.Cluster.Spec.Upgrade.DeepCopy()
+	}
+	if strategy.MaxUnavailable < 1 {
+		strategy.MaxUnavailable = 1
+	}
+	if strategy.DrainTimeout == nil {
+		strategy.DrainTimeout = &metaV1.Duration{Duration: defaultUpgradeDrainTimeout}
+	}
+	if strategy.NodeReadyTimeout == nil {
+		strategy.NodeReadyTimeout = &metaV1.Duration{Duration: defaultUpgradeNodeReadyTimeout}
+	}
+	return strategy
+}
+
+// splitBatches splits the hosts into batches of size, all the hosts are in a single batch if size is not positive.
+func splitBatches(ips []string, size int) [][]string {
+	if size <= 0 {
+		size = len(ips)
+	}
+	var batches [][]string
+	for size < len(ips) {
+		ips, batches = ips[size:], append(batches, ips[:size])
+	}
+	if len(ips) > 0 {
+		batches = append(batches, ips)
+	}
+	return batches
+}
+
+// upgradePreflight makes sure that the api-server is healthy and every host is a ready node,
+// it returns the node names of the hosts and the hosts which are unschedulable before upgrading.
+// The hosts left cordoned by the last failed upgrade are not counted as unschedulable.
+func (k *KubeadmRuntime) upgradePreflight(cli kubernetes.Client, hosts []string) (map[string]string, map[string]bool, error) {
+	if err := kubernetes.NewKubeHealthy(cli.Kubernetes(), time.Minute).ForAPI(); err != nil {
+		return nil, nil, fmt.Errorf("api-server is not healthy: %v", err)
+	}
+	var drained []string
+	if backup := k.Cluster.Status.UpgradeBackup; backup != nil {
+		drained = backup.DrainedHosts
+	}
+	nodeNames := make(map[string]string, len(hosts))
+	unschedulable := make(map[string]bool)
+	for _, ip := range hosts {
+		nodeName, err := k.getRemoteInterface().Hostname(ip)
+		if err != nil {
+			return nil, nil, err
+		}
+		//default nodeName in k8s is the lower case of their hostname because of DNS protocol.
+		nodeName = str.ToLower(nodeName)
+		node, err := cli.Kubernetes().CoreV1().Nodes().Get(k.ctx, nodeName, metaV1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get node %s of host %s: %v", nodeName, ip, err)
+		}
+		if !isNodeReady(node) {
+			return nil, nil, fmt.Errorf("node %s is not ready, fix it before upgrading", nodeName)
+		}
+		nodeNames[ip] = nodeName
+		if node.Spec.Unschedulable && !v2.In(ip, drained) {
+			unschedulable[ip] = true
+		}
+	}
+	return nodeNames, unschedulable, nil
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// backupBeforeUpgrade backs up every host, the kubeadm-config ConfigMap and takes an etcd snapshot on master0,
+// the backup is recorded in the cluster status. A backup of the same upgrade is reused, since the hosts might
+// have been partially upgraded by the last failed attempt.
+func (k *KubeadmRuntime) backupBeforeUpgrade(cli kubernetes.Client, version string, hosts []string) error {
+	curVersion := k.getKubeVersionFromImage()
+	if backup := k.Cluster.Status.UpgradeBackup; backup != nil && backup.Version == curVersion && backup.TargetVersion == version {
+		logger.Info("backup of upgrading %s to %s already exists in %s, skip backup", curVersion, version, backup.Dir)
+		return nil
+	}
+	kubeadmConfig, err := cli.Kubernetes().CoreV1().ConfigMaps(metaV1.NamespaceSystem).Get(k.ctx, kubeadmConfigMap, metaV1.GetOptions{})
 	if err != nil {
+		return fmt.Errorf("failed to get kubeadm-config: %v", err)
+	}
+	dir := path.Join(k.getContentData().BackupPath(), "upgrade-"+curVersion)
+	logger.Info("start to backup kubernetes %s of all hosts into %s", curVersion, dir)
+	eg, _ := errgroup.WithContext(k.ctx)
+	for _, ip := range hosts {
+		ip := ip
+		eg.Go(func() error {
+			return k.sshCmdAsync(ip, fmt.Sprintf(backupUpgradeCmd, dir))
+		})
+	}
+	if err := eg.Wait(); err != nil {
 		return err
 	}
-	//upgrade other control-planes and worker nodes
-	upgradeNodes := []string{}
-	for _, ip := range append(k.getMasterIPList(), k.getNodeIPList()...) {
-		if ip == k.getMaster0IP() {
-			continue
+	// external etcd is not touched by upgrading
+	var snapshot string
+	if !k.Cluster.IsExternalEtcd() {
+		snapshot = path.Join(dir, "etcd-snapshot.db")
+		if err := k.saveEtcdSnapshot(k.getMaster0IPAndPort(), snapshot); err != nil {
+			return err
 		}
-		upgradeNodes = append(upgradeNodes, ip)
 	}
-	logger.Info("start to upgrade other control-planes and worker nodes")
-	return k.upgradeOtherNodes(upgradeNodes, version)
+	k.Cluster.Status.UpgradeBackup = &v2.UpgradeBackup{
+		Version:           curVersion,
+		TargetVersion:     version,
+		Dir:               dir,
+		Hosts:             hosts,
+		EtcdSnapshot:      snapshot,
+		KubeadmConfig:     kubeadmConfig.Data,
+		CreationTimestamp: metaV1.Now(),
+	}
+	return nil
 }
 
-func (k *KubeadmRuntime) upgradeMaster0(version string) error {
-	master0ip := k.getMaster0IP()
-	if versionutil.Compare(version, V1260) {
-		if err := k.changeCRIVersion(master0ip); err != nil {
+func (k *KubeadmRuntime) cleanUpgradeBackup() {
+	backup := k.Cluster.Status.UpgradeBackup
+	if backup == nil {
+		return
+	}
+	for _, ip := range backup.Hosts {
+		if err := k.sshCmdAsync(ip, fmt.Sprintf("rm -rf %s", backup.Dir)); err != nil {
+			logger.Warn("failed to remove upgrade backup %s on %s: %v", backup.Dir, ip, err)
+		}
+	}
+	k.Cluster.Status.UpgradeBackup = nil
+}
+
+// RollbackUpgrade restores the binaries, manifests and kubelet configs of all hosts from the backup
+// of the last failed upgrade, etcd is restored from the snapshot only if restoreEtcd is set, otherwise
+// the kubeadm-config ConfigMap is restored. The hosts drained by the failed upgrade are uncordoned.
+func (k *KubeadmRuntime) RollbackUpgrade(restoreEtcd bool) error {
+	backup := k.Cluster.Status.UpgradeBackup
+	if backup == nil {
+		return fmt.Errorf("no upgrade backup found in cluster %s", k.getClusterName())
+	}
+	logger.Info("start to roll back kubernetes %s to %s", backup.TargetVersion, backup.Version)
+	for _, ip := range backup.Hosts {
+		logger.Info("roll back host %s", ip)
+		if err := k.sshCmdAsync(ip,
+			fmt.Sprintf(restoreUpgradeCmd, backup.Dir),
+			fmt.Sprintf(restoreCRIShimCmd, backup.Dir),
+			daemonReload,
+			restartKubelet,
+		); err != nil {
+			return fmt.Errorf("failed to restore backup on %s: %v", ip, err)
+		}
+	}
+	if restoreEtcd && backup.EtcdSnapshot != "" {
+		// the snapshot is only saved on master0, fetch it so that it can be sent to all masters
+		snapshot := path.Join(k.getContentData().TmpPath(), path.Base(backup.EtcdSnapshot))
+		_ = os.Remove(snapshot)
+		if err := k.getSSHInterface().CopyR(k.ctx, k.getMaster0IPAndPort(), snapshot, backup.EtcdSnapshot); err != nil {
+			return fmt.Errorf("failed to fetch etcd snapshot: %v", err)
+		}
+		defer os.Remove(snapshot)
+		if err := k.RestoreEtcdSnapshot(snapshot); err != nil {
+			return fmt.Errorf("failed to restore etcd snapshot: %v", err)
+		}
+	}
+	if err := k.pingAPIServer(); err != nil {
+		return err
+	}
+	cli, err := kubernetes.NewKubernetesClient(k.getContentData().AdminFile(), k.getMaster0IPAPIServer())
+	if err != nil {
+		return err
+	}
+	if !restoreEtcd || backup.EtcdSnapshot == "" {
+		if err = k.restoreKubeadmConfig(cli, backup); err != nil {
 			return err
 		}
 	}
-	master0Name, err := k.getRemoteInterface().Hostname(master0ip)
+	for _, ip := range backup.DrainedHosts {
+		if err = uncordonHost(k.ctx, cli, ip); err != nil {
+			return err
+		}
+	}
+	logger.Info("the backup in %s is kept on hosts, remove it manually if no longer needed", backup.Dir)
+	k.Cluster.Status.UpgradeBackup = nil
+	return nil
+}
+
+// restoreKubeadmConfig restores the kubeadm-config ConfigMap, which is updated to the target version by kubeadm.
+func (k *KubeadmRuntime) restoreKubeadmConfig(cli kubernetes.Client, backup *v2.UpgradeBackup) error {
+	if len(backup.KubeadmConfig) == 0 {
+		logger.Warn("kubeadm-config is not found in the backup, it might keep the version %s", backup.TargetVersion)
+		return nil
+	}
+	cm, err := cli.Kubernetes().CoreV1().ConfigMaps(metaV1.NamespaceSystem).Get(k.ctx, kubeadmConfigMap, metaV1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get kubeadm-config: %v", err)
+	}
+	cm.Data = backup.KubeadmConfig
+	if _, err = cli.Kubernetes().CoreV1().ConfigMaps(metaV1.NamespaceSystem).Update(k.ctx, cm, metaV1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to restore kubeadm-config: %v", err)
+	}
+	logger.Info("restored kubeadm-config of version %s", backup.Version)
+	return nil
+}
+
+func uncordonHost(ctx context.Context, cli kubernetes.Client, host string) error {
+	nodeName, err := getNodeNameByIP(ctx, cli, host)
 	if err != nil {
 		return err
 	}
-	//default nodeName in k8s is the lower case of their hostname because of DNS protocol.
-	master0Name = str.ToLower(master0Name)
-	kubeBinaryPath := k.getContentData().RootFSBinPath()
+	if nodeName == "" {
+		logger.Warn("node of host %s is not found, skip uncordoning it", host)
+		return nil
+	}
+	node, err := cli.Kubernetes().CoreV1().Nodes().Get(ctx, nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err = drain.RunCordonOrUncordon(newDrainHelper(ctx, cli, 0, false, false), node, false); err != nil {
+		return fmt.Errorf("failed to uncordon node %s: %v", nodeName, err)
+	}
+	logger.Info("uncordoned node %s", nodeName)
+	return nil
+}
+
+// nodeUpgrader upgrades a single node: upgrade the control plane or kubelet config by kubeadm,
+// drain the node, upgrade kubelet and wait for the node to be ready before uncordoning it.
+type nodeUpgrader struct {
+	*KubeadmRuntime
+	client    kubernetes.Client
+	strategy  v2.UpgradeStrategy
+	version   string
+	nodeNames map[string]string
+	// unschedulable are the hosts cordoned before upgrading, they are kept cordoned
+	unschedulable map[string]bool
+}
+
+func (u *nodeUpgrader) upgrade(ip string, isMaster0 bool) error {
+	nodeName := u.nodeNames[ip]
+	if versionutil.Compare(u.version, V1260) {
+		if err := u.changeCRIVersion(ip); err != nil {
+			return err
+		}
+	}
+	kubeBinaryPath := u.getContentData().RootFSBinPath()
 	//assure the connection to api-server succeed before executing upgrade cmds
-	if err = k.pingAPIServer(); err != nil {
+	if err := u.pingAPIServer(); err != nil {
 		return err
 	}
-	err = k.sshCmdAsync(master0ip,
-		//install kubeadm:{version} at master0
+	logger.Info("upgrade node %s", nodeName)
+	upgradeCmd := upradeNodeCmd
+	if isMaster0 {
+		upgradeCmd = fmt.Sprintf(upgradeApplyCmd, u.version)
+	}
+	err := u.sshCmdAsync(ip,
+		//install kubeadm:{version} at the node
 		fmt.Sprintf(installKubeadmCmd, kubeBinaryPath),
-		//execute  kubeadm upgrade apply {version} at master0
-		fmt.Sprintf(upgradeApplyCmd, version),
-		//kubectl cordon <node-to-cordon>
-		fmt.Sprintf(cordonNodeCmd, master0Name),
-		//install kubelet:{version},kubectl{version} at master0
+		//execute kubeadm upgrade apply {version} at master0, and upgrade node at the others
+		upgradeCmd,
+	)
+	if err != nil {
+		return err
+	}
+	err = u.drainNode(nodeName)
+	if err == nil {
+		err = u.upgradeKubelet(ip, nodeName)
+	}
+	if err == nil {
+		err = u.uncordonNode(ip)
+	}
+	if err != nil {
+		// the node is kept cordoned, it is uncordoned after rolled back
+		u.recordDrained(ip)
+	}
+	return err
+}
+
+func (u *nodeUpgrader) upgradeKubelet(ip, nodeName string) error {
+	kubeBinaryPath := u.getContentData().RootFSBinPath()
+	err := u.sshCmdAsync(ip,
+		//install kubelet:{version},kubectl{version} at the node
 		fmt.Sprintf(installKubectlCmd, kubeBinaryPath),
 		fmt.Sprintf(installKubeletCmd, kubeBinaryPath),
 		//reload kubelet daemon
@@ -110,48 +419,79 @@ func (k *KubeadmRuntime) upgradeMaster0(version string) error {
 	if err != nil {
 		return err
 	}
-	return k.tryUncordonNode(master0ip, master0Name)
+	healthy := kubernetes.NewKubeHealthy(u.client.Kubernetes(), u.strategy.NodeReadyTimeout.Duration)
+	if err = healthy.ForNodeReady(nodeName); err != nil {
+		return fmt.Errorf("node %s is not ready after upgrading: %v", nodeName, err)
+	}
+	return nil
 }
 
-func (k *KubeadmRuntime) upgradeOtherNodes(ips []string, version string) error {
-	for _, ip := range ips {
-		if versionutil.Compare(version, V1260) {
-			if err := k.changeCRIVersion(ip); err != nil {
-				return err
-			}
-		}
-		nodename, err := k.getRemoteInterface().Hostname(ip)
-		if err != nil {
-			return err
-		}
-		//default nodeName in k8s is the lower case of their hostname because of DNS protocol.
-		nodename = str.ToLower(nodename)
-		kubeBinaryPath := k.getContentData().RootFSBinPath()
-		//assure the connection to api-server succeed before executing upgrade cmds
-		if err = k.pingAPIServer(); err != nil {
-			return err
-		}
-		logger.Info("upgrade node %s", nodename)
-		err = k.sshCmdAsync(ip,
-			//install kubeadm:{version} at the node
-			fmt.Sprintf(installKubeadmCmd, kubeBinaryPath),
-			//upgrade other control-plane and nodes
-			upradeNodeCmd,
-			//kubectl cordon <node-to-cordon>
-			fmt.Sprintf(cordonNodeCmd, nodename),
-			//install kubelet:{version},kubectl{version} at the node
-			fmt.Sprintf(installKubectlCmd, kubeBinaryPath),
-			fmt.Sprintf(installKubeletCmd, kubeBinaryPath),
-			//reload kubelet daemon
-			daemonReload,
-			restartKubelet,
-		)
-		if err != nil {
-			return err
-		}
-		if err = k.tryUncordonNode(ip, nodename); err != nil {
-			return err
-		}
+// recordDrained records the host cordoned by the upgrade, the hosts cordoned before upgrading are
+// not recorded so that they are kept cordoned after rolled back.
+func (u *nodeUpgrader) recordDrained(ip string) {
+	if u.unschedulable[ip] {
+		return
+	}
+	u.Lock()
+	defer u.Unlock()
+	if backup := u.Cluster.Status.UpgradeBackup; backup != nil && !v2.In(ip, backup.DrainedHosts) {
+		backup.DrainedHosts = append(backup.DrainedHosts, ip)
+	}
+}
+
+func (u *nodeUpgrader) newDrainHelper() *drain.Helper {
+	return newDrainHelper(u.ctx, u.client, u.strategy.DrainTimeout.Duration, u.strategy.DisableEviction, u.strategy.DeleteEmptyDirData)
+}
+
+// newDrainHelper returns a drain helper which evicts the pods respecting PodDisruptionBudgets
+// unless disableEviction is set, the DaemonSet pods are ignored. Like kubectl drain, the pods
+// using emptyDir volumes fail the draining unless deleteEmptyDirData is set.
+func newDrainHelper(ctx context.Context, client kubernetes.Client, timeout time.Duration, disableEviction, deleteEmptyDirData bool) *drain.Helper {
+	return &drain.Helper{
+		Ctx:                 ctx,
+		Client:              client.Kubernetes(),
+		GracePeriodSeconds:  -1,
+		IgnoreAllDaemonSets: true,
+		DeleteEmptyDirData:  deleteEmptyDirData,
+		DisableEviction:     disableEviction,
+		Timeout:             timeout,
+		Out:                 os.Stdout,
+		ErrOut:              os.Stderr,
+	}
+}
+
+func (u *nodeUpgrader) drainNode(nodeName string) error {
+	node, err := u.client.Kubernetes().CoreV1().Nodes().Get(u.ctx, nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	helper := u.newDrainHelper()
+	if err = drain.RunCordonOrUncordon(helper, node, true); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %v", nodeName, err)
+	}
+	if u.strategy.SkipDrain {
+		return nil
+	}
+	logger.Info("drain node %s", nodeName)
+	if err = drain.RunNodeDrain(helper, nodeName); err != nil {
+		return fmt.Errorf("failed to drain node %s: %v", nodeName, err)
+	}
+	return nil
+}
+
+// uncordonNode uncordons the node of the host unless it was cordoned before upgrading.
+func (u *nodeUpgrader) uncordonNode(ip string) error {
+	nodeName := u.nodeNames[ip]
+	if u.unschedulable[ip] {
+		logger.Info("node %s was cordoned before upgrading, keep it cordoned", nodeName)
+		return nil
+	}
+	node, err := u.client.Kubernetes().CoreV1().Nodes().Get(u.ctx, nodeName, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err = drain.RunCordonOrUncordon(u.newDrainHelper(), node, false); err != nil {
+		return fmt.Errorf("failed to uncordon node %s: %v", nodeName, err)
 	}
 	return nil
 }
@@ -202,22 +542,6 @@ func (k *KubeadmRuntime) pingAPIServer() error {
 	return nil
 }
 
-func (k *KubeadmRuntime) tryUncordonNode(ip, nodename string) error {
-	err := k.sshCmdAsync(ip, fmt.Sprintf(uncordonNodeCmd, nodename))
-	timeout := time.Now().Add(1 * time.Minute)
-	for err != nil {
-		time.Sleep(5 * time.Second)
-		err = k.sshCmdAsync(ip, fmt.Sprintf(uncordonNodeCmd, nodename))
-		if err == nil {
-			break
-		}
-		if time.Now().After(timeout) {
-			return fmt.Errorf("try uncordon node %s timeout one minute", nodename)
-		}
-	}
-	return nil
-}
-
 func (k *KubeadmRuntime) changeCRIVersion(ip string) error {
 	return k.sshCmdAsync(ip,
 		"sed -i \"s/v1alpha2/v1/\" /etc/image-cri-shim.yaml",
@@ -0,0 +1,112 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+)
+
+func TestSplitBatches(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  []string
+		size int
+		want [][]string
+	}{
+		{
+			name: "empty",
+			size: 2,
+		},
+		{
+			name: "one by one",
+			ips:  []string{"1", "2", "3"},
+			size: 1,
+			want: [][]string{{"1"}, {"2"}, {"3"}},
+		},
+		{
+			name: "uneven",
+			ips:  []string{"1", "2", "3"},
+			size: 2,
+			want: [][]string{{"1", "2"}, {"3"}},
+		},
+		{
+			name: "no limit",
+			ips:  []string{"1", "2", "3"},
+			size: 0,
+			want: [][]string{{"1", "2", "3"}},
+		},
+		{
+			name: "larger than nodes",
+			ips:  []string{"1", "2"},
+			size: 5,
+			want: [][]string{{"1", "2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitBatches(tt.ips, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitBatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUpgradeStrategy(t *testing.T) {
+	k := &KubeadmRuntime{Cluster: &v2.Cluster{}}
+	strategy := k.getUpgradeStrategy()
+	if strategy.MaxUnavailable != 1 || strategy.DrainTimeout.Duration != defaultUpgradeDrainTimeout ||
+		strategy.NodeReadyTimeout.Duration != defaultUpgradeNodeReadyTimeout {
+		t.Errorf("getUpgradeStrategy() unexpected defaults %+v", strategy)
+	}
+
+	k.Cluster.Spec.Upgrade = &v2.UpgradeStrategy{
+		MaxUnavailable: 3,
+		DrainTimeout:   &metaV1.Duration{Duration: time.Minute},
+	}
+	strategy = k.getUpgradeStrategy()
+	if strategy.MaxUnavailable != 3 || strategy.DrainTimeout.Duration != time.Minute {
+		t.Errorf("getUpgradeStrategy() = %+v, want values from spec", strategy)
+	}
+	if k.Cluster.Spec.Upgrade.NodeReadyTimeout != nil {
+		t.Errorf("getUpgradeStrategy() should not modify the cluster spec")
+	}
+}
+
+func TestRecordDrained(t *testing.T) {
+	u := &nodeUpgrader{
+		KubeadmRuntime: &KubeadmRuntime{Mutex: &sync.Mutex{}, Cluster: &v2.Cluster{}},
+		unschedulable:  map[string]bool{"192.168.0.4:22": true},
+	}
+	// nothing to record without backup
+	u.recordDrained("192.168.0.2:22")
+
+	u.Cluster.Status.UpgradeBackup = &v2.UpgradeBackup{}
+	u.recordDrained("192.168.0.2:22")
+	u.recordDrained("192.168.0.3:22")
+	u.recordDrained("192.168.0.2:22")
+	// cordoned before upgrading, it is kept cordoned after rolled back
+	u.recordDrained("192.168.0.4:22")
+	want := []string{"192.168.0.2:22", "192.168.0.3:22"}
+	if got := u.Cluster.Status.UpgradeBackup.DrainedHosts; !reflect.DeepEqual(got, want) {
+		t.Errorf("recordDrained() drained hosts = %v, want %v", got, want)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/labring/sealos/pkg/utils/versionutil"
 
 	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/events"
 	"github.com/labring/sealos/pkg/utils/logger"
 
 	"golang.org/x/sync/errgroup"
@@ -41,9 +42,11 @@ const (
 	KUBESCHEDULERCONFIGFILE  = "/etc/kubernetes/scheduler.conf"
 )
 
-func (k *KubeadmRuntime) pipeline(name string, pipeline []func() error) error {
+func (k *KubeadmRuntime) pipeline(name string, pipeline []func() error) (err error) {
+	step := events.StartStep(events.SourceRuntime, name, k.getMaster0IPAndPort())
+	defer func() { step.Finish(err) }()
 	for _, f := range pipeline {
-		if err := f(); err != nil {
+		if err = f(); err != nil {
 			return fmt.Errorf("failed to %s %v", name, err)
 		}
 	}
@@ -109,7 +112,7 @@ func (k *KubeadmRuntime) sendNewCertAndKey(hosts []string) error {
 }
 
 func (k *KubeadmRuntime) sendFileToHosts(Hosts []string, src, dst string) error {
-	eg, _ := errgroup.WithContext(context.Background())
+	eg, _ := errgroup.WithContext(k.ctx)
 	for _, node := range Hosts {
 		node := node
 		eg.Go(func() error {
@@ -0,0 +1,150 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	fileutils "github.com/labring/sealos/pkg/utils/file"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const certificateSuffix = "-cert.pub"
+
+// loadSigners returns the signers of the private key along with its certificate, the default keys
+// are loaded instead if neither the raw data nor the file of the private key is usable.
+func loadSigners(opt *Option) ([]ssh.Signer, error) {
+	if len(opt.rawPrivateKeyData) > 0 {
+		signer, err := parsePrivateKey([]byte(opt.rawPrivateKeyData), []byte(opt.passphrase))
+		if err != nil {
+			return nil, err
+		}
+		return withCertificate(signer, opt.certificate, true)
+	}
+	if len(opt.privateKey) > 0 {
+		if fileutils.IsExist(opt.privateKey) {
+			signer, err := parsePrivateKeyFile(opt.privateKey, opt.passphrase)
+			if err != nil {
+				return nil, err
+			}
+			if opt.certificate != "" {
+				return withCertificate(signer, opt.certificate, true)
+			}
+			return withCertificate(signer, opt.privateKey+certificateSuffix, false)
+		}
+		logger.Debug("private key %s does not exist, trying the default keys", opt.privateKey)
+	}
+	var signers []ssh.Signer
+	for _, f := range opt.identityFiles {
+		signer, err := parsePrivateKeyFile(f, opt.passphrase)
+		if err != nil {
+			logger.Debug("skip the default private key %s: %v", f, err)
+			continue
+		}
+		cert := f + certificateSuffix
+		if opt.certificate != "" {
+			cert = opt.certificate
+		}
+		s, err := withCertificate(signer, cert, false)
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, s...)
+	}
+	return signers, nil
+}
+
+// withCertificate returns the signer of the certificate followed by the signer itself, the certificate is
+// skipped if it is not required and missing or not of the key.
+func withCertificate(signer ssh.Signer, file string, required bool) ([]ssh.Signer, error) {
+	if file == "" || (!required && !fileutils.IsExist(file)) {
+		return []ssh.Signer{signer}, nil
+	}
+	certSigner, err := newCertSigner(signer, file)
+	if err != nil {
+		if required {
+			return nil, err
+		}
+		logger.Debug("skip certificate %s: %v", file, err)
+		return []ssh.Signer{signer}, nil
+	}
+	return []ssh.Signer{certSigner, signer}, nil
+}
+
+func newCertSigner(signer ssh.Signer, file string) (ssh.Signer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file %v", err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %v", file, err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an OpenSSH certificate", file)
+	}
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("certificate %s is not of the private key: %v", file, err)
+	}
+	return certSigner, nil
+}
+
+// agentClient is a client of the ssh-agent with its connection, which is closed when the client is dropped.
+type agentClient struct {
+	agent.ExtendedAgent
+	conn net.Conn
+}
+
+// agents are the clients of the ssh-agents indexed by the sockets, which are shared by all the connections.
+var agents = struct {
+	sync.Mutex
+	clients map[string]*agentClient
+}{clients: make(map[string]*agentClient)}
+
+// agentSigners returns the signers of the keys in the ssh-agent listening on the socket, an unavailable
+// agent is not an error since the other methods might work, and it is connected again next time.
+func agentSigners(socket string) []ssh.Signer {
+	if socket == "" {
+		return nil
+	}
+	agents.Lock()
+	defer agents.Unlock()
+	client, ok := agents.clients[socket]
+	if !ok {
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			logger.Debug("failed to connect ssh-agent %s: %v", socket, err)
+			return nil
+		}
+		client = &agentClient{ExtendedAgent: agent.NewClient(conn), conn: conn}
+		agents.clients[socket] = client
+	}
+	signers, err := client.Signers()
+	if err != nil {
+		logger.Debug("failed to list the keys of ssh-agent %s: %v", socket, err)
+		_ = client.conn.Close()
+		delete(agents.clients, socket)
+		return nil
+	}
+	return signers
+}
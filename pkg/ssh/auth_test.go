@@ -0,0 +1,186 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// writeTestKey writes a new private key to the file, and returns its signer.
+func writeTestKey(t *testing.T, file string) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// writeTestCert writes a user certificate of the key signed by a new CA to the file.
+func writeTestCert(t *testing.T, file string, key ssh.PublicKey) {
+	ca := writeTestKey(t, filepath.Join(t.TempDir(), "ca"))
+	cert := &ssh.Certificate{
+		Key:             key,
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"root"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, ssh.MarshalAuthorizedKey(cert), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signerTypes(signers []ssh.Signer) []string {
+	var types []string
+	for _, s := range signers {
+		types = append(types, s.PublicKey().Type())
+	}
+	return types
+}
+
+func TestLoadSigners(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ed25519")
+	signer := writeTestKey(t, key)
+	other := filepath.Join(dir, "id_ecdsa")
+	writeTestKey(t, other)
+	writeTestCert(t, key+certificateSuffix, signer.PublicKey())
+
+	tests := []struct {
+		name      string
+		opt       *Option
+		wantTypes []string
+		wantErr   bool
+	}{
+		{
+			name:      "private key with certificate next to it",
+			opt:       &Option{privateKey: key},
+			wantTypes: []string{ssh.CertAlgoED25519v01, ssh.KeyAlgoED25519},
+		},
+		{
+			name:      "default keys if private key does not exist",
+			opt:       &Option{privateKey: filepath.Join(dir, "id_rsa"), identityFiles: []string{other, key}},
+			wantTypes: []string{ssh.KeyAlgoED25519, ssh.CertAlgoED25519v01, ssh.KeyAlgoED25519},
+		},
+		{
+			name:      "certificate of another key is skipped for default keys",
+			opt:       &Option{identityFiles: []string{other}, certificate: key + certificateSuffix},
+			wantTypes: []string{ssh.KeyAlgoED25519},
+		},
+		{
+			name:    "certificate of another key",
+			opt:     &Option{privateKey: other, certificate: key + certificateSuffix},
+			wantErr: true,
+		},
+		{
+			name:    "missing certificate",
+			opt:     &Option{privateKey: key, certificate: filepath.Join(dir, "missing-cert.pub")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signers, err := loadSigners(tt.opt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadSigners() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := signerTypes(signers); !tt.wantErr && !reflect.DeepEqual(got, tt.wantTypes) {
+				t.Errorf("loadSigners() = %v, want %v", got, tt.wantTypes)
+			}
+		})
+	}
+}
+
+func TestAgentSigners(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := agent.NewKeyring()
+	if err = keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	served := make(chan net.Conn, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			served <- conn
+			go func() {
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+
+	if signers := agentSigners(socket); len(signers) != 1 {
+		t.Errorf("got %d signers of the agent, want 1", len(signers))
+	}
+	agents.Lock()
+	cached := agents.clients[socket]
+	agents.Unlock()
+	if cached == nil {
+		t.Fatalf("client of the agent is not cached")
+	}
+	// the agent hangs up, the cached client is dropped with its connection closed
+	_ = (<-served).Close()
+	if signers := agentSigners(socket); len(signers) != 0 {
+		t.Errorf("got %d signers of a hung up agent, want 0", len(signers))
+	}
+	agents.Lock()
+	_, ok := agents.clients[socket]
+	agents.Unlock()
+	if ok {
+		t.Errorf("client of the hung up agent is still cached")
+	}
+	if _, err = cached.conn.Write([]byte{0}); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("connection of the dropped client is not closed, write error: %v", err)
+	}
+	if signers := agentSigners(filepath.Join(t.TempDir(), "missing.sock")); len(signers) != 0 {
+		t.Errorf("got %d signers of a missing agent, want 0", len(signers))
+	}
+}
@@ -15,6 +15,7 @@
 package ssh
 
 import (
+	"context"
 	"strings"
 	"sync"
 
@@ -27,6 +28,17 @@ type clusterClient struct {
 	configs  map[string]*Option
 	cache    map[*Option]Interface
 	mutex    sync.RWMutex
+	// retry overrides the default retry policy of the clients if set
+	retry *RetryPolicy
+}
+
+func newClusterClient(cluster *v1beta1.Cluster, isStdout bool) *clusterClient {
+	return &clusterClient{
+		cluster:  cluster,
+		isStdout: isStdout,
+		configs:  make(map[string]*Option),
+		cache:    make(map[*Option]Interface),
+	}
 }
 
 func overSSHConfig(original, override *v1beta1.SSH) {
@@ -46,9 +58,18 @@ func overSSHConfig(original, override *v1beta1.SSH) {
 		if override.PkPasswd != "" {
 			original.PkPasswd = override.PkPasswd
 		}
+		if override.PkCert != "" {
+			original.PkCert = override.PkCert
+		}
 		if override.Port > 0 {
 			original.Port = override.Port
 		}
+		if len(override.ProxyJump) > 0 {
+			original.ProxyJump = override.ProxyJump
+		}
+		if override.Proxy != "" {
+			original.Proxy = override.Proxy
+		}
 	}
 }
 
@@ -69,6 +90,7 @@ func (cc *clusterClient) getSSHOptionForHost(host string) (*Option, error) {
 		}
 	}
 	opt := newOptionFromSSH(sshConfig, cc.isStdout)
+	WithKnownHostsFile(KnownHostsFile(cc.cluster.Name))(opt)
 	cc.mutex.Lock()
 	cc.configs[host] = opt
 	cc.mutex.Unlock()
@@ -85,10 +107,12 @@ func (cc *clusterClient) getClientForHost(host string) (Interface, error) {
 	cc.mutex.RUnlock()
 	if client == nil {
 		var err error
-		client, err = newFromOptions(sshConfig)
+		c, err := newFromOptions(sshConfig)
 		if err != nil {
 			return nil, err
 		}
+		c.retry = cc.retry
+		client = c
 		cc.mutex.Lock()
 		cc.cache[sshConfig] = client
 		cc.mutex.Unlock()
@@ -96,50 +120,50 @@ func (cc *clusterClient) getClientForHost(host string) (Interface, error) {
 	return client, nil
 }
 
-func (cc *clusterClient) Copy(host, src, dst string) error {
+func (cc *clusterClient) Copy(ctx context.Context, host, src, dst string) error {
 	client, err := cc.getClientForHost(host)
 	if err != nil {
 		return err
 	}
-	return client.Copy(host, src, dst)
+	return client.Copy(ctx, host, src, dst)
 }
 
-func (cc *clusterClient) CopyR(host, src, dst string) error {
+func (cc *clusterClient) CopyR(ctx context.Context, host, src, dst string) error {
 	client, err := cc.getClientForHost(host)
 	if err != nil {
 		return err
 	}
-	return client.CopyR(host, src, dst)
+	return client.CopyR(ctx, host, src, dst)
 }
 
-func (cc *clusterClient) CmdAsync(host string, cmds ...string) error {
+func (cc *clusterClient) CmdAsync(ctx context.Context, host string, cmds ...string) error {
 	client, err := cc.getClientForHost(host)
 	if err != nil {
 		return err
 	}
-	return client.CmdAsync(host, cmds...)
+	return client.CmdAsync(ctx, host, cmds...)
 }
 
-func (cc *clusterClient) Cmd(host, cmd string) ([]byte, error) {
+func (cc *clusterClient) Cmd(ctx context.Context, host, cmd string) ([]byte, error) {
 	client, err := cc.getClientForHost(host)
 	if err != nil {
 		return nil, err
 	}
-	return client.Cmd(host, cmd)
+	return client.Cmd(ctx, host, cmd)
 }
 
-func (cc *clusterClient) CmdToString(host, cmd, sep string) (string, error) {
+func (cc *clusterClient) CmdToString(ctx context.Context, host, cmd, sep string) (string, error) {
 	client, err := cc.getClientForHost(host)
 	if err != nil {
 		return "", err
 	}
-	return client.CmdToString(host, cmd, sep)
+	return client.CmdToString(ctx, host, cmd, sep)
 }
 
-func (cc *clusterClient) Ping(host string) error {
+func (cc *clusterClient) Ping(ctx context.Context, host string) error {
 	client, err := cc.getClientForHost(host)
 	if err != nil {
 		return err
 	}
-	return client.Ping(host)
+	return client.Ping(ctx, host)
 }
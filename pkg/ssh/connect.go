@@ -15,29 +15,26 @@
 package ssh
 
 import (
+	"context"
 	"fmt"
-	"io"
 	"os"
 	"strings"
-	"time"
 
 	"golang.org/x/crypto/ssh"
 
 	"github.com/labring/sealos/pkg/unshare"
 	"github.com/labring/sealos/pkg/utils/iputils"
-	"github.com/labring/sealos/pkg/utils/logger"
 )
 
 func (c *Client) connect(host string) (*ssh.Client, error) {
 	ip, port := iputils.GetSSHHostIPAndPort(host)
 	addr := formalizeAddr(ip, port)
-	return ssh.Dial("tcp", addr, c.ClientConfig)
+	return c.dialThrough(addr, c.ClientConfig)
 }
 
 func newSession(client *ssh.Client) (*ssh.Session, error) {
 	session, err := client.NewSession()
 	if err != nil {
-		_ = client.Close()
 		return nil, err
 	}
 	modes := ssh.TerminalModes{
@@ -47,55 +44,51 @@ func newSession(client *ssh.Client) (*ssh.Session, error) {
 	}
 	if err := session.RequestPty("xterm", 80, 40, modes); err != nil {
 		_ = session.Close()
-		_ = client.Close()
 		return nil, err
 	}
 	return session, nil
 }
 
 func (c *Client) Connect(host string) (sshClient *ssh.Client, session *ssh.Session, err error) {
-	err = exponentialBackOffRetry(defaultMaxRetry, time.Millisecond*100, 2, func() error {
+	err = c.retryPolicy().Do(context.Background(), func() error {
 		sshClient, session, err = c.newClientAndSession(host)
 		return err
-	}, isErrorWorthRetry)
+	})
 	return
 }
 
-func isErrorWorthRetry(err error) bool {
-	return strings.Contains(err.Error(), "connection reset by peer") ||
-		strings.Contains(err.Error(), io.EOF.Error())
-}
-
-func exponentialBackOffRetry(steps int, interval time.Duration, factor int,
-	fn func() error,
-	retryIfCertainError func(error) bool) error {
-	var err error
-	for i := 0; i < steps; i++ {
-		if i > 0 {
-			logger.Debug("retrying %s later due to error occur: %v", interval, err)
-			time.Sleep(interval)
-			interval *= time.Duration(factor)
-		}
-		if err = fn(); err != nil {
-			if retryIfCertainError(err) {
-				continue
-			}
-			return err
-		}
-		break
-	}
-	return err
-}
-
 func (c *Client) newClientAndSession(host string) (*ssh.Client, *ssh.Session, error) {
 	sshClient, err := c.connect(host)
 	if err != nil {
 		return nil, nil, err
 	}
 	session, err := newSession(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+	}
 	return sshClient, session, err
 }
 
+// pooledSession returns a new session on a pooled connection to the host, release must be called once
+// the session is closed.
+func (c *Client) pooledSession(host string) (session *ssh.Session, release func(), err error) {
+	release, err = c.pooled(host, func(client *ssh.Client) (err error) {
+		session, err = newSession(client)
+		return err
+	})
+	return session, release, err
+}
+
+// retrySession returns a pooled session to the host, only the session is retried by the retry policy,
+// the commands are never retried once started since they might not be idempotent.
+func (c *Client) retrySession(ctx context.Context, host string) (session *ssh.Session, release func(), err error) {
+	err = c.retryPolicy().Do(ctx, func() error {
+		session, release, err = c.pooledSession(host)
+		return err
+	})
+	return session, release, err
+}
+
 func (c *Client) isLocalAction(host string) bool {
 	return !unshare.IsRootless() && getLocalAddresses() != nil && iputils.IsLocalIP(host, getLocalAddresses())
 }
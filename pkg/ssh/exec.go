@@ -35,7 +35,7 @@ type Exec struct {
 func NewExecCmdFromRoles(cluster *v2.Cluster, roles string) (Exec, error) {
 	var ipList []string
 	if roles == "" {
-		ipList = append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...)
+		ipList = append(append(cluster.GetMasterIPAndPortList(), cluster.GetNodeIPAndPortList()...), cluster.GetEtcdIPAndPortList()...)
 	} else {
 		roleList := strings.Split(roles, ",")
 		for _, role := range roleList {
@@ -53,13 +53,13 @@ func NewExecCmdFromIPs(cluster *v2.Cluster, ips []string) (Exec, error) {
 	return Exec{cluster: cluster, ipList: ips}, nil
 }
 
-func (e *Exec) RunCmd(cmd string) error {
-	sshClient := NewSSHClient(&e.cluster.Spec.SSH, true)
-	eg, _ := errgroup.WithContext(context.Background())
+func (e *Exec) RunCmd(ctx context.Context, cmd string) error {
+	sshClient := NewSSHClientWithCluster(e.cluster, true)
+	eg, ctx := errgroup.WithContext(ctx)
 	for _, ipAddr := range e.ipList {
 		ip := ipAddr
 		eg.Go(func() error {
-			return sshClient.CmdAsync(ip, cmd)
+			return sshClient.CmdAsync(ctx, ip, cmd)
 		})
 	}
 	if err := eg.Wait(); err != nil {
@@ -68,13 +68,13 @@ func (e *Exec) RunCmd(cmd string) error {
 	return nil
 }
 
-func (e *Exec) RunCopy(srcFilePath, dstFilePath string) error {
-	sshClient := NewSSHClient(&e.cluster.Spec.SSH, true)
-	eg, _ := errgroup.WithContext(context.Background())
+func (e *Exec) RunCopy(ctx context.Context, srcFilePath, dstFilePath string) error {
+	sshClient := NewSSHClientWithCluster(e.cluster, true)
+	eg, ctx := errgroup.WithContext(ctx)
 	for _, ipAddr := range e.ipList {
 		ip := ipAddr
 		eg.Go(func() error {
-			return sshClient.Copy(ip, srcFilePath, dstFilePath)
+			return sshClient.Copy(ctx, ip, srcFilePath, dstFilePath)
 		})
 	}
 	if err := eg.Wait(); err != nil {
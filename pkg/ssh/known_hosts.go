@@ -0,0 +1,214 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/labring/sealos/pkg/constants"
+	"github.com/labring/sealos/pkg/system"
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+// HostKeyPolicy is how the host keys are verified by the known_hosts file of the cluster.
+type HostKeyPolicy string
+
+const (
+	// HostKeyPolicyStrict rejects the hosts which are not in the known_hosts file.
+	HostKeyPolicyStrict HostKeyPolicy = "strict"
+	// HostKeyPolicyAcceptNew records the keys of the hosts on first contact, and rejects the changed ones.
+	HostKeyPolicyAcceptNew HostKeyPolicy = "accept-new"
+	// HostKeyPolicyInsecure accepts any host keys.
+	HostKeyPolicyInsecure HostKeyPolicy = "insecure"
+)
+
+const knownHostsFileName = "known_hosts"
+
+// knownHostsLock serializes the updates of the known_hosts files, since the hosts are connected in parallel.
+var knownHostsLock sync.Mutex
+
+// KnownHostsFile returns the path of the known_hosts file of the cluster, which is next to its Clusterfile.
+func KnownHostsFile(clusterName string) string {
+	return filepath.Join(constants.ClusterDir(clusterName), knownHostsFileName)
+}
+
+// GetHostKeyPolicy returns the policy set in the config of sealos.
+func GetHostKeyPolicy() (HostKeyPolicy, error) {
+	v, err := system.Get(system.HostKeyPolicyConfigKey)
+	if err != nil {
+		return "", err
+	}
+	switch p := HostKeyPolicy(v); p {
+	case HostKeyPolicyStrict, HostKeyPolicyAcceptNew, HostKeyPolicyInsecure:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown ssh host key policy %s, must be one of %s, %s and %s", v, HostKeyPolicyStrict, HostKeyPolicyAcceptNew, HostKeyPolicyInsecure)
+	}
+}
+
+// newKnownHostsCallback returns a host key callback which verifies the keys by the known_hosts file with the policy.
+func newKnownHostsCallback(file string, policy HostKeyPolicy) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if policy == HostKeyPolicyInsecure {
+			return nil
+		}
+		knownHostsLock.Lock()
+		defer knownHostsLock.Unlock()
+		err := checkKnownHost(file, hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		if len(keyErr.Want) > 0 {
+			want := keyErr.Want[0]
+			return fmt.Errorf("host key of %s has changed to %s %s, but %s is expected by %s:%d. "+
+				"The host might be re-imaged or someone is eavesdropping on it, run `sealos ssh-keys rescan --hosts %s` if the new key is trusted",
+				hostname, key.Type(), ssh.FingerprintSHA256(key), ssh.FingerprintSHA256(want.Key), want.Filename, want.Line, hostname)
+		}
+		if policy == HostKeyPolicyStrict {
+			return fmt.Errorf("host key of %s is not found in %s, run `sealos ssh-keys rescan --hosts %s` to trust it", hostname, file, hostname)
+		}
+		logger.Info("add host key %s %s of %s to %s", key.Type(), ssh.FingerprintSHA256(key), hostname, file)
+		return appendKnownHosts(file, map[string]ssh.PublicKey{hostname: key})
+	}
+}
+
+func checkKnownHost(file, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return &knownhosts.KeyError{}
+	}
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return fmt.Errorf("failed to load known hosts %s: %v", file, err)
+	}
+	return callback(hostname, remote, key)
+}
+
+func appendKnownHosts(file string, keys map[string]ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for addr, key := range keys {
+		if _, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(addr)}, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplaceKnownHosts removes the keys of the hosts from the known_hosts file and adds the new ones,
+// the entries of the other hosts are kept as they are.
+func ReplaceKnownHosts(file string, keys map[string]ssh.PublicKey) error {
+	knownHostsLock.Lock()
+	defer knownHostsLock.Unlock()
+	replaced := make(map[string]bool, len(keys))
+	for addr := range keys {
+		replaced[knownhosts.Normalize(addr)] = true
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var kept bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !isKnownHostsLineOf(line, replaced) {
+			kept.WriteString(line + "\n")
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(file, kept.Bytes(), 0600); err != nil {
+		return err
+	}
+	return appendKnownHosts(file, keys)
+}
+
+func isKnownHostsLineOf(line string, hosts map[string]bool) bool {
+	if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return false
+	}
+	_, patterns, _, _, _, err := ssh.ParseKnownHosts([]byte(line))
+	if err != nil {
+		return false
+	}
+	for _, p := range patterns {
+		if hosts[p] {
+			return true
+		}
+	}
+	return false
+}
+
+var errHostKeyScanned = errors.New("host key scanned")
+
+// ScanHostKey returns the host key of the host of the cluster without authenticating, host is the address with
+// an optional ssh port. The bastions and proxy of the host are connected through, the host keys of the bastions
+// are still verified. The returned address is the one recorded in the known_hosts file.
+func ScanHostKey(cluster *v2.Cluster, host string, timeout time.Duration) (string, ssh.PublicKey, error) {
+	opt, err := newClusterClient(cluster, false).getSSHOptionForHost(host)
+	if err != nil {
+		return "", nil, err
+	}
+	scanOpt := *opt
+	WithTimeout(timeout)(&scanOpt)
+	client, err := newFromOptions(&scanOpt)
+	if err != nil {
+		return "", nil, err
+	}
+	ip, port := iputils.GetSSHHostIPAndPort(host)
+	addr := formalizeAddr(ip, port)
+	var hostKey ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User:    client.User,
+		Timeout: client.Timeout,
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errHostKeyScanned
+		},
+	}
+	sshClient, err := client.dialThrough(addr, config)
+	if err == nil {
+		_ = sshClient.Close()
+	}
+	if hostKey == nil {
+		return "", nil, fmt.Errorf("failed to scan host key of %s: %v", host, err)
+	}
+	return addr, hostKey, nil
+}
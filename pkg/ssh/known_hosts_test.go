@@ -0,0 +1,89 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("192.168.0.2"), Port: 22}
+	key, changed := newTestHostKey(t), newTestHostKey(t)
+
+	file := filepath.Join(t.TempDir(), knownHostsFileName)
+	if err := newKnownHostsCallback(file, HostKeyPolicyStrict)("192.168.0.2:22", addr, key); err == nil {
+		t.Errorf("strict policy accepts unknown host")
+	}
+	acceptNew := newKnownHostsCallback(file, HostKeyPolicyAcceptNew)
+	if err := acceptNew("192.168.0.2:22", addr, key); err != nil {
+		t.Fatalf("accept-new policy rejects unknown host: %v", err)
+	}
+	if err := newKnownHostsCallback(file, HostKeyPolicyStrict)("192.168.0.2:22", addr, key); err != nil {
+		t.Errorf("strict policy rejects known host: %v", err)
+	}
+	err := acceptNew("192.168.0.2:22", addr, changed)
+	if err == nil || !strings.Contains(err.Error(), "192.168.0.2:22") {
+		t.Errorf("accept-new policy error of changed key = %v", err)
+	}
+	if err = newKnownHostsCallback(file, HostKeyPolicyInsecure)("192.168.0.2:22", addr, changed); err != nil {
+		t.Errorf("insecure policy rejects changed key: %v", err)
+	}
+}
+
+func TestReplaceKnownHosts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), knownHostsFileName)
+	old, other, key := newTestHostKey(t), newTestHostKey(t), newTestHostKey(t)
+	if err := appendKnownHosts(file, map[string]ssh.PublicKey{"192.168.0.2:22": old, "192.168.0.3:2222": other}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceKnownHosts(file, map[string]ssh.PublicKey{"192.168.0.2:22": key}); err != nil {
+		t.Fatalf("ReplaceKnownHosts() error = %v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("known hosts = %q, want 2 lines", data)
+	}
+	callback := newKnownHostsCallback(file, HostKeyPolicyStrict)
+	if err = callback("192.168.0.2:22", &net.TCPAddr{IP: net.ParseIP("192.168.0.2"), Port: 22}, key); err != nil {
+		t.Errorf("replaced key is rejected: %v", err)
+	}
+	if err = callback("192.168.0.3:2222", &net.TCPAddr{IP: net.ParseIP("192.168.0.3"), Port: 2222}, other); err != nil {
+		t.Errorf("key of other host is rejected: %v", err)
+	}
+}
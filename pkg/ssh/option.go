@@ -16,6 +16,7 @@ package ssh
 
 import (
 	"net"
+	"os"
 	"path"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/spf13/pflag"
 	"golang.org/x/crypto/ssh"
 
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
 	"github.com/labring/sealos/pkg/utils/file"
 )
 
@@ -36,6 +38,18 @@ type Option struct {
 	passphrase        string
 	timeout           time.Duration
 	hostKeyCallback   ssh.HostKeyCallback
+	// knownHostsFile verifies the host keys with the policy in the config, it overrides hostKeyCallback if set.
+	knownHostsFile string
+	// proxyJumps are the bastions to connect through in order
+	proxyJumps []v2.SSHJump
+	// proxy is the url of the SOCKS5 or HTTP CONNECT proxy to connect through
+	proxy string
+	// identityFiles are the default private keys tried if neither privateKey nor rawPrivateKeyData is usable
+	identityFiles []string
+	// certificate is the OpenSSH certificate of the private key, <privateKey>-cert.pub is used if not set
+	certificate string
+	// agentSocket is the socket of the ssh-agent whose keys are tried, empty means not using any agent
+	agentSocket string
 }
 
 func (o *Option) BindFlags(fs *pflag.FlagSet) {
@@ -44,30 +58,39 @@ func (o *Option) BindFlags(fs *pflag.FlagSet) {
 	fs.StringVarP(&o.user, "user", "u", o.user, "username to authenticate as")
 	fs.StringVarP(&o.password, "password", "p", o.password, "use given password to authenticate with")
 	fs.StringVarP(&o.privateKey, "private-key", "i", o.privateKey,
-		"selects a file from which the identity (private key) for public key authentication is read")
+		"selects a file from which the identity (private key) for public key authentication is read, "+
+			"the default keys in ~/.ssh are tried if not set")
+	fs.StringVar(&o.certificate, "certificate", o.certificate,
+		"selects a file from which the OpenSSH certificate of the private key is read, default to <private-key>-cert.pub")
 	fs.StringVar(&o.passphrase, "passphrase", o.passphrase, "passphrase for decrypting a PEM encoded private key")
 	fs.DurationVar(&o.timeout, "timeout", o.timeout, "ssh connection timeout")
 }
 
 const (
 	defaultUsername = "root"
+	agentSocketEnv  = "SSH_AUTH_SOCK"
 )
 
+// defaultIdentityFiles are the private keys in ~/.ssh tried by default, in the same order as OpenSSH.
+var defaultIdentityFiles = []string{"id_rsa", "id_ecdsa", "id_ed25519", "id_dsa"}
+
 func NewOption() *Option {
 	homedir := homedir.Get()
-	getSSHFile := func(filenames ...string) string {
+	getSSHFiles := func(filenames ...string) []string {
+		var files []string
 		for _, fn := range filenames {
 			absPath := path.Join(homedir, ".ssh", fn)
 			if file.IsExist(absPath) {
-				return absPath
+				files = append(files, absPath)
 			}
 		}
-		return ""
+		return files
 	}
 	opt := &Option{
-		user:       defaultUsername,
-		privateKey: getSSHFile("id_rsa", "id_dsa"),
-		timeout:    10 * time.Second,
+		user:          defaultUsername,
+		identityFiles: getSSHFiles(defaultIdentityFiles...),
+		agentSocket:   os.Getenv(agentSocketEnv),
+		timeout:       10 * time.Second,
 		hostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
 			return nil
 		},
@@ -129,3 +152,38 @@ func WithHostKeyCallback(fn ssh.HostKeyCallback) OptionFunc {
 		o.hostKeyCallback = fn
 	}
 }
+
+// WithKnownHostsFile verifies the host keys by the known_hosts file with the policy in the config of sealos.
+func WithKnownHostsFile(file string) OptionFunc {
+	return func(o *Option) {
+		o.knownHostsFile = file
+	}
+}
+
+// WithProxyJump connects through the bastions in order.
+func WithProxyJump(jumps ...v2.SSHJump) OptionFunc {
+	return func(o *Option) {
+		o.proxyJumps = jumps
+	}
+}
+
+// WithProxy connects through the SOCKS5 or HTTP CONNECT proxy of the url.
+func WithProxy(url string) OptionFunc {
+	return func(o *Option) {
+		o.proxy = url
+	}
+}
+
+// WithCertificate authenticates with the OpenSSH certificate of the private key.
+func WithCertificate(file string) OptionFunc {
+	return func(o *Option) {
+		o.certificate = file
+	}
+}
+
+// WithAgentSocket authenticates with the keys of the ssh-agent listening on the socket, empty disables the agent.
+func WithAgentSocket(socket string) OptionFunc {
+	return func(o *Option) {
+		o.agentSocket = socket
+	}
+}
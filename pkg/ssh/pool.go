@@ -0,0 +1,270 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/labring/sealos/pkg/system"
+	"github.com/labring/sealos/pkg/utils/hash"
+	"github.com/labring/sealos/pkg/utils/iputils"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	defaultMaxSessions  = 8
+	defaultIdleTimeout  = time.Minute
+	keepAliveRequestKey = "keepalive@openssh.com"
+)
+
+var keepAliveInterval = 15 * time.Second
+
+// PoolStats are the counters of the ssh connection pool.
+type PoolStats struct {
+	// Handshakes is the number of the ssh connections established.
+	Handshakes int64
+	// Reused is the number of the handshakes saved by reusing the pooled connections.
+	Reused int64
+	// Evicted is the number of the pooled connections closed for being idle or broken.
+	Evicted int64
+}
+
+// connPool shares the ssh connections among the clients, the connections are keyed by the address
+// and credentials, and each of them multiplexes a limited number of sessions at the same time.
+type connPool struct {
+	mu    sync.Mutex
+	conns map[string][]*pooledConn
+
+	handshakes int64
+	reused     int64
+	evicted    int64
+}
+
+type pooledConn struct {
+	client   *ssh.Client
+	key      string
+	sessions int
+	lastUsed time.Time
+	// retired connections are never handed out again, and closed once all of their sessions are released
+	retired bool
+	closed  bool
+}
+
+var defaultPool = newConnPool()
+
+func newConnPool() *connPool {
+	return &connPool{conns: make(map[string][]*pooledConn)}
+}
+
+// GetPoolStats returns the counters of the ssh connection pool.
+func GetPoolStats() PoolStats {
+	return defaultPool.stats()
+}
+
+// ClosePool closes all the pooled ssh connections, the sessions in use are interrupted.
+func ClosePool() {
+	defaultPool.close()
+	if stats := defaultPool.stats(); stats.Handshakes > 0 {
+		logger.Debug("ssh connection pool: %d handshakes, %d saved, %d evicted", stats.Handshakes, stats.Reused, stats.Evicted)
+	}
+}
+
+func (p *connPool) stats() PoolStats {
+	return PoolStats{
+		Handshakes: atomic.LoadInt64(&p.handshakes),
+		Reused:     atomic.LoadInt64(&p.reused),
+		Evicted:    atomic.LoadInt64(&p.evicted),
+	}
+}
+
+// get returns a pooled connection of the key which has less than maxSessions sessions, nil if none.
+func (p *connPool) get(key string, maxSessions int) *pooledConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pc := range p.conns[key] {
+		if pc.sessions < maxSessions {
+			pc.sessions++
+			return pc
+		}
+	}
+	return nil
+}
+
+// put adds the new connection with one session in use, it is not pooled if pooled is false.
+func (p *connPool) put(key string, client *ssh.Client, pooled bool, idleTimeout time.Duration) *pooledConn {
+	atomic.AddInt64(&p.handshakes, 1)
+	pc := &pooledConn{client: client, key: key, sessions: 1, lastUsed: time.Now(), retired: !pooled}
+	if !pooled {
+		return pc
+	}
+	p.mu.Lock()
+	p.conns[key] = append(p.conns[key], pc)
+	p.mu.Unlock()
+	go p.keepAlive(pc, keepAliveInterval, idleTimeout)
+	return pc
+}
+
+// release returns a session of the connection, the connection is retired if it is broken.
+func (p *connPool) release(pc *pooledConn, broken bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc.sessions--
+	pc.lastUsed = time.Now()
+	if broken {
+		p.retire(pc)
+	}
+	p.closeIfUnused(pc)
+}
+
+// retire removes the connection from the pool, the caller must hold the lock.
+func (p *connPool) retire(pc *pooledConn) {
+	if pc.retired {
+		return
+	}
+	pc.retired = true
+	atomic.AddInt64(&p.evicted, 1)
+	conns := p.conns[pc.key]
+	for i := range conns {
+		if conns[i] == pc {
+			conns = append(conns[:i], conns[i+1:]...)
+			break
+		}
+	}
+	if len(conns) == 0 {
+		delete(p.conns, pc.key)
+	} else {
+		p.conns[pc.key] = conns
+	}
+}
+
+// closeIfUnused closes the retired connection if no sessions are in use, the caller must hold the lock.
+func (p *connPool) closeIfUnused(pc *pooledConn) {
+	if pc.retired && pc.sessions <= 0 && !pc.closed {
+		pc.closed = true
+		_ = pc.client.Close()
+	}
+}
+
+// keepAlive keeps the connection alive until it is retired, the connection is evicted once it is
+// broken or idle for longer than idleTimeout.
+func (p *connPool) keepAlive(pc *pooledConn, interval, idleTimeout time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.mu.Lock()
+		if pc.retired {
+			p.mu.Unlock()
+			return
+		}
+		if pc.sessions <= 0 && time.Since(pc.lastUsed) > idleTimeout {
+			p.retire(pc)
+			p.closeIfUnused(pc)
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+		if _, _, err := pc.client.SendRequest(keepAliveRequestKey, true, nil); err != nil {
+			logger.Debug("pooled ssh connection to %s is broken: %v", pc.client.RemoteAddr(), err)
+			p.mu.Lock()
+			p.retire(pc)
+			p.closeIfUnused(pc)
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conns := range p.conns {
+		for _, pc := range conns {
+			pc.retired = true
+			pc.closed = true
+			_ = pc.client.Close()
+		}
+	}
+	p.conns = make(map[string][]*pooledConn)
+}
+
+// getMaxSessions returns the max number of sessions multiplexed on a pooled connection, 0 means no pooling.
+func getMaxSessions() int {
+	v, err := system.Get(system.SSHMaxSessionsConfigKey)
+	if err != nil {
+		return defaultMaxSessions
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		logger.Warn("invalid %s %s, use %d instead", system.SSHMaxSessionsConfigKey, v, defaultMaxSessions)
+		return defaultMaxSessions
+	}
+	return n
+}
+
+func getIdleTimeout() time.Duration {
+	v, err := system.Get(system.SSHIdleTimeoutConfigKey)
+	if err != nil {
+		return defaultIdleTimeout
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		logger.Warn("invalid %s %s, use %s instead", system.SSHIdleTimeoutConfigKey, v, defaultIdleTimeout)
+		return defaultIdleTimeout
+	}
+	return d
+}
+
+// poolKey identifies the connections which are able to be shared, which are of the same address and credentials.
+func (c *Client) poolKey(host string) string {
+	ip, port := iputils.GetSSHHostIPAndPort(host)
+	o := c.Option
+	return hash.Digest([]byte(fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%s|%v|%s|%+v",
+		formalizeAddr(ip, port), o.user, o.password, o.privateKey, o.rawPrivateKeyData, o.passphrase, o.certificate,
+		o.agentSocket, o.knownHostsFile, o.identityFiles, o.proxy, o.proxyJumps)))
+}
+
+// pooled calls open with a pooled connection to the host, a new connection is made if none is available
+// or the pooled one is broken. release must be called once the resources opened are closed. The errors
+// of the new connection are returned to be retried by the callers.
+func (c *Client) pooled(host string, open func(*ssh.Client) error) (release func(), err error) {
+	maxSessions := getMaxSessions()
+	key := c.poolKey(host)
+	if maxSessions > 0 {
+		if pc := defaultPool.get(key, maxSessions); pc != nil {
+			if err = open(pc.client); err == nil {
+				atomic.AddInt64(&defaultPool.reused, 1)
+				return func() { defaultPool.release(pc, false) }, nil
+			}
+			logger.Debug("failed to reuse the ssh connection to %s, reconnecting: %v", host, err)
+			defaultPool.release(pc, true)
+		}
+	}
+	client, err := c.connect(host)
+	if err != nil {
+		return nil, err
+	}
+	pc := defaultPool.put(key, client, maxSessions > 0, getIdleTimeout())
+	if err = open(client); err != nil {
+		defaultPool.release(pc, true)
+		return nil, err
+	}
+	return func() { defaultPool.release(pc, false) }, nil
+}
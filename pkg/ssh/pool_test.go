@@ -0,0 +1,184 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer accepts any clients and runs any commands successfully.
+type testSSHServer struct {
+	addr  string
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newTestSSHServer(t *testing.T) *testSSHServer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSSHServer{addr: l.Addr().String()}
+	t.Cleanup(func() {
+		_ = l.Close()
+		s.closeConns()
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		ch, requests, err := newChan.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				_ = req.Reply(true, nil)
+				if req.Type == "exec" {
+					_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+					_ = ch.Close()
+				}
+			}
+		}()
+	}
+}
+
+func (s *testSSHServer) numConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func (s *testSSHServer) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+func runPooledSession(t *testing.T, c *Client, host string) {
+	session, release, err := c.pooledSession(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	defer session.Close()
+	if err = session.Run("true"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConnPool(t *testing.T) {
+	defaultPool = newConnPool()
+	defer ClosePool()
+	server := newTestSSHServer(t)
+	c, err := New(NewOption(), WithUsername("root"), WithPrivateKeyAndPhrase("", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runPooledSession(t, c, server.addr)
+	runPooledSession(t, c, server.addr)
+	if n := server.numConns(); n != 1 {
+		t.Errorf("got %d connections for sequential sessions, want 1", n)
+	}
+	if stats := GetPoolStats(); stats.Handshakes != 1 || stats.Reused != 1 {
+		t.Errorf("stats = %+v, want 1 handshake and 1 reused", stats)
+	}
+
+	t.Setenv("SEALOS_SSH_MAX_SESSIONS", "1")
+	session, release, err := c.pooledSession(server.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runPooledSession(t, c, server.addr)
+	_ = session.Close()
+	release()
+	if n := server.numConns(); n != 2 {
+		t.Errorf("got %d connections when the pooled one is full, want 2", n)
+	}
+
+	server.closeConns()
+	runPooledSession(t, c, server.addr)
+	if n := server.numConns(); n != 3 {
+		t.Errorf("got %d connections after the pooled ones are broken, want 3", n)
+	}
+}
+
+func TestConnPoolEvictIdle(t *testing.T) {
+	interval := keepAliveInterval
+	keepAliveInterval = 10 * time.Millisecond
+	defer func() {
+		keepAliveInterval = interval
+	}()
+	server := newTestSSHServer(t)
+	client, err := ssh.Dial("tcp", server.addr, &ssh.ClientConfig{
+		User:            "root",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // #nosec
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newConnPool()
+	pc := p.put("key", client, true, 50*time.Millisecond)
+	p.release(pc, false)
+	if p.get("key", 1) != pc {
+		t.Fatal("the idle connection is not reused")
+	}
+	p.release(pc, false)
+	time.Sleep(500 * time.Millisecond)
+	if p.get("key", 1) != nil {
+		t.Errorf("the idle connection is not evicted")
+	}
+	if stats := p.stats(); stats.Evicted != 1 {
+		t.Errorf("stats = %+v, want 1 evicted", stats)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+
+	v2 "github.com/labring/sealos/pkg/types/v1beta1"
+	"github.com/labring/sealos/pkg/utils/iputils"
+)
+
+type dialFunc func(network, addr string) (net.Conn, error)
+
+// jumpHost is a bastion connected through before the target host.
+type jumpHost struct {
+	addr   string
+	config *ssh.ClientConfig
+}
+
+// setupProxy prepares the dialer of the proxy and the client configs of the bastions.
+func (c *Client) setupProxy() error {
+	dialer := &net.Dialer{Timeout: c.ClientConfig.Timeout}
+	c.dial = dialer.Dial
+	if c.Option.proxy != "" {
+		dial, err := newProxyDialer(c.Option.proxy, dialer)
+		if err != nil {
+			return err
+		}
+		c.dial = dial
+	}
+	c.jumps = nil
+	for _, j := range c.Option.proxyJumps {
+		if j.Host == "" {
+			return fmt.Errorf("host of proxy jump is required")
+		}
+		config, err := newClientConfig(c.Option.forJump(j))
+		if err != nil {
+			return fmt.Errorf("failed to create ssh config of proxy jump %s: %v", j.Host, err)
+		}
+		ip, port := iputils.GetSSHHostIPAndPort(j.Host)
+		if j.Port > 0 {
+			port = strconv.Itoa(int(j.Port))
+		}
+		c.jumps = append(c.jumps, jumpHost{addr: net.JoinHostPort(ip, port), config: config})
+	}
+	return nil
+}
+
+// forJump returns the option of the bastion, the credentials of the target host are kept if none is set.
+func (o *Option) forJump(j v2.SSHJump) *Option {
+	opt := *o
+	opt.proxyJumps = nil
+	opt.proxy = ""
+	if j.User != "" {
+		opt.user = j.User
+	}
+	if j.Passwd != "" || j.Pk != "" || j.PkData != "" {
+		opt.password = j.Passwd
+		opt.privateKey = j.Pk
+		opt.rawPrivateKeyData = j.PkData
+		opt.passphrase = j.PkPasswd
+		opt.certificate = ""
+	}
+	return &opt
+}
+
+// dialThrough connects to the address through the proxy and the bastions in order, the connections
+// to the bastions are closed along with the returned client.
+func (c *Client) dialThrough(addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	dial := c.dial
+	var hops []*ssh.Client
+	closeHops := func() {
+		for i := len(hops) - 1; i >= 0; i-- {
+			_ = hops[i].Close()
+		}
+	}
+	for _, j := range c.jumps {
+		hop, err := dialSSH(dial, j.addr, j.config)
+		if err != nil {
+			closeHops()
+			return nil, fmt.Errorf("failed to connect proxy jump %s: %v", j.addr, err)
+		}
+		hops = append(hops, hop)
+		dial = hop.Dial
+	}
+	client, err := dialSSH(dial, addr, config)
+	if err != nil {
+		closeHops()
+		return nil, err
+	}
+	if len(hops) > 0 {
+		go func() {
+			_ = client.Wait()
+			closeHops()
+		}()
+	}
+	return client, nil
+}
+
+// dialSSH is the same as ssh.Dial except the connection is made by dial.
+func dialSSH(dial dialFunc, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	// the deadline is not supported by the connections forwarded by bastions, which is fine
+	if config.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(config.Timeout))
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// newProxyDialer returns the dialer of the SOCKS5 or HTTP CONNECT proxy of the url.
+func newProxyDialer(rawURL string, forward *net.Dialer) (dialFunc, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %s: %v", rawURL, err)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(u, forward)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %s: %v", rawURL, err)
+		}
+		return dialer.Dial, nil
+	case "http":
+		if u.Port() == "" {
+			u.Host = net.JoinHostPort(u.Hostname(), "80")
+		}
+		return (&httpConnectDialer{proxy: u, forward: forward}).Dial, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %s of proxy %s, must be socks5, socks5h or http", u.Scheme, rawURL)
+	}
+}
+
+// httpConnectDialer tunnels the connections through a proxy by the HTTP CONNECT method.
+type httpConnectDialer struct {
+	proxy   *url.URL
+	forward *net.Dialer
+}
+
+func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial(network, d.proxy.Host)
+	if err != nil {
+		return nil, err
+	}
+	if d.forward.Timeout > 0 {
+		_ = conn.SetDeadline(time.Now().Add(d.forward.Timeout))
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u := d.proxy.User; u != nil {
+		password, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err = req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to connect %s by proxy %s: %v", addr, d.proxy.Host, err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to connect %s by proxy %s: %v", addr, d.proxy.Host, err)
+	}
+	// the body of a successful response is the tunnel itself, so it is never read or closed
+	if resp.StatusCode != http.StatusOK {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to connect %s by proxy %s: %s", addr, d.proxy.Host, resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	// the ssh server might have sent its version along with the response
+	return &bufferedConn{Conn: conn, reader: br}, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,212 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/labring/sealos/pkg/system"
+	"github.com/labring/sealos/pkg/utils/logger"
+)
+
+const (
+	defaultMaxRetry         = 5
+	defaultRetryInterval    = 100 * time.Millisecond
+	defaultMaxRetryInterval = 5 * time.Second
+)
+
+// retryableErrors are the messages of the transient errors, the errors are matched by messages since
+// most of them are wrapped without their types.
+var retryableErrors = []string{
+	"connection reset by peer",
+	"connection refused",
+	"broken pipe",
+	"no route to host",
+	"i/o timeout",
+	"connection timed out",
+	"use of closed network connection",
+	"remote command exited without exit status or exit signal",
+	io.EOF.Error(),
+}
+
+// RetryPolicy is how the operations on remote hosts are retried on errors.
+type RetryPolicy struct {
+	// Attempts is the max number of attempts, the operation is never retried if it is less than 2.
+	Attempts int
+	// Interval is the delay before the first retry, it grows by Factor after each retry up to MaxInterval.
+	Interval    time.Duration
+	Factor      float64
+	MaxInterval time.Duration
+	// Retryable returns whether the error is worth retrying, IsRetryableError is used if it is nil.
+	Retryable func(error) bool
+}
+
+// NoRetry never retries the operations.
+var NoRetry = RetryPolicy{Attempts: 1}
+
+// DefaultRetryPolicy returns the policy set by the config of sealos, which retries the transient errors only.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:    getMaxRetry(),
+		Interval:    getRetryInterval(),
+		Factor:      2,
+		MaxInterval: defaultMaxRetryInterval,
+	}
+}
+
+// Do calls fn until it succeeds, the error is not retryable, the attempts are used up or ctx is done.
+// fn is never called once ctx is done, the last error of fn is returned if there is one.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryableError
+	}
+	interval := p.Interval
+	var err error
+	for attempt := 1; ; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if err != nil {
+				return err
+			}
+			return ctxErr
+		}
+		err = fn()
+		if err == nil || attempt >= p.Attempts || !retryable(err) {
+			return err
+		}
+		logger.Debug("retrying in %s due to error occur: %v", interval, err)
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if p.Factor > 1 {
+			interval = time.Duration(float64(interval) * p.Factor)
+		}
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+}
+
+// IsRetryableError returns whether the error is transient such as connection reset, the commands
+// exited with non-zero status are never retried.
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := err.Error()
+	for _, s := range retryableErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRetryPolicy returns the client which retries the operations by the policy instead of the default one.
+func WithRetryPolicy(client Interface, policy RetryPolicy) Interface {
+	switch c := client.(type) {
+	case *Client:
+		cp := *c
+		cp.retry = &policy
+		return &cp
+	case *clusterClient:
+		cc := newClusterClient(c.cluster, c.isStdout)
+		cc.retry = &policy
+		return cc
+	default:
+		return &retryClient{Interface: client, policy: policy}
+	}
+}
+
+// retryClient retries the operations of the clients which are not aware of the retry policies, the commands
+// are never retried since it is unknown whether they have been started on the hosts.
+type retryClient struct {
+	Interface
+	policy RetryPolicy
+}
+
+func (c *retryClient) Copy(ctx context.Context, host, src, dst string) error {
+	return c.policy.Do(ctx, func() error {
+		return c.Interface.Copy(ctx, host, src, dst)
+	})
+}
+
+func (c *retryClient) CopyR(ctx context.Context, host, dst, src string) error {
+	return c.policy.Do(ctx, func() error {
+		return c.Interface.CopyR(ctx, host, dst, src)
+	})
+}
+
+func (c *retryClient) Ping(ctx context.Context, host string) error {
+	return c.policy.Do(ctx, func() error {
+		return c.Interface.Ping(ctx, host)
+	})
+}
+
+func (c *Client) retryPolicy() RetryPolicy {
+	if c.retry != nil {
+		return *c.retry
+	}
+	return DefaultRetryPolicy()
+}
+
+func getMaxRetry() int {
+	if maxRetry > 0 {
+		return maxRetry
+	}
+	v, err := system.Get(system.SSHMaxRetryConfigKey)
+	if err != nil {
+		return defaultMaxRetry
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		logger.Warn("invalid %s %s, use %d instead", system.SSHMaxRetryConfigKey, v, defaultMaxRetry)
+		return defaultMaxRetry
+	}
+	return n
+}
+
+func getRetryInterval() time.Duration {
+	v, err := system.Get(system.SSHRetryIntervalConfigKey)
+	if err != nil {
+		return defaultRetryInterval
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		logger.Warn("invalid %s %s, use %s instead", system.SSHRetryIntervalConfigKey, v, defaultRetryInterval)
+		return defaultRetryInterval
+	}
+	return d
+}
@@ -0,0 +1,169 @@
+// Copyright © 2023 sealos.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "connection reset", err: fmt.Errorf("connect error: %v", syscall.ECONNRESET), want: true},
+		{name: "connection refused", err: fmt.Errorf("dial tcp 192.168.0.2:22: %w", syscall.ECONNREFUSED), want: true},
+		{name: "eof", err: fmt.Errorf("ssh: handshake failed: %v", io.EOF), want: true},
+		{name: "non-zero exit", err: fmt.Errorf("run command: %w", &ssh.ExitError{}), want: false},
+		{name: "non-zero exit with transient output", err: fmt.Errorf("output: connection refused, error: %w", &ssh.ExitError{}), want: false},
+		{name: "canceled", err: fmt.Errorf("run command: %w", context.Canceled), want: false},
+		{name: "authentication", err: errors.New("ssh: handshake failed: ssh: unable to authenticate"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := errors.New("connection reset by peer")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		policy RetryPolicy
+		errs   []error
+		// cancelAt cancels the context in the call of fn with the number if it is positive
+		cancelAt  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "retry transient errors until succeeded",
+			ctx:       context.Background(),
+			policy:    RetryPolicy{Attempts: 5, Factor: 2},
+			errs:      []error{transient, transient, nil},
+			wantCalls: 3,
+		},
+		{
+			name:      "attempts used up",
+			ctx:       context.Background(),
+			policy:    RetryPolicy{Attempts: 2},
+			errs:      []error{transient, transient, nil},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+		{
+			name:      "non-retryable error",
+			ctx:       context.Background(),
+			policy:    RetryPolicy{Attempts: 5},
+			errs:      []error{&ssh.ExitError{}, nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "custom retryable",
+			ctx:       context.Background(),
+			policy:    RetryPolicy{Attempts: 5, Retryable: func(err error) bool { return true }},
+			errs:      []error{&ssh.ExitError{}, nil},
+			wantCalls: 2,
+		},
+		{
+			name:      "no retry",
+			ctx:       context.Background(),
+			policy:    NoRetry,
+			errs:      []error{transient, nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "context done",
+			ctx:       canceled,
+			policy:    RetryPolicy{Attempts: 5},
+			errs:      []error{transient, nil},
+			wantCalls: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "context done after the first call",
+			ctx:       context.Background(),
+			policy:    RetryPolicy{Attempts: 5},
+			errs:      []error{transient, nil},
+			cancelAt:  1,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(tt.ctx)
+			defer cancel()
+			calls := 0
+			err := tt.policy.Do(ctx, func() error {
+				calls++
+				if calls == tt.cancelAt {
+					cancel()
+				}
+				return tt.errs[calls-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Do() calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// flakyClient fails to ping for the first failures times.
+type flakyClient struct {
+	Interface
+	failures int
+	pings    int
+}
+
+func (c *flakyClient) Ping(_ context.Context, host string) error {
+	c.pings++
+	if c.pings <= c.failures {
+		return fmt.Errorf("failed to connect %s: connection refused", host)
+	}
+	return nil
+}
+
+func TestWaitSSHReady(t *testing.T) {
+	t.Setenv("SEALOS_SSH_RETRY_INTERVAL", "1ms")
+	client := &flakyClient{failures: 2}
+	if err := WaitSSHReady(context.Background(), client, 3, "192.168.0.2"); err != nil || client.pings != 3 {
+		t.Errorf("WaitSSHReady() error = %v, pings = %d, want 3 pings", err, client.pings)
+	}
+	client = &flakyClient{failures: 2}
+	if err := WaitSSHReady(context.Background(), client, 2, "192.168.0.2"); err == nil || client.pings != 2 {
+		t.Errorf("WaitSSHReady() error = %v, pings = %d, want an error after 2 pings", err, client.pings)
+	}
+}
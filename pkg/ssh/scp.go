@@ -15,6 +15,7 @@
 package ssh
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -22,7 +23,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -38,9 +38,9 @@ import (
 	"github.com/labring/sealos/pkg/utils/progress"
 )
 
-func (c *Client) RemoteSha256Sum(host, remoteFilePath string) string {
+func (c *Client) RemoteSha256Sum(ctx context.Context, host, remoteFilePath string) string {
 	cmd := fmt.Sprintf("sha256sum %s | cut -d\" \" -f1", remoteFilePath)
-	remoteHash, err := c.CmdToString(host, cmd, "")
+	remoteHash, err := c.CmdToString(ctx, host, cmd, "")
 	if err != nil {
 		logger.Error("failed to calculate remote sha256 sum %s %s %v", host, remoteFilePath, err)
 	}
@@ -52,9 +52,9 @@ func getOnelineResult(output string, sep string) string {
 }
 
 // CmdToString execute command on host and replace output with sep to oneline
-func (c *Client) CmdToString(host, cmd, sep string) (string, error) {
+func (c *Client) CmdToString(ctx context.Context, host, cmd, sep string) (string, error) {
 	logger.Debug("start to exec remote %s shell: %s", host, cmd)
-	output, err := c.Cmd(host, cmd)
+	output, err := c.Cmd(ctx, host, cmd)
 	data := string(output)
 	if err != nil {
 		return data, err
@@ -65,38 +65,36 @@ func (c *Client) CmdToString(host, cmd, sep string) (string, error) {
 	return getOnelineResult(data, sep), nil
 }
 
-func (c *Client) newClientAndSftpClient(host string) (*ssh.Client, *sftp.Client, error) {
-	sshClient, err := c.connect(host)
-	if err != nil {
-		return nil, nil, err
-	}
-	// create sftp client
-	sftpClient, err := sftp.NewClient(sshClient)
-	return sshClient, sftpClient, err
-}
-
-func (c *Client) sftpConnect(host string) (sshClient *ssh.Client, sftpClient *sftp.Client, err error) {
-	err = exponentialBackOffRetry(defaultMaxRetry, time.Millisecond*100, 2, func() error {
-		sshClient, sftpClient, err = c.newClientAndSftpClient(host)
+// sftpConnect returns a sftp client on a pooled connection to the host, release must be called once
+// the sftp client is closed.
+func (c *Client) sftpConnect(host string) (sftpClient *sftp.Client, release func(), err error) {
+	release, err = c.pooled(host, func(client *ssh.Client) (err error) {
+		sftpClient, err = sftp.NewClient(client)
 		return err
-	}, isErrorWorthRetry)
-	return
+	})
+	return sftpClient, release, err
 }
 
 // Copy is copy file or dir to remotePath, add md5 validate
-func (c *Client) Copy(host, localPath, remotePath string) error {
+func (c *Client) Copy(ctx context.Context, host, localPath, remotePath string) error {
 	if c.isLocalAction(host) {
 		logger.Debug("local %s copy files src %s to dst %s", host, localPath, remotePath)
 		return file.RecursionCopy(localPath, remotePath)
 	}
 	logger.Debug("remote copy files src %s to dst %s", localPath, remotePath)
-	sshClient, sftpClient, err := c.sftpConnect(host)
+	return c.retryPolicy().Do(ctx, func() error {
+		return c.sftpCopy(ctx, host, localPath, remotePath)
+	})
+}
+
+func (c *Client) sftpCopy(ctx context.Context, host, localPath, remotePath string) error {
+	sftpClient, release, err := c.sftpConnect(host)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %s", err)
 	}
 	defer func() {
 		_ = sftpClient.Close()
-		_ = sshClient.Close()
+		release()
 	}()
 
 	f, err := os.Stat(localPath)
@@ -129,22 +127,28 @@ func (c *Client) Copy(host, localPath, remotePath string) error {
 		_ = bar.Close()
 	}()
 
-	return c.doCopy(sftpClient, host, localPath, remotePath, bar)
+	return c.doCopy(ctx, sftpClient, host, localPath, remotePath, bar)
 }
 
-func (c *Client) CopyR(host, localPath, remotePath string) error {
+func (c *Client) CopyR(ctx context.Context, host, localPath, remotePath string) error {
 	if c.isLocalAction(host) {
 		logger.Debug("local %s copy files src %s to dst %s", host, remotePath, localPath)
 		return file.RecursionCopy(remotePath, localPath)
 	}
 	logger.Debug("remote fetch files src %s to dst %s", remotePath, localPath)
-	sshClient, sftpClient, err := c.sftpConnect(host)
+	return c.retryPolicy().Do(ctx, func() error {
+		return c.sftpCopyR(host, localPath, remotePath)
+	})
+}
+
+func (c *Client) sftpCopyR(host, localPath, remotePath string) error {
+	sftpClient, release, err := c.sftpConnect(host)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %s", err)
 	}
 	defer func() {
 		_ = sftpClient.Close()
-		_ = sshClient.Close()
+		release()
 	}()
 
 	srcFile, err := sftpClient.Open(remotePath)
@@ -178,7 +182,11 @@ func (c *Client) CopyR(host, localPath, remotePath string) error {
 	return err
 }
 
-func (c *Client) doCopy(client *sftp.Client, host, src, dest string, epu *progressbar.ProgressBar) error {
+func (c *Client) doCopy(ctx context.Context, client *sftp.Client, host, src, dest string, epu *progressbar.ProgressBar) error {
+	// the files are checked one by one, so a long copy of a dir stops soon after ctx is done
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("copy %s to %s on %s: %w", src, dest, host, err)
+	}
 	lfp, err := os.Stat(src)
 	if err != nil {
 		return fmt.Errorf("failed to Stat local: %v", err)
@@ -192,7 +200,7 @@ func (c *Client) doCopy(client *sftp.Client, host, src, dest string, epu *progre
 			return fmt.Errorf("failed to Mkdir remote: %v", err)
 		}
 		for _, entry := range entries {
-			if err = c.doCopy(client, host, path.Join(src, entry.Name()), path.Join(dest, entry.Name()), epu); err != nil {
+			if err = c.doCopy(ctx, client, host, path.Join(src, entry.Name()), path.Join(dest, entry.Name()), epu); err != nil {
 				return err
 			}
 		}
@@ -206,7 +214,7 @@ func (c *Client) doCopy(client *sftp.Client, host, src, dest string, epu *progre
 		}
 		if isCheckFileMD5() && fn(host, dest) {
 			rfp, _ := client.Stat(dest)
-			if lfp.Size() == rfp.Size() && hash.FileDigest(src) == c.RemoteSha256Sum(host, dest) {
+			if lfp.Size() == rfp.Size() && hash.FileDigest(src) == c.RemoteSha256Sum(ctx, host, dest) {
 				logger.Debug("remote dst %s already exists and is the latest version, skip copying process", dest)
 				return nil
 			}
@@ -229,7 +237,7 @@ func (c *Client) doCopy(client *sftp.Client, host, src, dest string, epu *progre
 			return fmt.Errorf("failed to Copy: %v", err)
 		}
 		if isCheckFileMD5() {
-			dh := c.RemoteSha256Sum(host, dest)
+			dh := c.RemoteSha256Sum(ctx, host, dest)
 			if dh == "" {
 				// when ssh connection failed, remote sha256 is default to "", so ignore it.
 				return nil
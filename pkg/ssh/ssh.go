@@ -16,6 +16,7 @@ package ssh
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"sync"
 
@@ -23,33 +24,37 @@ import (
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/labring/sealos/pkg/system"
 	v2 "github.com/labring/sealos/pkg/types/v1beta1"
-	fileutils "github.com/labring/sealos/pkg/utils/file"
 	"github.com/labring/sealos/pkg/utils/iputils"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
-var defaultMaxRetry = 5
+// maxRetry overrides the config of the max number of attempts if it is set by the flag
+var maxRetry int
 
 func RegisterFlags(fs *pflag.FlagSet) {
-	fs.IntVar(&defaultMaxRetry, "max-retry", defaultMaxRetry, "define max num of ssh retry times")
+	fs.IntVar(&maxRetry, "max-retry", maxRetry,
+		fmt.Sprintf("max number of attempts of ssh operations on transient errors, it overrides the config %s if set", system.SSHMaxRetryConfigKey))
 }
 
 type Interface interface {
 	// Copy copy local file to remote
 	// scp -r /tmp root@192.168.0.2:/root/tmp => Copy("192.168.0.2","tmp","/root/tmp")
 	// skip checksum if env DO_NOT_CHECKSUM=true
-	Copy(host, src, dst string) error
+	Copy(ctx context.Context, host, src, dst string) error
 	// CopyR copy remote file to local
 	// scp -r root@192.168.0.2:/root/tmp/file /tmp/file => Copy("192.168.0.2","/tmp/file","/root/tmp/file")
-	CopyR(host, dst, src string) error
-	// CmdAsync exec commands on remote host asynchronously
-	CmdAsync(host string, cmds ...string) error
+	CopyR(ctx context.Context, host, dst, src string) error
+	// CmdAsync exec commands on remote host asynchronously,
+	// the remote session is interrupted once ctx is done.
+	CmdAsync(ctx context.Context, host string, cmds ...string) error
 	// Cmd exec command on remote host, and return combined standard output and standard error
-	Cmd(host, cmd string) ([]byte, error)
+	Cmd(ctx context.Context, host, cmd string) ([]byte, error)
 	// CmdToString exec command on remote host, and return spilt standard output by separator and standard error
-	CmdToString(host, cmd, spilt string) (string, error)
-	Ping(host string) error
+	CmdToString(ctx context.Context, host, cmd, spilt string) (string, error)
+	// Ping checks whether the host is connectable, the retries of all the methods stop once ctx is done.
+	Ping(ctx context.Context, host string) error
 }
 
 var (
@@ -71,6 +76,11 @@ func getLocalAddresses() *[]net.Addr {
 type Client struct {
 	*ssh.ClientConfig
 	*Option
+	// dial connects to the first hop, which is either the first bastion or the target host
+	dial  dialFunc
+	jumps []jumpHost
+	// retry overrides the default retry policy if set
+	retry *RetryPolicy
 }
 
 var _ Interface = &Client{}
@@ -96,6 +106,18 @@ func newFromOptions(opt *Option, opts ...OptionFunc) (*Client, error) {
 		opts[i](opt)
 	}
 
+	config, err := newClientConfig(opt)
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{ClientConfig: config, Option: opt}
+	if err = client.setupProxy(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func newClientConfig(opt *Option) (*ssh.ClientConfig, error) {
 	config := &ssh.ClientConfig{
 		Config: ssh.Config{
 			Ciphers: defaultCiphers,
@@ -105,27 +127,27 @@ func newFromOptions(opt *Option, opts ...OptionFunc) (*Client, error) {
 		Auth:            []ssh.AuthMethod{},
 		HostKeyCallback: opt.hostKeyCallback,
 	}
-	if len(opt.password) > 0 {
-		config.Auth = append(config.Auth, ssh.Password(opt.password))
-	}
-	if len(opt.rawPrivateKeyData) > 0 {
-		signer, err := parsePrivateKey([]byte(opt.rawPrivateKeyData), []byte(opt.passphrase))
+	if opt.knownHostsFile != "" {
+		policy, err := GetHostKeyPolicy()
 		if err != nil {
 			return nil, err
 		}
-		config.Auth = append(config.Auth, ssh.PublicKeys(signer))
-	} else if len(opt.privateKey) > 0 {
-		if !fileutils.IsExist(opt.privateKey) {
-			logger.Debug("not trying to parse private key file cause it's not exists")
-		} else {
-			signer, err := parsePrivateKeyFile(opt.privateKey, opt.passphrase)
-			if err != nil {
-				return nil, err
-			}
-			config.Auth = append(config.Auth, ssh.PublicKeys(signer))
-		}
+		config.HostKeyCallback = newKnownHostsCallback(opt.knownHostsFile, policy)
+	}
+	if len(opt.password) > 0 {
+		config.Auth = append(config.Auth, ssh.Password(opt.password))
+	}
+	signers, err := loadSigners(opt)
+	if err != nil {
+		return nil, err
 	}
-	return &Client{ClientConfig: config, Option: opt}, nil
+	// the publickey method is tried only once, so the keys of the agent are offered by the same method
+	if len(signers) > 0 || opt.agentSocket != "" {
+		config.Auth = append(config.Auth, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			return append(append([]ssh.Signer{}, signers...), agentSigners(opt.agentSocket)...), nil
+		}))
+	}
+	return config, nil
 }
 
 func newOptionFromSSH(ssh *v2.SSH, isStdout bool) *Option {
@@ -144,6 +166,15 @@ func newOptionFromSSH(ssh *v2.SSH, isStdout bool) *Option {
 	if len(ssh.PkData) > 0 {
 		opts = append(opts, WithRawPrivateKeyDataAndPhrase(ssh.PkData, ssh.PkPasswd))
 	}
+	if len(ssh.PkCert) > 0 {
+		opts = append(opts, WithCertificate(ssh.PkCert))
+	}
+	if len(ssh.ProxyJump) > 0 {
+		opts = append(opts, WithProxyJump(ssh.ProxyJump...))
+	}
+	if len(ssh.Proxy) > 0 {
+		opts = append(opts, WithProxy(ssh.Proxy))
+	}
 	opt := NewOption()
 	for i := range opts {
 		opts[i](opt)
@@ -163,24 +194,33 @@ func NewSSHClient(ssh *v2.SSH, isStdout bool) Interface {
 	return client
 }
 
+// NewSSHClientWithCluster returns a client with the ssh config of the cluster overridden by each host,
+// including the proxies, the host keys are verified by the known_hosts file of the cluster.
+func NewSSHClientWithCluster(cluster *v2.Cluster, isStdout bool) Interface {
+	return newClusterClient(cluster, isStdout)
+}
+
 func NewSSHByCluster(cluster *v2.Cluster, isStdout bool) (Interface, error) {
-	cc := &clusterClient{
-		cluster:  cluster,
-		isStdout: isStdout,
-		configs:  make(map[string]*Option),
-		cache:    make(map[*Option]Interface),
-	}
+	cc := newClusterClient(cluster, isStdout)
 	var ipList []string
 	ipList = append(ipList, append(cluster.GetIPSByRole(v2.MASTER), cluster.GetIPSByRole(v2.NODE)...)...)
-	return cc, WaitSSHReady(cc, defaultMaxRetry, ipList...)
+	return cc, WaitSSHReady(context.Background(), cc, 0, ipList...)
 }
 
-func WaitSSHReady(client Interface, _ int, hosts ...string) error {
-	eg, _ := errgroup.WithContext(context.Background())
+// WaitSSHReady pings the hosts, each of them is tried at most retry times on transient errors,
+// the default retry policy is used if retry is not positive.
+func WaitSSHReady(ctx context.Context, client Interface, retry int, hosts ...string) error {
+	if retry > 0 {
+		policy := DefaultRetryPolicy()
+		policy.Attempts = retry
+		client = WithRetryPolicy(client, policy)
+	}
+	eg, _ := errgroup.WithContext(ctx)
+	eg.SetLimit(system.GetParallelism())
 	for i := range hosts {
 		host := hosts[i]
 		eg.Go(func() (err error) {
-			return client.Ping(host)
+			return client.Ping(ctx, host)
 		})
 	}
 	return eg.Wait()
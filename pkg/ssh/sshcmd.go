@@ -24,22 +24,42 @@ import (
 	"strings"
 	"sync"
 
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/labring/sealos/pkg/utils/exec"
 	"github.com/labring/sealos/pkg/utils/logger"
 )
 
-func (c *Client) Ping(host string) error {
+// commandOutput is where the output of commands is printed to if the client is created with isStdout
+var commandOutput io.Writer = os.Stdout
+
+// SetCommandOutput prints the output of commands to w instead of stdout.
+func SetCommandOutput(w io.Writer) {
+	commandOutput = w
+}
+
+func (c *Client) Ping(ctx context.Context, host string) error {
 	if c.isLocalAction(host) {
 		logger.Debug("host %s is local, ping is always true", host)
 		return nil
 	}
-	client, _, err := c.Connect(host)
+	err := c.retryPolicy().Do(ctx, func() error {
+		// a pooled connection might have been broken since the last keepalive, so it is checked by a request
+		release, err := c.pooled(host, func(client *ssh.Client) error {
+			_, _, err := client.SendRequest(keepAliveRequestKey, true, nil)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		release()
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect %s: %v", host, err)
 	}
-	return client.Close()
+	return nil
 }
 
 // wrapCommands just placeholder for now
@@ -52,18 +72,25 @@ func (c *Client) wrapCommands(cmds ...string) string {
 }
 
 // CmdAsync not actually asynchronously, just print output asynchronously
-func (c *Client) CmdAsync(host string, cmds ...string) error {
+func (c *Client) CmdAsync(ctx context.Context, host string, cmds ...string) error {
 	cmd := c.wrapCommands(cmds...)
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("skip command `%s` on %s: %w", cmd, host, err)
+	}
 	if c.isLocalAction(host) {
 		logger.Debug("start to run command `%s` via exec", cmd)
-		return exec.Cmd("bash", "-c", cmd)
+		return exec.CmdContextWithOutput(ctx, commandOutput, "bash", "-c", cmd)
 	}
 	logger.Debug("start to exec `%s` on %s", cmd, host)
-	client, session, err := c.Connect(host)
+	return c.cmdAsync(ctx, host, cmd)
+}
+
+func (c *Client) cmdAsync(ctx context.Context, host, cmd string) error {
+	session, release, err := c.retrySession(ctx, host)
 	if err != nil {
 		return fmt.Errorf("connect error: %v", err)
 	}
-	defer client.Close()
+	defer release()
 	defer session.Close()
 	stdout, err := session.StdoutPipe()
 	if err != nil {
@@ -89,27 +116,47 @@ func (c *Client) CmdAsync(host string, cmds ...string) error {
 	if err := session.Start(cmd); err != nil {
 		return fmt.Errorf("start command `%s` on %s: %v", cmd, host, err)
 	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			logger.Debug("interrupt command `%s` on %s", cmd, host)
+			// closing the session hangs up the pty, so the remote process
+			// is terminated even if it ignores SIGINT.
+			_ = session.Signal(ssh.SIGINT)
+			_ = session.Close()
+		case <-done:
+		}
+	}()
 	if err = eg.Wait(); err != nil {
 		return err
 	}
 	if err = session.Wait(); err != nil {
-		return fmt.Errorf("run command `%s` on %s, output: %s, error: %v,", cmd, host, out.b.String(), err)
+		if ctx.Err() != nil {
+			return fmt.Errorf("run command `%s` on %s: %w", cmd, host, ctx.Err())
+		}
+		return fmt.Errorf("run command `%s` on %s, output: %s, error: %w,", cmd, host, out.b.String(), err)
 	}
 	return nil
 }
 
-func (c *Client) Cmd(host, cmd string) ([]byte, error) {
+func (c *Client) Cmd(ctx context.Context, host, cmd string) ([]byte, error) {
 	cmd = c.wrapCommands(cmd)
 	if c.isLocalAction(host) {
 		logger.Debug("host %s is local, command via exec", host)
 		d, err := exec.RunBashCmd(cmd)
 		return []byte(d), err
 	}
-	client, session, err := c.Connect(host)
+	return c.cmd(ctx, host, cmd)
+}
+
+func (c *Client) cmd(ctx context.Context, host, cmd string) ([]byte, error) {
+	session, release, err := c.retrySession(ctx, host)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ssh session for %s: %v", host, err)
 	}
-	defer client.Close()
+	defer release()
 	defer session.Close()
 	in, err := session.StdinPipe()
 	if err != nil {
@@ -177,7 +224,7 @@ func (c *Client) handlePipe(host string, pipe io.Reader, out io.Writer, isStdout
 	r := bufio.NewReader(pipe)
 	writers := []io.Writer{out}
 	if isStdout {
-		writers = append(writers, &withPrefixWriter{prefix: host + "\t", newline: true, w: os.Stdout})
+		writers = append(writers, &withPrefixWriter{prefix: host + "\t", newline: true, w: commandOutput})
 	}
 	w := io.MultiWriter(writers...)
 	var line []byte
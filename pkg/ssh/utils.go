@@ -17,24 +17,25 @@ limitations under the License.
 package ssh
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 )
 
-func CopyDir(sshClient Interface, host, src, dest string, filter func(fs.DirEntry) bool) error {
+func CopyDir(ctx context.Context, sshClient Interface, host, src, dest string, filter func(fs.DirEntry) bool) error {
 	entries, err := os.ReadDir(src)
 	if err != nil {
 		return fmt.Errorf("failed to read dir entries %s", err)
 	}
 	// Copy empty dir anyway
 	if len(entries) == 0 {
-		return sshClient.Copy(host, src, dest)
+		return sshClient.Copy(ctx, host, src, dest)
 	}
 	for _, f := range entries {
 		if filter == nil || filter(f) {
-			err = sshClient.Copy(host, filepath.Join(src, f.Name()), filepath.Join(dest, f.Name()))
+			err = sshClient.Copy(ctx, host, filepath.Join(src, f.Name()), filepath.Join(dest, f.Name()))
 			if err != nil {
 				return fmt.Errorf("failed to copy entry %s -> %s to %s: %v", filepath.Join(src, f.Name()), filepath.Join(dest, f.Name()), host, err)
 			}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 
 	"github.com/containers/buildah"
 	"github.com/containers/storage/pkg/homedir"
@@ -82,14 +83,57 @@ var configOptions = []ConfigOption{
 		OSEnv:         "SEALOS_SCP_CHECKSUM",
 		AllowedValues: []string{"true", "false"},
 	},
+	{
+		Key:          ParallelismConfigKey,
+		Description:  "max number of hosts to operate concurrently, e.g. copying files and joining nodes, 0 means no limit.",
+		DefaultValue: "20",
+		OSEnv:        "SEALOS_PARALLELISM",
+	},
+	{
+		Key:           HostKeyPolicyConfigKey,
+		Description:   "how to verify the host keys of ssh connections by the known_hosts file of the cluster, strict rejects unknown hosts, accept-new records the hosts on first contact, insecure accepts any keys.",
+		DefaultValue:  "accept-new",
+		OSEnv:         "SEALOS_SSH_HOST_KEY_POLICY",
+		AllowedValues: []string{"strict", "accept-new", "insecure"},
+	},
+	{
+		Key:          SSHMaxSessionsConfigKey,
+		Description:  "max number of sessions multiplexed on a pooled ssh connection, 0 disables the connection pool.",
+		DefaultValue: "8",
+		OSEnv:        "SEALOS_SSH_MAX_SESSIONS",
+	},
+	{
+		Key:          SSHIdleTimeoutConfigKey,
+		Description:  "how long an idle pooled ssh connection is kept before it is closed.",
+		DefaultValue: "1m",
+		OSEnv:        "SEALOS_SSH_IDLE_TIMEOUT",
+	},
+	{
+		Key:          SSHMaxRetryConfigKey,
+		Description:  "max number of attempts of ssh operations on transient errors such as connection reset, 1 means no retry.",
+		DefaultValue: "5",
+		OSEnv:        "SEALOS_SSH_MAX_RETRY",
+	},
+	{
+		Key:          SSHRetryIntervalConfigKey,
+		Description:  "delay before the first retry of ssh operations, which is doubled after each retry.",
+		DefaultValue: "100ms",
+		OSEnv:        "SEALOS_SSH_RETRY_INTERVAL",
+	},
 }
 
 const (
-	PromptConfigKey        = "prompt"
-	RuntimeRootConfigKey   = "sealos_runtime_root"
-	DataRootConfigKey      = "sealos_data_root"
-	BuildahFormatConfigKey = "buildah_format"
-	ScpCheckSumConfigKey   = "scp_check_sum"
+	PromptConfigKey           = "prompt"
+	RuntimeRootConfigKey      = "sealos_runtime_root"
+	DataRootConfigKey         = "sealos_data_root"
+	BuildahFormatConfigKey    = "buildah_format"
+	ScpCheckSumConfigKey      = "scp_check_sum"
+	ParallelismConfigKey      = "parallelism"
+	HostKeyPolicyConfigKey    = "ssh_host_key_policy"
+	SSHMaxSessionsConfigKey   = "ssh_max_sessions"
+	SSHIdleTimeoutConfigKey   = "ssh_idle_timeout"
+	SSHMaxRetryConfigKey      = "ssh_max_retry"
+	SSHRetryIntervalConfigKey = "ssh_retry_interval"
 )
 
 func (*envSystemConfig) getValueOrDefault(key string) (string, error) {
@@ -120,11 +164,26 @@ func (*envSystemConfig) setValue(key, value string) error {
 				}
 				return fmt.Errorf("value %s is not allowed for key %s", value, key)
 			}
+			return os.Setenv(option.OSEnv, value)
 		}
 	}
 	return nil
 }
 
+// GetParallelism returns the max number of hosts to operate concurrently,
+// a negative number means no limit, which is able to be passed to errgroup.Group.SetLimit directly.
+func GetParallelism() int {
+	v, err := Get(ParallelismConfigKey)
+	if err != nil {
+		return -1
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return -1
+	}
+	return n
+}
+
 func ConfigOptions() []ConfigOption {
 	return configOptions
 }
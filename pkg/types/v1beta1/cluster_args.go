@@ -56,6 +56,19 @@ func (c *Cluster) GetNodeIPAndPortList() []string {
 	return c.GetIPSByRole(NODE)
 }
 
+func (c *Cluster) GetEtcdIPList() []string {
+	return iputils.GetHostIPs(c.GetIPSByRole(ETCD))
+}
+
+func (c *Cluster) GetEtcdIPAndPortList() []string {
+	return c.GetIPSByRole(ETCD)
+}
+
+// IsExternalEtcd returns true if etcd runs on dedicated hosts instead of being stacked on the masters.
+func (c *Cluster) IsExternalEtcd() bool {
+	return len(c.GetEtcdIPAndPortList()) > 0
+}
+
 func (c *Cluster) GetRegistryIP() string {
 	return iputils.GetHostIP(c.GetRegistryIPAndPort())
 }
@@ -144,6 +157,18 @@ func (c *Cluster) GetRootfsImage(defaultMount string) *MountImage {
 	return image
 }
 
+// GetDistribution returns the kubernetes distribution of the cluster, the one declared in Clusterfile
+// takes precedence over the label of the rootfs image.
+func (c *Cluster) GetDistribution() Distribution {
+	if c.Spec.Distribution != "" {
+		return c.Spec.Distribution
+	}
+	if d := c.GetRootfsImage("").Labels[ImageDistributionKey]; d != "" {
+		return Distribution(d)
+	}
+	return KubeadmDistribution
+}
+
 func (c *Cluster) FindImage(targetImage string) *MountImage {
 	var image *MountImage
 	if c.Status.Mounts != nil {
@@ -176,6 +201,61 @@ func (c *Cluster) SetMountImage(targetMount *MountImage) {
 	}
 }
 
+// RemoveRootfsImage removes the rootfs images of the kubernetes version from both spec and status,
+// it does nothing if there is no other rootfs image left. The removed mounts are returned.
+func (c *Cluster) RemoveRootfsImage(version string) []MountImage {
+	hasOther := false
+	for _, img := range c.Status.Mounts {
+		if img.Type == RootfsImage && img.Labels[ImageKubeVersionKey] != version {
+			hasOther = true
+		}
+	}
+	if !hasOther {
+		return nil
+	}
+	var mounts, removed []MountImage
+	removedImages := make(map[string]struct{})
+	for _, img := range c.Status.Mounts {
+		if img.Type == RootfsImage && img.Labels[ImageKubeVersionKey] == version {
+			removed = append(removed, img)
+			removedImages[img.ImageName] = struct{}{}
+			continue
+		}
+		mounts = append(mounts, img)
+	}
+	c.Status.Mounts = mounts
+	var images ImageList
+	for _, img := range c.Spec.Image {
+		if _, ok := removedImages[img]; !ok {
+			images = append(images, img)
+		}
+	}
+	c.Spec.Image = images
+	return removed
+}
+
+// RemoveAppImage removes the app image from both spec and status, the removed mount is returned.
+func (c *Cluster) RemoveAppImage(image string) *MountImage {
+	var removed *MountImage
+	var mounts []MountImage
+	for i := range c.Status.Mounts {
+		if c.Status.Mounts[i].Type == AppImage && c.Status.Mounts[i].ImageName == image {
+			removed = c.Status.Mounts[i].DeepCopy()
+			continue
+		}
+		mounts = append(mounts, c.Status.Mounts[i])
+	}
+	c.Status.Mounts = mounts
+	var images ImageList
+	for _, img := range c.Spec.Image {
+		if img != image {
+			images = append(images, img)
+		}
+	}
+	c.Spec.Image = images
+	return removed
+}
+
 func (c *Cluster) ReplaceRootfsImage() {
 	i1, i2 := -1, -1
 	var v1, v2 string
@@ -266,6 +346,19 @@ func (c *Cluster) HasAppImage() bool {
 	return false
 }
 
+// GetHostByIP returns the host group which the ip (with or without port) belongs to, nil if not found.
+func (c *Cluster) GetHostByIP(ip string) *Host {
+	ip = iputils.GetHostIP(ip)
+	for i := range c.Spec.Hosts {
+		for _, addr := range c.Spec.Hosts[i].IPS {
+			if iputils.GetHostIP(addr) == ip {
+				return &c.Spec.Hosts[i]
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Cluster) GetRolesByIP(ip string) []string {
 	var routes []string
 	for _, host := range c.Spec.Hosts {
@@ -24,6 +24,8 @@ var (
 	MASTER   = "master"
 	NODE     = "node"
 	REGISTRY = "registry"
+	// ETCD is the role of the hosts which run external etcd members instead of the masters.
+	ETCD = "etcd"
 )
 
 type Arch string
@@ -104,6 +104,43 @@ func DeleteCondition(conditions []ClusterCondition, conditionType string) []Clus
 	return conditions
 }
 
+// IsStepSucceeded returns true if the named step has succeeded in the checkpoint.
+func (c *Checkpoint) IsStepSucceeded(name string) bool {
+	if c == nil {
+		return false
+	}
+	for _, step := range c.Steps {
+		if step.Name == name {
+			return step.Phase == StepSucceeded
+		}
+	}
+	return false
+}
+
+// GetSucceededHosts returns the hosts on which the named step has succeeded.
+func (c *Checkpoint) GetSucceededHosts(name string) []string {
+	if c == nil {
+		return nil
+	}
+	for _, step := range c.Steps {
+		if step.Name == name {
+			return step.SucceededHosts
+		}
+	}
+	return nil
+}
+
+// UpdateStep replaces the result of the same named step, adds it if not existed.
+func (c *Checkpoint) UpdateStep(step StepCheckpoint) {
+	for i := range c.Steps {
+		if c.Steps[i].Name == step.Name {
+			c.Steps[i] = step
+			return
+		}
+	}
+	c.Steps = append(c.Steps, step)
+}
+
 func In(key string, slice []string) bool {
 	for _, s := range slice {
 		if key == s {
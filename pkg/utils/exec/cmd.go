@@ -15,7 +15,9 @@
 package exec
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -32,6 +34,21 @@ func Cmd(name string, args ...string) error {
 	return cmd.Run()
 }
 
+// CmdContext is like Cmd but the process is killed once ctx is done.
+func CmdContext(ctx context.Context, name string, args ...string) error {
+	return CmdContextWithOutput(ctx, os.Stdout, name, args...)
+}
+
+// CmdContextWithOutput is like CmdContext but the standard output of the process is written to stdout.
+func CmdContextWithOutput(ctx context.Context, stdout io.Writer, name string, args ...string) error {
+	// nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
+	cmd := exec.CommandContext(ctx, name, args[:]...) // #nosec
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdout
+	return cmd.Run()
+}
+
 func Output(name string, args ...string) ([]byte, error) {
 	// nosemgrep: go.lang.security.audit.dangerous-exec-command.dangerous-exec-command
 	cmd := exec.Command(name, args[:]...) // #nosec
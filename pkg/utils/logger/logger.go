@@ -27,6 +27,8 @@ import (
 
 var (
 	defaultLogger *zap.Logger
+	// consoleOutput is where the console logs are written to
+	consoleOutput = os.Stdout
 )
 
 // init default logger with only console output info above
@@ -35,6 +37,12 @@ func init() {
 	defaultLogger = zap.New(zc)
 }
 
+// SetConsoleOutput writes the console logs to f instead of stdout, it takes effect
+// when the logger is configured next time.
+func SetConsoleOutput(f *os.File) {
+	consoleOutput = f
+}
+
 // CfgConsoleLogger config for console logs
 // cfg donot support concurrent calls (as any package should init cfg at startup once)
 func CfgConsoleLogger(debugMode bool, showPath bool) {
@@ -76,7 +84,7 @@ func genConfigs(debugMode bool, showPath bool) (zapcore.LevelEnabler, []zap.Opti
 }
 
 func newConsoleCore(le zapcore.LevelEnabler) zapcore.Core {
-	consoleLogger := zapcore.Lock(os.Stdout)
+	consoleLogger := zapcore.Lock(consoleOutput)
 
 	zec := zap.NewProductionEncoderConfig()
 	zec.EncodeLevel = zapcore.LowercaseColorLevelEncoder
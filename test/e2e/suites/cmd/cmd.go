@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"os"
 	"os/exec"
@@ -26,19 +27,19 @@ func (c RemoteCmd) Exec(cmd string, args ...string) ([]byte, error) {
 	if c.Interface == nil {
 		return nil, errors.New("SSHInterface not initialized")
 	}
-	return c.Cmd(c.Host, strings.Join(append([]string{cmd}, args...), " "))
+	return c.Cmd(context.Background(), c.Host, strings.Join(append([]string{cmd}, args...), " "))
 }
 
 func (c RemoteCmd) AsyncExec(cmd string, args ...string) error {
-	return c.CmdAsync(c.Host, strings.Join(append([]string{cmd}, args...), " "))
+	return c.CmdAsync(context.Background(), c.Host, strings.Join(append([]string{cmd}, args...), " "))
 }
 
 func (c RemoteCmd) Copy(src string, dst string) error {
-	return c.Interface.Copy(c.Host, src, dst)
+	return c.Interface.Copy(context.Background(), c.Host, src, dst)
 }
 
 func (c RemoteCmd) CopyR(dst string, src string) error {
-	return c.Interface.CopyR(c.Host, dst, src)
+	return c.Interface.CopyR(context.Background(), c.Host, dst, src)
 }
 
 // RemoteCmd implements the Interface for remote command execution using SSH